@@ -37,12 +37,13 @@ VERSION=${VERSION:-dev}
 COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo "unknown")
 DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
 
-# Build the binary with version information
+# Build the binary with version information; sqlite_fts5 enables the
+# full-text index behind the library search
 echo "Building binary (version: $VERSION)..."
-go build -ldflags "-X github.com/haryoiro/yutemal/internal/version.Version=$VERSION \
+go build -tags sqlite_fts5 -ldflags "-X github.com/haryoiro/yutemal/internal/version.Version=$VERSION \
     -X github.com/haryoiro/yutemal/internal/version.Commit=$COMMIT \
     -X github.com/haryoiro/yutemal/internal/version.Date=$DATE" \
-    -o yutemal main.go
+    -o yutemal .
 
 if [ $? -eq 0 ]; then
     echo "Build successful! Binary created: ./yutemal"
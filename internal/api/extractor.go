@@ -95,6 +95,7 @@ func extractTrackFromItem(value any) *TrackRef {
 	artists := findArtists(obj)
 	duration := findDuration(obj)
 	thumbnail := findThumbnail(obj)
+	album := findAlbum(obj)
 
 	return &TrackRef{
 		TrackID:     trackID,
@@ -102,6 +103,7 @@ func extractTrackFromItem(value any) *TrackRef {
 		Artists:     artists,
 		Duration:    duration,
 		Thumbnail:   thumbnail,
+		Album:       album,
 		IsAvailable: true,
 	}
 }
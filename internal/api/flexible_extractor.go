@@ -142,6 +142,38 @@ func parseArtistsFromString(subtitle string) []string {
 	return []string{parts[0]}
 }
 
+// findAlbum searches the flex columns for a run that links to an album page.
+func findAlbum(obj map[string]any) string {
+	flexCols, ok := obj["flexColumns"].([]any)
+	if !ok {
+		return ""
+	}
+
+	for i := range flexCols {
+		runs, ok := getPath(obj, "flexColumns", i, "musicResponsiveListItemFlexColumnRenderer", "text", "runs").([]any)
+		if !ok {
+			continue
+		}
+
+		for _, runItem := range runs {
+			runObj, runOK := runItem.(map[string]any)
+			if !runOK {
+				continue
+			}
+
+			pageType := getPathString(runObj, "navigationEndpoint", "browseEndpoint",
+				"browseEndpointContextSupportedConfigs", "browseEndpointContextMusicConfig", "pageType")
+			if pageType == "MUSIC_PAGE_TYPE_ALBUM" {
+				if text, textOK := runObj["text"].(string); textOK {
+					return text
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
 // findDuration searches for duration information.
 func findDuration(obj map[string]any) int {
 	// Try different duration paths
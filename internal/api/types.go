@@ -6,6 +6,7 @@ type TrackRef struct {
 	Title       string   `json:"title"`
 	Artists     []string `json:"artists"`
 	Thumbnail   string   `json:"thumbnail,omitempty"`
+	Album       string   `json:"album,omitempty"`
 	Duration    int      `json:"duration"` // in seconds
 	IsAvailable bool     `json:"isAvailable"`
 	IsExplicit  bool     `json:"isExplicit"`
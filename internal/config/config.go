@@ -41,7 +41,15 @@ func Default() *structures.Config {
 		SeekSeconds:            5,
 		MaxCacheSize:           1024,   // 1GB
 		AudioQuality:           "high", // Default to medium quality
+		DownloadFormat:         "original",
 		EQPreset:               "flat",
+		Normalization:          "off",
+		AudioOutput:            "speaker",
+		OutputSampleRate:       48000,
+		Limiter:                true,
+		ResumeOnStart:          true,
+		PlayThresholdPercent:   50,
+		PlayThresholdSeconds:   240,
 		Theme: structures.Theme{
 			Background:       "#1a1b26",  // Tokyo Night Storm background
 			Foreground:       "#c0caf5",  // Tokyo Night foreground
@@ -51,6 +59,7 @@ func Default() *structures.Config {
 			ProgressBar:      "#565f89",  // Tokyo Night dark gray
 			ProgressBarFill:  "#7aa2f7",  // Tokyo Night blue
 			ProgressBarStyle: "gradient", // Default to gradient style
+			SpectrumStyle:    "bars",
 		},
 		KeyBindings: structures.KeyBindings{
 			// Global controls
@@ -73,6 +82,34 @@ func Default() *structures.Config {
 			RemoveTrack: "d",
 
 			ToggleEQ: "e",
+			EQEditor: "E",
+
+			SpeedUp:   "]",
+			SpeedDown: "[",
+
+			SleepTimer: "z",
+
+			Repeat: "r",
+
+			MoveTrackUp:   "K",
+			MoveTrackDown: "J",
+			MoveToTop:     "T",
+			MoveToEnd:     "E",
+			VisualSelect:  "v",
+
+			AddToPlaylist: "p",
+
+			ABLoop:    "L",
+			Bookmarks: "B",
+
+			History: "H",
+			Stats:   "S",
+
+			ToggleMono:      "M",
+			ToggleCrossfeed: "X",
+			ToggleKaraoke:   "K",
+			BalanceLeft:     "<",
+			BalanceRight:    ">",
 		},
 	}
 }
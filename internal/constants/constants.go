@@ -57,6 +57,24 @@ var AudioQualityMap = map[string]string{
 	AudioQualityLow:    "9", // Low quality (~64-96 kbps)
 }
 
+// yt-dlp format selectors used when downloads keep the original container.
+var AudioFormatSelectorMap = map[string]string{
+	AudioQualityBest:   "bestaudio",
+	AudioQualityHigh:   "bestaudio[abr<=160]/bestaudio",
+	AudioQualityMedium: "bestaudio[abr<=128]/bestaudio",
+	AudioQualityLow:    "worstaudio/bestaudio",
+}
+
+// Download formats.
+const (
+	DownloadFormatOriginal = "original" // Keep YouTube's Opus/WebM or AAC/M4A stream
+	DownloadFormatMP3      = "mp3"      // Transcode to MP3
+)
+
+// AudioExtensions are the file extensions a cached download or imported
+// local file may have, in lookup order.
+var AudioExtensions = []string{".mp3", ".webm", ".opus", ".m4a", ".ogg", ".mp4", ".flac", ".oga", ".wav"}
+
 // File size constants.
 const (
 	KB = 1024
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+func TestHistoryOutlivesTheTrack(t *testing.T) {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "yutemal.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+
+	cached := structures.Track{TrackID: "cached", Title: "Halo", Artists: []string{"Beyoncé"}, Album: "I Am... Sasha Fierce"}
+	streamed := structures.Track{TrackID: "streamed", Title: "Yesterday", Artists: []string{"The Beatles"}, Album: "Help!"}
+
+	if err := db.Add(structures.DatabaseEntry{Track: cached, AddedAt: time.Now()}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	now := time.Now()
+	for _, play := range []struct {
+		track structures.Track
+		at    time.Time
+	}{
+		{cached, now.Add(-3 * time.Hour)},
+		{streamed, now.Add(-2 * time.Hour)},
+		{cached, now.Add(-time.Hour)},
+	} {
+		if err := db.AddPlay(play.track, play.at, 3*time.Minute); err != nil {
+			t.Fatalf("AddPlay(%s): %v", play.track.TrackID, err)
+		}
+	}
+
+	var plays int
+	if err := db.db.QueryRow(`SELECT play_count FROM tracks WHERE track_id = 'cached'`).Scan(&plays); err != nil || plays != 2 {
+		t.Errorf("play count of the cached track: got (%d, %v), want 2", plays, err)
+	}
+
+	if err := db.Remove("cached"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	history := db.GetHistory(time.Time{}, now.Add(time.Minute), 0)
+	if len(history) != 3 {
+		t.Fatalf("history: got %d listens, want 3", len(history))
+	}
+
+	if h := history[0]; h.Track.Title != "Halo" || len(h.Track.Artists) != 1 || h.Track.Artists[0] != "Beyoncé" || h.Played != 3*time.Minute {
+		t.Errorf("latest listen: got %+v", h)
+	}
+
+	stats, err := db.GetStats(now.Add(-24*time.Hour), now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+
+	if stats.Plays != 3 || stats.Tracks != 2 || stats.Artists != 2 {
+		t.Errorf("totals: got %d plays, %d tracks, %d artists, want 3, 2, 2", stats.Plays, stats.Tracks, stats.Artists)
+	}
+
+	if len(stats.TopTracks) != 2 || stats.TopTracks[0].Track.Title != "Halo" || stats.TopTracks[0].Plays != 2 {
+		t.Errorf("top tracks: got %+v", stats.TopTracks)
+	}
+
+	if len(stats.TopAlbums) != 2 || stats.TopAlbums[0].Name != "I Am... Sasha Fierce" || stats.TopAlbums[0].Artist != "Beyoncé" {
+		t.Errorf("top albums: got %+v", stats.TopAlbums)
+	}
+
+	if len(stats.NewArtists) != 2 {
+		t.Errorf("new artists: got %+v, want both", stats.NewArtists)
+	}
+}
+
+func TestHistoryMigrationKeepsListens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yutemal.db")
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+
+	track := structures.Track{TrackID: "halo", Title: "Halo", Artists: []string{"Beyoncé"}, IsAvailable: true}
+	if err := db.Add(structures.DatabaseEntry{Track: track, AddedAt: time.Now()}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	// Go back to the table of older versions, with a listen in it
+	for _, query := range []string{
+		`DROP TABLE listening_history`,
+		`CREATE TABLE listening_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			track_id TEXT NOT NULL,
+			played_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			duration_played INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (track_id) REFERENCES tracks(track_id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX idx_history_played_at ON listening_history(played_at)`,
+		`INSERT INTO listening_history (track_id, played_at, duration_played) VALUES ('halo', '2025-06-01 12:00:00', 200)`,
+	} {
+		if _, err := db.db.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	db.Close()
+
+	db, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite after downgrade: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Remove("halo"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	history := db.GetHistory(time.Time{}, time.Now(), 0)
+	if len(history) != 1 {
+		t.Fatalf("history: got %d listens, want 1", len(history))
+	}
+
+	if h := history[0]; h.Track.Title != "Halo" || !h.Track.IsAvailable || h.Played != 200*time.Second {
+		t.Errorf("migrated listen: got %+v", h)
+	}
+
+	var index sql.NullString
+	if err := db.db.QueryRow(`SELECT tbl_name FROM sqlite_master WHERE name = 'idx_history_played_at'`).Scan(&index); err != nil || index.String != "listening_history" {
+		t.Errorf("played_at index: got (%v, %v)", index, err)
+	}
+}
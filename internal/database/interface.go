@@ -1,6 +1,10 @@
 package database
 
-import "github.com/haryoiro/yutemal/internal/structures"
+import (
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
 
 // DB is the interface that both Database and SQLiteDatabase implement.
 type DB interface {
@@ -8,6 +12,8 @@ type DB interface {
 	Remove(trackID string) error
 	Get(trackID string) (*structures.DatabaseEntry, bool)
 	GetAll() []structures.DatabaseEntry
+	GetByAlbum(album string) []structures.DatabaseEntry
+	SearchTracks(query string, limit int) []structures.Track
 	Close() error
 
 	// Cache methods
@@ -16,4 +22,28 @@ type DB interface {
 	InvalidateCache(cacheKey string) error
 	InvalidateCacheByType(cacheType string) error
 	CleanExpiredCache() error
+
+	// App state methods
+	GetAppState(key string) (string, bool)
+	SetAppState(key, value string) error
+
+	// Bookmark methods
+	AddBookmark(bookmark structures.Bookmark) (int64, error)
+	GetBookmarks(trackID string) []structures.Bookmark
+	RemoveBookmark(id int64) error
+
+	// Listening history methods
+	AddPlay(track structures.Track, playedAt time.Time, played time.Duration) error
+	GetHistory(from, to time.Time, limit int) []structures.HistoryEntry
+	GetStats(from, to time.Time, limit int) (structures.ListeningStats, error)
+
+	// Local playlist methods
+	CreatePlaylist(name string) (string, error)
+	RenamePlaylist(playlistID, name string) error
+	DeletePlaylist(playlistID string) error
+	GetLocalPlaylists() []structures.LocalPlaylist
+	GetPlaylistTracks(playlistID string) []structures.Track
+	AddPlaylistTracks(playlistID string, tracks []structures.Track) (int, error)
+	RemovePlaylistTrack(playlistID, trackID string) error
+	MovePlaylistTrack(playlistID string, from, to int) error
 }
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// searchIndexTriggers keep the full-text index of the library in step with
+// tracks.
+var searchIndexTriggers = []string{"tracks_fts_insert", "tracks_fts_delete", "tracks_fts_update"}
+
+// searchIndexQueries create the full-text index of the library: an FTS5
+// table over the title, artists and album of tracks, with triggers that
+// keep it in step with tracks. The unicode61 tokenizer folds case and, with
+// remove_diacritics 2, accents, so "beyonce" finds "Beyoncé". Artists are
+// indexed as their JSON array, whose punctuation the tokenizer skips.
+var searchIndexQueries = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS tracks_fts USING fts5(
+		title, artists, album,
+		content = 'tracks', content_rowid = 'rowid',
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`,
+
+	`CREATE TRIGGER IF NOT EXISTS tracks_fts_insert AFTER INSERT ON tracks BEGIN
+		INSERT INTO tracks_fts (rowid, title, artists, album)
+		VALUES (NEW.rowid, NEW.title, NEW.artists, NEW.album);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS tracks_fts_delete AFTER DELETE ON tracks BEGIN
+		INSERT INTO tracks_fts (tracks_fts, rowid, title, artists, album)
+		VALUES ('delete', OLD.rowid, OLD.title, OLD.artists, OLD.album);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS tracks_fts_update AFTER UPDATE OF title, artists, album ON tracks BEGIN
+		INSERT INTO tracks_fts (tracks_fts, rowid, title, artists, album)
+		VALUES ('delete', OLD.rowid, OLD.title, OLD.artists, OLD.album);
+		INSERT INTO tracks_fts (rowid, title, artists, album)
+		VALUES (NEW.rowid, NEW.title, NEW.artists, NEW.album);
+	END`,
+
+	// Index the tracks added while there was no index, or no triggers
+	`INSERT INTO tracks_fts (tracks_fts) VALUES ('rebuild')`,
+}
+
+// createSearchIndex creates the full-text index of the library unless it
+// is complete already. SQLite built without FTS5, as go-sqlite3 is without
+// the sqlite_fts5 build tag, cannot run the triggers of an index made by a
+// build with it, so they are dropped, to be recreated along with a rebuilt
+// index the next time FTS5 is there. The library search then falls back to
+// a slower LIKE scan.
+func (db *SQLiteDatabase) createSearchIndex() error {
+	var available bool
+	if err := db.db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&available); err != nil {
+		return fmt.Errorf("failed to check for FTS5: %w", err)
+	}
+
+	if !available {
+		for _, trigger := range searchIndexTriggers {
+			if _, err := db.db.Exec("DROP TRIGGER IF EXISTS " + trigger); err != nil {
+				return fmt.Errorf("failed to drop the search index trigger %s: %w", trigger, err)
+			}
+		}
+
+		logger.Warn("SQLite was built without FTS5; library search falls back to substring matching")
+
+		return nil
+	}
+
+	var count int
+	if err := db.db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE (type = 'table' AND name = 'tracks_fts')
+		   OR (type = 'trigger' AND name IN (?, ?, ?))
+	`, searchIndexTriggers[0], searchIndexTriggers[1], searchIndexTriggers[2]).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check for the search index: %w", err)
+	}
+
+	if count == len(searchIndexTriggers)+1 {
+		db.fts = true
+		return nil
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, query := range searchIndexQueries {
+		if _, err := tx.Exec(query); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to create the search index: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	db.fts = true
+
+	return nil
+}
+
+// SearchTracks searches the tracks in the library by title, artist and
+// album. Every word of the query has to match the start of a word, ignoring
+// case and accents; the best matches come first, titles counting most.
+func (db *SQLiteDatabase) SearchTracks(query string, limit int) []structures.Track {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	if !db.fts {
+		return db.searchTracksLike(terms, limit)
+	}
+
+	// Each word as a quoted prefix, so that the query syntax of FTS5 does
+	// not apply to what was typed
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+
+	rows, err := db.db.Query(`
+		SELECT `+trackColumns+`
+		FROM tracks JOIN (
+			SELECT rowid AS match_rowid, bm25(tracks_fts, 10.0, 5.0, 2.0) AS rank
+			FROM tracks_fts
+			WHERE tracks_fts MATCH ?
+		) ON tracks.rowid = match_rowid
+		ORDER BY rank
+		LIMIT ?
+	`, strings.Join(match, " "), limit)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	return entryTracks(scanEntries(rows))
+}
+
+// searchTracksLike is SearchTracks without the full-text index: every word
+// has to appear somewhere in the title, an artist or the album, ignoring
+// case only for ASCII letters. Artists are matched one by one rather than
+// as their JSON array, whose quotes and escapes would match too.
+func (db *SQLiteDatabase) searchTracksLike(terms []string, limit int) []structures.Track {
+	var (
+		where []string
+		args  []any
+	)
+
+	for _, term := range terms {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term) + "%"
+
+		where = append(where, `(title LIKE ? ESCAPE '\' OR album LIKE ? ESCAPE '\'
+			OR EXISTS (SELECT 1 FROM json_each(artists) WHERE value LIKE ? ESCAPE '\'))`)
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	rows, err := db.db.Query(`
+		SELECT `+trackColumns+`
+		FROM tracks
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY title
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	return entryTracks(scanEntries(rows))
+}
+
+// entryTracks returns the tracks of database entries.
+func entryTracks(entries []structures.DatabaseEntry) []structures.Track {
+	tracks := make([]structures.Track, len(entries))
+	for i, entry := range entries {
+		tracks[i] = entry.Track
+	}
+
+	return tracks
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// The tests run the FTS5 search when built with -tags sqlite_fts5 and the
+// LIKE fallback either way.
+
+var searchLibrary = []structures.Track{
+	{TrackID: "crazy", Title: "Crazy in Love", Artists: []string{"Beyoncé", "JAY-Z"}, Album: "Dangerously in Love"},
+	{TrackID: "halo", Title: "Halo", Artists: []string{"Beyoncé"}, Album: "I Am... Sasha Fierce"},
+	{TrackID: "yesterday", Title: "Yesterday", Artists: []string{"The Beatles"}, Album: "Help!"},
+	{TrackID: "help", Title: "Help!", Artists: []string{"The Beatles"}, Album: "Help!"},
+	{TrackID: "percent", Title: "100% Pure Love", Artists: []string{"Crystal Waters"}},
+}
+
+func openSearchLibrary(t *testing.T) *SQLiteDatabase {
+	t.Helper()
+
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "yutemal.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, track := range searchLibrary {
+		if err := db.Add(structures.DatabaseEntry{Track: track, AddedAt: time.Now()}); err != nil {
+			t.Fatalf("Add(%s): %v", track.TrackID, err)
+		}
+	}
+
+	return db
+}
+
+// searchIDs returns the IDs of the tracks SearchTracks finds, in order.
+func searchIDs(db *SQLiteDatabase, query string) []string {
+	var ids []string
+	for _, track := range db.SearchTracks(query, 0) {
+		ids = append(ids, track.TrackID)
+	}
+
+	return ids
+}
+
+// forEachSearch runs a test against the full-text search, if there is one,
+// and against the LIKE fallback.
+func forEachSearch(t *testing.T, test func(t *testing.T, db *SQLiteDatabase)) {
+	t.Run("fts", func(t *testing.T) {
+		db := openSearchLibrary(t)
+		if !db.fts {
+			t.Skip("SQLite was built without FTS5; build with -tags sqlite_fts5")
+		}
+
+		test(t, db)
+	})
+
+	t.Run("like", func(t *testing.T) {
+		db := openSearchLibrary(t)
+		db.fts = false
+
+		test(t, db)
+	})
+}
+
+func TestSearchTracksMatchesEveryWord(t *testing.T) {
+	forEachSearch(t, func(t *testing.T, db *SQLiteDatabase) {
+		for query, want := range map[string][]string{
+			"halo":            {"halo"},
+			"HALO beyoncé":    {"halo"},
+			"jay":             {"crazy"},
+			"beatles yester":  {"yesterday"},
+			"beyoncé beatles": nil,
+			"   ":             nil,
+		} {
+			got := searchIDs(db, query)
+			if !slices.Equal(got, want) {
+				t.Errorf("%q: got %v, want %v", query, got, want)
+			}
+		}
+
+		got := searchIDs(db, "love")
+		slices.Sort(got)
+		if !slices.Equal(got, []string{"crazy", "percent"}) {
+			t.Errorf(`"love": got %v, want crazy and percent`, got)
+		}
+
+		if got := db.SearchTracks("beatles", 1); len(got) != 1 {
+			t.Errorf("limit 1: got %d tracks", len(got))
+		}
+	})
+}
+
+func TestSearchTracksFollowsChanges(t *testing.T) {
+	forEachSearch(t, func(t *testing.T, db *SQLiteDatabase) {
+		renamed := searchLibrary[1]
+		renamed.Title = "Halo (Live)"
+		renamed.Artists = []string{"Beyoncé", "Guest"}
+
+		if err := db.Add(structures.DatabaseEntry{Track: renamed, AddedAt: time.Now()}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+
+		if got := searchIDs(db, "live guest"); !slices.Equal(got, []string{"halo"}) {
+			t.Errorf("after update: got %v, want [halo]", got)
+		}
+
+		if err := db.Remove("yesterday"); err != nil {
+			t.Fatalf("Remove: %v", err)
+		}
+
+		if got := searchIDs(db, "yesterday"); got != nil {
+			t.Errorf("after delete: got %v, want nothing", got)
+		}
+
+		if got := searchIDs(db, "beatles"); !slices.Equal(got, []string{"help"}) {
+			t.Errorf("after delete: got %v, want [help]", got)
+		}
+	})
+}
+
+func TestSearchTracksFullText(t *testing.T) {
+	db := openSearchLibrary(t)
+	if !db.fts {
+		t.Skip("SQLite was built without FTS5; build with -tags sqlite_fts5")
+	}
+
+	// Accents are folded, and words only match at their start
+	got := searchIDs(db, "beyonce")
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"crazy", "halo"}) {
+		t.Errorf(`"beyonce": got %v, want crazy and halo`, got)
+	}
+
+	if got := searchIDs(db, "esterday"); got != nil {
+		t.Errorf(`"esterday": got %v, want nothing`, got)
+	}
+
+	// A title match ranks above an album match
+	if got := searchIDs(db, "help"); !slices.Equal(got, []string{"help", "yesterday"}) {
+		t.Errorf(`"help": got %v, want [help yesterday]`, got)
+	}
+
+	// Query syntax is taken literally
+	if got := searchIDs(db, `halo OR "yesterday`); got != nil {
+		t.Errorf("query syntax: got %v, want nothing", got)
+	}
+}
+
+func TestSearchTracksLikeMatchesArtistsNotJSON(t *testing.T) {
+	db := openSearchLibrary(t)
+	db.fts = false
+
+	// The quotes and commas of the artists' JSON array are not matched
+	for _, query := range []string{`"`, `", "`, `["`} {
+		if got := searchIDs(db, query); got != nil {
+			t.Errorf("%q: got %v, want nothing", query, got)
+		}
+	}
+
+	// LIKE wildcards are taken literally
+	if got := searchIDs(db, "100%"); !slices.Equal(got, []string{"percent"}) {
+		t.Errorf(`"100%%": got %v, want [percent]`, got)
+	}
+
+	if got := searchIDs(db, "_"); got != nil {
+		t.Errorf(`"_": got %v, want nothing`, got)
+	}
+
+	if got := searchIDs(db, "esterday"); !slices.Equal(got, []string{"yesterday"}) {
+		t.Errorf(`"esterday": got %v, want [yesterday]`, got)
+	}
+}
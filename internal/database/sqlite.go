@@ -1,18 +1,49 @@
 package database
 
 import (
+	"crypto/rand"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 
 	"github.com/haryoiro/yutemal/internal/structures"
 )
 
+// trackColumns lists the tracks columns read by scanEntry, in order.
+const trackColumns = `track_id, title, artists, thumbnail, duration, is_available,
+		       is_explicit, added_at, file_path, file_size, audio_bitrate, audio_quality,
+		       album, loudness_lufs, true_peak_dbtp`
+
+// playlistTracksTable creates the playlist_tracks table.
+const playlistTracksTable = `CREATE TABLE IF NOT EXISTS playlist_tracks (
+			playlist_id TEXT NOT NULL,
+			track_id TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			track TEXT NOT NULL, -- JSON
+			added_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (playlist_id, track_id),
+			FOREIGN KEY (playlist_id) REFERENCES playlists(playlist_id) ON DELETE CASCADE
+		)`
+
+// listeningHistoryTable creates the listening_history table. Each listen
+// keeps a snapshot of its track, so that the history outlives the cached
+// file and covers tracks that were only streamed.
+const listeningHistoryTable = `CREATE TABLE IF NOT EXISTS listening_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			track_id TEXT NOT NULL,
+			played_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			duration_played INTEGER NOT NULL DEFAULT 0,
+			track TEXT NOT NULL -- JSON
+		)`
+
 // SQLiteDatabase represents the SQLite-based music database.
 type SQLiteDatabase struct {
 	mu   sync.RWMutex
@@ -26,6 +57,9 @@ type SQLiteDatabase struct {
 	stmtRemove   *sql.Stmt
 	stmtGetCache *sql.Stmt
 	stmtSetCache *sql.Stmt
+
+	// fts is whether the full-text index of the library exists
+	fts bool
 }
 
 // OpenSQLite opens or creates a SQLite database.
@@ -95,6 +129,11 @@ func OpenSQLite(path string) (*SQLiteDatabase, error) {
 		return nil, fmt.Errorf("failed to create tables: %w", createErr)
 	}
 
+	if indexErr := sqliteDB.createSearchIndex(); indexErr != nil {
+		db.Close()
+		return nil, indexErr
+	}
+
 	if prepErr := sqliteDB.prepareStatements(); prepErr != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to prepare statements: %w", prepErr)
@@ -138,24 +177,22 @@ func (db *SQLiteDatabase) createTables() error {
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 		)`,
 
-		`CREATE TABLE IF NOT EXISTS playlist_tracks (
-			playlist_id TEXT NOT NULL,
-			track_id TEXT NOT NULL,
-			position INTEGER NOT NULL,
-			added_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (playlist_id, track_id),
-			FOREIGN KEY (playlist_id) REFERENCES playlists(playlist_id) ON DELETE CASCADE,
-			FOREIGN KEY (track_id) REFERENCES tracks(track_id) ON DELETE CASCADE
-		)`,
+		// Tracks are kept as JSON rather than referencing tracks, so that a
+		// playlist can hold tracks that are not downloaded yet and keeps them
+		// when they are removed from the cache
+		playlistTracksTable,
 
-		`CREATE TABLE IF NOT EXISTS listening_history (
+		listeningHistoryTable,
+		`CREATE INDEX IF NOT EXISTS idx_history_played_at ON listening_history(played_at)`,
+
+		`CREATE TABLE IF NOT EXISTS bookmarks (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			track_id TEXT NOT NULL,
-			played_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			duration_played INTEGER NOT NULL DEFAULT 0,
-			FOREIGN KEY (track_id) REFERENCES tracks(track_id) ON DELETE CASCADE
+			name TEXT NOT NULL,
+			position_ms INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 		)`,
-		`CREATE INDEX IF NOT EXISTS idx_history_played_at ON listening_history(played_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_bookmarks_track ON bookmarks(track_id, position_ms)`,
 
 		`CREATE TABLE IF NOT EXISTS app_state (
 			key TEXT PRIMARY KEY,
@@ -233,6 +270,45 @@ func (db *SQLiteDatabase) runMigrations() error {
 		}
 	}
 
+	// Check if playlist_tracks has the track column. Before it, the table
+	// referenced tracks and was never written to, so it is simply recreated.
+	var playlistTrackExists bool
+	err = db.db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('playlist_tracks')
+		WHERE name = 'track'
+	`).Scan(&playlistTrackExists)
+
+	if err != nil {
+		return fmt.Errorf("failed to check playlist_tracks.track column existence: %w", err)
+	}
+
+	if !playlistTrackExists {
+		for _, migration := range []string{`DROP TABLE playlist_tracks`, playlistTracksTable} {
+			if _, migrationErr := db.db.Exec(migration); migrationErr != nil {
+				return fmt.Errorf("failed to recreate playlist_tracks: %w", migrationErr)
+			}
+		}
+	}
+
+	// Check if listening_history has the track column. Before it, listens
+	// referenced tracks and were deleted along with them; the table is
+	// rebuilt with snapshots of the tracks that are still there.
+	var historyTrackExists bool
+	err = db.db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('listening_history')
+		WHERE name = 'track'
+	`).Scan(&historyTrackExists)
+
+	if err != nil {
+		return fmt.Errorf("failed to check listening_history.track column existence: %w", err)
+	}
+
+	if !historyTrackExists {
+		if err := db.migrateListeningHistory(); err != nil {
+			return fmt.Errorf("failed to rebuild listening_history: %w", err)
+		}
+	}
+
 	// Check if tracks table has thumbnail_path column
 	var thumbnailPathExists bool
 	err = db.db.QueryRow(`
@@ -280,17 +356,79 @@ func (db *SQLiteDatabase) runMigrations() error {
 		}
 	}
 
+	// Check if tracks table has loudness columns
+	var loudnessExists bool
+	err = db.db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('tracks')
+		WHERE name = 'loudness_lufs'
+	`).Scan(&loudnessExists)
+
+	if err != nil {
+		return fmt.Errorf("failed to check loudness_lufs column existence: %w", err)
+	}
+
+	// Add loudness_lufs and true_peak_dbtp columns if they don't exist
+	if !loudnessExists {
+		migrations := []string{
+			`ALTER TABLE tracks ADD COLUMN loudness_lufs REAL`,
+			`ALTER TABLE tracks ADD COLUMN true_peak_dbtp REAL`,
+		}
+
+		for _, migration := range migrations {
+			if _, migrationErr := db.db.Exec(migration); migrationErr != nil {
+				// Ignore error if column already exists
+				// SQLite doesn't support IF NOT EXISTS for ALTER TABLE
+				continue
+			}
+		}
+	}
+
 	return nil
 }
 
+// migrateListeningHistory moves the listens into a listening_history with
+// track snapshots, built from the tracks table the way AddPlay would.
+func (db *SQLiteDatabase) migrateListeningHistory() error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`ALTER TABLE listening_history RENAME TO listening_history_old`,
+		`DROP INDEX IF EXISTS idx_history_played_at`,
+		listeningHistoryTable,
+		`CREATE INDEX IF NOT EXISTS idx_history_played_at ON listening_history(played_at)`,
+		`INSERT INTO listening_history (id, track_id, played_at, duration_played, track)
+		SELECT h.id, h.track_id, h.played_at, h.duration_played, json_object(
+			'artists', json(COALESCE(t.artists, '[]')),
+			'track_id', h.track_id,
+			'title', COALESCE(t.title, ''),
+			'thumbnail', COALESCE(t.thumbnail, ''),
+			'album', COALESCE(t.album, ''),
+			'duration', COALESCE(t.duration, 0),
+			'is_available', json(CASE WHEN t.is_available THEN 'true' ELSE 'false' END),
+			'is_explicit', json(CASE WHEN t.is_explicit THEN 'true' ELSE 'false' END)
+		)
+		FROM listening_history_old h LEFT JOIN tracks t USING (track_id)`,
+		`DROP TABLE listening_history_old`,
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // prepareStatements pre-compiles frequently used SQL queries.
 // This avoids repeated query parsing and reduces CGO crossing overhead per call.
 func (db *SQLiteDatabase) prepareStatements() error {
 	var err error
 
 	db.stmtGet, err = db.db.Prepare(`
-		SELECT track_id, title, artists, thumbnail, duration, is_available,
-		       is_explicit, added_at, file_path, file_size, audio_bitrate, audio_quality
+		SELECT ` + trackColumns + `
 		FROM tracks WHERE track_id = ?
 	`)
 	if err != nil {
@@ -298,19 +436,29 @@ func (db *SQLiteDatabase) prepareStatements() error {
 	}
 
 	db.stmtGetAll, err = db.db.Prepare(`
-		SELECT track_id, title, artists, thumbnail, duration, is_available,
-		       is_explicit, added_at, file_path, file_size, audio_bitrate, audio_quality
+		SELECT ` + trackColumns + `
 		FROM tracks ORDER BY added_at DESC
 	`)
 	if err != nil {
 		return fmt.Errorf("prepare GetAll: %w", err)
 	}
 
+	// An upsert rather than INSERT OR REPLACE, which would delete the row
+	// and with it, through the foreign keys, the track's listening history
 	db.stmtAdd, err = db.db.Prepare(`
-		INSERT OR REPLACE INTO tracks
+		INSERT INTO tracks
 		(track_id, title, artists, thumbnail, duration, is_available, is_explicit,
-		 added_at, file_path, file_size, audio_bitrate, audio_quality)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 added_at, file_path, file_size, audio_bitrate, audio_quality, album,
+		 loudness_lufs, true_peak_dbtp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(track_id) DO UPDATE SET
+			title = excluded.title, artists = excluded.artists,
+			thumbnail = excluded.thumbnail, duration = excluded.duration,
+			is_available = excluded.is_available, is_explicit = excluded.is_explicit,
+			added_at = excluded.added_at, file_path = excluded.file_path,
+			file_size = excluded.file_size, audio_bitrate = excluded.audio_bitrate,
+			audio_quality = excluded.audio_quality, album = excluded.album,
+			loudness_lufs = excluded.loudness_lufs, true_peak_dbtp = excluded.true_peak_dbtp
 	`)
 	if err != nil {
 		return fmt.Errorf("prepare Add: %w", err)
@@ -364,6 +512,13 @@ func (db *SQLiteDatabase) Add(entry structures.DatabaseEntry) error {
 		return fmt.Errorf("failed to marshal artists: %w", err)
 	}
 
+	// SQLite has no infinities, so unmeasurable loudness is stored as NULL
+	var loudness, truePeak sql.NullFloat64
+	if l := entry.Loudness; l != nil && !math.IsInf(l.IntegratedLUFS, 0) && !math.IsInf(l.TruePeakDBTP, 0) {
+		loudness = sql.NullFloat64{Float64: entry.Loudness.IntegratedLUFS, Valid: true}
+		truePeak = sql.NullFloat64{Float64: entry.Loudness.TruePeakDBTP, Valid: true}
+	}
+
 	_, err = db.stmtAdd.Exec(
 		entry.Track.TrackID,
 		entry.Track.Title,
@@ -377,6 +532,9 @@ func (db *SQLiteDatabase) Add(entry structures.DatabaseEntry) error {
 		entry.FileSize,
 		entry.Track.AudioBitrate,
 		entry.Track.AudioQuality,
+		entry.Track.Album,
+		loudness,
+		truePeak,
 	)
 
 	return err
@@ -397,14 +555,57 @@ func (db *SQLiteDatabase) Get(trackID string) (*structures.DatabaseEntry, bool)
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	row := db.stmtGet.QueryRow(trackID)
+	entry, err := scanEntry(db.stmtGet.QueryRow(trackID))
+	if err != nil {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// GetAll returns all tracks.
+func (db *SQLiteDatabase) GetAll() []structures.DatabaseEntry {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.stmtGetAll.Query()
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	return scanEntries(rows)
+}
+
+// GetByAlbum returns all downloaded tracks of an album.
+func (db *SQLiteDatabase) GetByAlbum(album string) []structures.DatabaseEntry {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.db.Query(`SELECT `+trackColumns+` FROM tracks WHERE album = ?`, album)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
 
+	return scanEntries(rows)
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanEntry reads one row selected with trackColumns.
+func scanEntry(row rowScanner) (structures.DatabaseEntry, error) {
 	var entry structures.DatabaseEntry
 	var artistsJSON string
 	var thumbnail, filePath sql.NullString
 	var fileSize sql.NullInt64
 	var audioBitrate sql.NullInt64
 	var audioQuality sql.NullString
+	var album sql.NullString
+	var loudness, truePeak sql.NullFloat64
 
 	err := row.Scan(
 		&entry.Track.TrackID,
@@ -419,19 +620,17 @@ func (db *SQLiteDatabase) Get(trackID string) (*structures.DatabaseEntry, bool)
 		&fileSize,
 		&audioBitrate,
 		&audioQuality,
+		&album,
+		&loudness,
+		&truePeak,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, false
-		}
-
-		return nil, false
+		return entry, err
 	}
 
 	// Parse artists JSON
 	if unmarshalErr := json.Unmarshal([]byte(artistsJSON), &entry.Track.Artists); unmarshalErr != nil {
-		return nil, false
+		return entry, unmarshalErr
 	}
 
 	// Handle nullable fields
@@ -440,62 +639,28 @@ func (db *SQLiteDatabase) Get(trackID string) (*structures.DatabaseEntry, bool)
 	entry.FileSize = fileSize.Int64
 	entry.Track.AudioBitrate = int(audioBitrate.Int64)
 	entry.Track.AudioQuality = audioQuality.String
+	entry.Track.Album = album.String
 
-	return &entry, true
-}
-
-// GetAll returns all tracks.
-func (db *SQLiteDatabase) GetAll() []structures.DatabaseEntry {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	rows, err := db.stmtGetAll.Query()
-	if err != nil {
-		return nil
+	if loudness.Valid && truePeak.Valid {
+		entry.Loudness = &structures.Loudness{
+			IntegratedLUFS: loudness.Float64,
+			TruePeakDBTP:   truePeak.Float64,
+		}
 	}
-	defer rows.Close()
 
+	return entry, nil
+}
+
+// scanEntries reads all rows, skipping any that fail to parse.
+func scanEntries(rows *sql.Rows) []structures.DatabaseEntry {
 	var entries []structures.DatabaseEntry
 
 	for rows.Next() {
-		var entry structures.DatabaseEntry
-		var artistsJSON string
-		var thumbnail, filePath sql.NullString
-		var fileSize sql.NullInt64
-		var audioBitrate sql.NullInt64
-		var audioQuality sql.NullString
-
-		scanErr := rows.Scan(
-			&entry.Track.TrackID,
-			&entry.Track.Title,
-			&artistsJSON,
-			&thumbnail,
-			&entry.Track.Duration,
-			&entry.Track.IsAvailable,
-			&entry.Track.IsExplicit,
-			&entry.AddedAt,
-			&filePath,
-			&fileSize,
-			&audioBitrate,
-			&audioQuality,
-		)
-
-		if scanErr != nil {
-			continue
-		}
-
-		// Parse artists JSON
-		if unmarshalErr := json.Unmarshal([]byte(artistsJSON), &entry.Track.Artists); unmarshalErr != nil {
+		entry, err := scanEntry(rows)
+		if err != nil {
 			continue
 		}
 
-		// Handle nullable fields
-		entry.Track.Thumbnail = thumbnail.String
-		entry.FilePath = filePath.String
-		entry.FileSize = fileSize.Int64
-		entry.Track.AudioBitrate = int(audioBitrate.Int64)
-		entry.Track.AudioQuality = audioQuality.String
-
 		entries = append(entries, entry)
 	}
 
@@ -556,3 +721,425 @@ func (db *SQLiteDatabase) CleanExpiredCache() error {
 
 	return err
 }
+
+// GetAppState returns a value saved with SetAppState.
+func (db *SQLiteDatabase) GetAppState(key string) (string, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var value string
+	if err := db.db.QueryRow("SELECT value FROM app_state WHERE key = ?", key).Scan(&value); err != nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+// SetAppState saves a value that should survive a restart.
+func (db *SQLiteDatabase) SetAppState(key, value string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.db.Exec(`
+		INSERT INTO app_state (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value)
+
+	return err
+}
+
+// AddBookmark saves a bookmark and returns its ID.
+func (db *SQLiteDatabase) AddBookmark(bookmark structures.Bookmark) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	result, err := db.db.Exec(
+		"INSERT INTO bookmarks (track_id, name, position_ms) VALUES (?, ?, ?)",
+		bookmark.TrackID, bookmark.Name, bookmark.Position.Milliseconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// GetBookmarks returns the bookmarks of a track in playback order.
+func (db *SQLiteDatabase) GetBookmarks(trackID string) []structures.Bookmark {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.db.Query(`
+		SELECT id, track_id, name, position_ms, created_at FROM bookmarks
+		WHERE track_id = ? ORDER BY position_ms, id
+	`, trackID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var bookmarks []structures.Bookmark
+
+	for rows.Next() {
+		var (
+			b          structures.Bookmark
+			positionMS int64
+		)
+
+		if err := rows.Scan(&b.ID, &b.TrackID, &b.Name, &positionMS, &b.CreatedAt); err != nil {
+			continue
+		}
+
+		b.Position = time.Duration(positionMS) * time.Millisecond
+		bookmarks = append(bookmarks, b)
+	}
+
+	return bookmarks
+}
+
+// RemoveBookmark deletes a bookmark.
+func (db *SQLiteDatabase) RemoveBookmark(id int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.db.Exec("DELETE FROM bookmarks WHERE id = ?", id)
+
+	return err
+}
+
+// historyTimeFormat is how listening_history stores played_at: in UTC, as
+// CURRENT_TIMESTAMP does, so that ranges compare as text.
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+// AddPlay records a listen in the history, with a snapshot of the track,
+// and counts it as a play of the track if it is in the database.
+func (db *SQLiteDatabase) AddPlay(track structures.Track, playedAt time.Time, played time.Duration) error {
+	data, err := json.Marshal(track)
+	if err != nil {
+		return fmt.Errorf("failed to marshal track: %w", err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	at := playedAt.UTC().Format(historyTimeFormat)
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO listening_history (track_id, played_at, duration_played, track) VALUES (?, ?, ?, ?)",
+		track.TrackID, at, int64(played.Seconds()), string(data),
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE tracks SET play_count = COALESCE(play_count, 0) + 1, last_played = ? WHERE track_id = ?",
+		at, track.TrackID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetHistory returns the listens from from up to to, newest first, at most
+// limit of them. A limit of 0 or less returns them all.
+func (db *SQLiteDatabase) GetHistory(from, to time.Time, limit int) []structures.HistoryEntry {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := db.db.Query(`
+		SELECT id, played_at, duration_played, track
+		FROM listening_history
+		WHERE played_at >= ? AND played_at < ?
+		ORDER BY played_at DESC, id DESC
+		LIMIT ?
+	`, from.UTC().Format(historyTimeFormat), to.UTC().Format(historyTimeFormat), limit)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var history []structures.HistoryEntry
+
+	for rows.Next() {
+		var (
+			h             structures.HistoryEntry
+			playedSeconds int64
+			data          string
+		)
+
+		if err := rows.Scan(&h.ID, &h.PlayedAt, &playedSeconds, &data); err != nil {
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(data), &h.Track); err != nil {
+			continue
+		}
+
+		h.Played = time.Duration(playedSeconds) * time.Second
+		history = append(history, h)
+	}
+
+	return history
+}
+
+// CreatePlaylist creates an empty local playlist and returns its ID.
+func (db *SQLiteDatabase) CreatePlaylist(name string) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	id := "local:" + rand.Text()
+
+	_, err := db.db.Exec("INSERT INTO playlists (playlist_id, name, is_local) VALUES (?, ?, 1)", id, name)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// RenamePlaylist renames a local playlist.
+func (db *SQLiteDatabase) RenamePlaylist(playlistID, name string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.db.Exec("UPDATE playlists SET name = ? WHERE playlist_id = ? AND is_local = 1", name, playlistID)
+
+	return err
+}
+
+// DeletePlaylist deletes a local playlist and its tracks.
+func (db *SQLiteDatabase) DeletePlaylist(playlistID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.db.Exec("DELETE FROM playlists WHERE playlist_id = ? AND is_local = 1", playlistID)
+
+	return err
+}
+
+// GetLocalPlaylists returns the local playlists by name.
+func (db *SQLiteDatabase) GetLocalPlaylists() []structures.LocalPlaylist {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.db.Query(`
+		SELECT p.playlist_id, p.name, p.updated_at, COUNT(t.track_id)
+		FROM playlists p LEFT JOIN playlist_tracks t ON t.playlist_id = p.playlist_id
+		WHERE p.is_local = 1
+		GROUP BY p.playlist_id
+		ORDER BY p.name COLLATE NOCASE, p.created_at
+	`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var playlists []structures.LocalPlaylist
+
+	for rows.Next() {
+		var p structures.LocalPlaylist
+		if err := rows.Scan(&p.ID, &p.Name, &p.UpdatedAt, &p.TrackCount); err != nil {
+			continue
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	return playlists
+}
+
+// GetPlaylistTracks returns the tracks of a local playlist in order.
+func (db *SQLiteDatabase) GetPlaylistTracks(playlistID string) []structures.Track {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.db.Query(
+		"SELECT track FROM playlist_tracks WHERE playlist_id = ? ORDER BY position",
+		playlistID,
+	)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var tracks []structures.Track
+
+	for rows.Next() {
+		var (
+			data  string
+			track structures.Track
+		)
+
+		if err := rows.Scan(&data); err != nil {
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(data), &track); err != nil {
+			continue
+		}
+
+		tracks = append(tracks, track)
+	}
+
+	return tracks
+}
+
+// AddPlaylistTracks appends tracks to a local playlist, skipping those
+// already in it, and returns how many were added.
+func (db *SQLiteDatabase) AddPlaylistTracks(playlistID string, tracks []structures.Track) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var next int
+	if err := tx.QueryRow(
+		"SELECT COALESCE(MAX(position) + 1, 0) FROM playlist_tracks WHERE playlist_id = ?",
+		playlistID,
+	).Scan(&next); err != nil {
+		return 0, err
+	}
+
+	added := 0
+
+	for _, track := range tracks {
+		data, err := json.Marshal(track)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal track: %w", err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO playlist_tracks (playlist_id, track_id, position, track)
+			VALUES (?, ?, ?, ?)
+		`, playlistID, track.TrackID, next, string(data))
+		if err != nil {
+			return 0, err
+		}
+
+		if n, _ := result.RowsAffected(); n > 0 {
+			next++
+			added++
+		}
+	}
+
+	if err := touchPlaylist(tx, playlistID); err != nil {
+		return 0, err
+	}
+
+	return added, tx.Commit()
+}
+
+// RemovePlaylistTrack removes a track from a local playlist.
+func (db *SQLiteDatabase) RemovePlaylistTrack(playlistID, trackID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var position int
+	if err := tx.QueryRow(
+		"SELECT position FROM playlist_tracks WHERE playlist_id = ? AND track_id = ?",
+		playlistID, trackID,
+	).Scan(&position); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"DELETE FROM playlist_tracks WHERE playlist_id = ? AND track_id = ?",
+		playlistID, trackID,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE playlist_tracks SET position = position - 1 WHERE playlist_id = ? AND position > ?",
+		playlistID, position,
+	); err != nil {
+		return err
+	}
+
+	if err := touchPlaylist(tx, playlistID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MovePlaylistTrack moves the track at index from of a local playlist to
+// index to.
+func (db *SQLiteDatabase) MovePlaylistTrack(playlistID string, from, to int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT track_id FROM playlist_tracks WHERE playlist_id = ? ORDER BY position", playlistID)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if from < 0 || from >= len(ids) || to < 0 || to >= len(ids) {
+		return fmt.Errorf("cannot move track %d to %d in a playlist of %d", from, to, len(ids))
+	}
+
+	id := ids[from]
+	ids = slices.Insert(slices.Delete(ids, from, from+1), to, id)
+
+	for position := min(from, to); position <= max(from, to); position++ {
+		if _, err := tx.Exec(
+			"UPDATE playlist_tracks SET position = ? WHERE playlist_id = ? AND track_id = ?",
+			position, playlistID, ids[position],
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := touchPlaylist(tx, playlistID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// touchPlaylist marks a playlist as changed.
+func touchPlaylist(tx *sql.Tx, playlistID string) error {
+	_, err := tx.Exec("UPDATE playlists SET updated_at = CURRENT_TIMESTAMP WHERE playlist_id = ?", playlistID)
+	return err
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// statsDayFormat is how the stats queries return local days.
+const statsDayFormat = "2006-01-02"
+
+// GetStats reports on the listens from from up to to: totals, the top
+// limit tracks, artists and albums, listening by hour and weekday, the
+// longest streaks and the artists first listened to in the range. A zero
+// from covers the whole history, with no new artists. Hours, weekdays and
+// days are local time.
+func (db *SQLiteDatabase) GetStats(from, to time.Time, limit int) (structures.ListeningStats, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	stats := structures.ListeningStats{From: from, To: to}
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	start, end := from.UTC().Format(historyTimeFormat), to.UTC().Format(historyTimeFormat)
+
+	var playedSeconds int64
+
+	if err := db.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(duration_played), 0), COUNT(DISTINCT track_id)
+		FROM listening_history
+		WHERE played_at >= ? AND played_at < ?
+	`, start, end).Scan(&stats.Plays, &playedSeconds, &stats.Tracks); err != nil {
+		return stats, err
+	}
+
+	stats.Played = time.Duration(playedSeconds) * time.Second
+
+	if err := db.db.QueryRow(`
+		SELECT COUNT(DISTINCT a.value)
+		FROM listening_history h, json_each(h.track, '$.artists') a
+		WHERE h.played_at >= ? AND h.played_at < ?
+	`, start, end).Scan(&stats.Artists); err != nil {
+		return stats, err
+	}
+
+	// Each track as of its latest listen: with MAX, SQLite reads the bare
+	// track column from the row holding the maximum
+	err := db.eachRow(func(rows *sql.Rows) error {
+		var (
+			s      structures.TrackStat
+			played int64
+			latest int64
+			data   string
+		)
+
+		if err := rows.Scan(&s.Plays, &played, &latest, &data); err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(data), &s.Track); err != nil {
+			return err
+		}
+
+		s.Played = time.Duration(played) * time.Second
+		stats.TopTracks = append(stats.TopTracks, s)
+
+		return nil
+	}, `
+		SELECT COUNT(*) AS plays, SUM(duration_played) AS played, MAX(id), track
+		FROM listening_history
+		WHERE played_at >= ? AND played_at < ?
+		GROUP BY track_id
+		ORDER BY plays DESC, played DESC
+		LIMIT ?
+	`, start, end, limit)
+	if err != nil {
+		return stats, err
+	}
+
+	err = db.eachRow(func(rows *sql.Rows) error {
+		s, err := scanPlayStat(rows, false)
+		stats.TopArtists = append(stats.TopArtists, s)
+
+		return err
+	}, `
+		SELECT a.value, COUNT(*) AS plays, SUM(h.duration_played) AS played
+		FROM listening_history h, json_each(h.track, '$.artists') a
+		WHERE h.played_at >= ? AND h.played_at < ?
+		GROUP BY a.value
+		ORDER BY plays DESC, played DESC, a.value
+		LIMIT ?
+	`, start, end, limit)
+	if err != nil {
+		return stats, err
+	}
+
+	err = db.eachRow(func(rows *sql.Rows) error {
+		s, err := scanPlayStat(rows, true)
+		stats.TopAlbums = append(stats.TopAlbums, s)
+
+		return err
+	}, `
+		SELECT json_extract(track, '$.album') AS album,
+		       COALESCE(json_extract(track, '$.artists[0]'), '') AS artist,
+		       COUNT(*) AS plays, SUM(duration_played) AS played
+		FROM listening_history
+		WHERE played_at >= ? AND played_at < ? AND COALESCE(album, '') != ''
+		GROUP BY album, artist
+		ORDER BY plays DESC, played DESC, album
+		LIMIT ?
+	`, start, end, limit)
+	if err != nil {
+		return stats, err
+	}
+
+	err = db.eachRow(func(rows *sql.Rows) error {
+		var hour, weekday int
+		var played int64
+
+		if err := rows.Scan(&hour, &weekday, &played); err != nil {
+			return err
+		}
+
+		if hour >= 0 && hour < len(stats.ByHour) && weekday >= 0 && weekday < len(stats.ByWeekday) {
+			stats.ByHour[hour] += time.Duration(played) * time.Second
+			stats.ByWeekday[weekday] += time.Duration(played) * time.Second
+		}
+
+		return nil
+	}, `
+		SELECT CAST(strftime('%H', played_at, 'localtime') AS INTEGER) AS hour,
+		       CAST(strftime('%w', played_at, 'localtime') AS INTEGER) AS weekday,
+		       SUM(duration_played)
+		FROM listening_history
+		WHERE played_at >= ? AND played_at < ?
+		GROUP BY hour, weekday
+	`, start, end)
+	if err != nil {
+		return stats, err
+	}
+
+	// Days in a run are consecutive exactly when they are all the same
+	// distance from their row number
+	err = db.eachRow(func(rows *sql.Rows) error {
+		var (
+			s           structures.Streak
+			first, last string
+		)
+
+		if err := rows.Scan(&first, &last, &s.Days); err != nil {
+			return err
+		}
+
+		s.Start, _ = time.ParseInLocation(statsDayFormat, first, time.Local)
+		s.End, _ = time.ParseInLocation(statsDayFormat, last, time.Local)
+		stats.Streaks = append(stats.Streaks, s)
+
+		return nil
+	}, `
+		WITH days AS (
+			SELECT DISTINCT date(played_at, 'localtime') AS day
+			FROM listening_history
+			WHERE played_at >= ? AND played_at < ?
+		), runs AS (
+			SELECT day, julianday(day) - ROW_NUMBER() OVER (ORDER BY day) AS run
+			FROM days
+		)
+		SELECT MIN(day), MAX(day), COUNT(*) AS length
+		FROM runs
+		GROUP BY run
+		ORDER BY length DESC, MIN(day) DESC
+		LIMIT ?
+	`, start, end, limit)
+	if err != nil {
+		return stats, err
+	}
+
+	if from.IsZero() {
+		return stats, nil
+	}
+
+	err = db.eachRow(func(rows *sql.Rows) error {
+		var (
+			a     structures.NewArtist
+			first string
+		)
+
+		if err := rows.Scan(&a.Name, &first, &a.Plays); err != nil {
+			return err
+		}
+
+		a.First, _ = time.ParseInLocation(historyTimeFormat, first, time.UTC)
+		stats.NewArtists = append(stats.NewArtists, a)
+
+		return nil
+	}, `
+		SELECT a.value, MIN(h.played_at) AS first, COUNT(*)
+		FROM listening_history h, json_each(h.track, '$.artists') a
+		WHERE h.played_at < ?
+		GROUP BY a.value
+		HAVING first >= ?
+		ORDER BY first DESC
+		LIMIT ?
+	`, end, start, limit)
+
+	return stats, err
+}
+
+// eachRow runs a query and calls fn for each row, stopping at the first
+// error.
+func (db *SQLiteDatabase) eachRow(fn func(rows *sql.Rows) error, query string, args ...any) error {
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanPlayStat scans the name, for albums the artist, the plays and the
+// seconds played of an artist or album.
+func scanPlayStat(rows *sql.Rows, album bool) (structures.PlayStat, error) {
+	var (
+		s      structures.PlayStat
+		played int64
+	)
+
+	dest := []any{&s.Name}
+	if album {
+		dest = append(dest, &s.Artist)
+	}
+
+	if err := rows.Scan(append(dest, &s.Plays, &played)...); err != nil {
+		return s, err
+	}
+
+	s.Played = time.Duration(played) * time.Second
+
+	return s, nil
+}
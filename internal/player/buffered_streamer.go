@@ -237,15 +237,7 @@ func (bs *BufferedStreamer) Stream(samples [][2]float64) (n int, ok bool) {
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
-	// Don't wait for initial fill if we've already started reading
-	// This helps with seeking to work immediately
-	if bs.position == 0 && bs.filled < bs.bufferSize*3/4 && !bs.closed && bs.underruns == 0 {
-		logger.Debug("Waiting for initial buffer fill: %d/%d samples", bs.filled, bs.bufferSize*3/4)
-
-		for bs.filled < bs.bufferSize*3/4 && !bs.closed {
-			bs.cond.Wait()
-		}
-	}
+	bs.waitInitialFill()
 
 	if bs.filled == 0 {
 		// Check if we've exhausted both source and buffer
@@ -304,6 +296,36 @@ func (bs *BufferedStreamer) Stream(samples [][2]float64) (n int, ok bool) {
 	return len(samples), true
 }
 
+// WaitFilled blocks until the initial fill is done, so that the first
+// Stream call does not have to wait for the source. It returns at once when
+// reading has already started.
+func (bs *BufferedStreamer) WaitFilled() {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.waitInitialFill()
+}
+
+// waitInitialFill waits with bs.mu held until the buffer is three quarters
+// full. A source shorter than that ends the wait as soon as it is exhausted.
+func (bs *BufferedStreamer) waitInitialFill() {
+	// Don't wait for initial fill if we've already started reading
+	// This helps with seeking to work immediately
+	if bs.position != 0 || bs.underruns != 0 {
+		return
+	}
+
+	if bs.filled >= bs.bufferSize*3/4 || bs.closed || bs.sourceExhausted {
+		return
+	}
+
+	logger.Debug("Waiting for initial buffer fill: %d/%d samples", bs.filled, bs.bufferSize*3/4)
+
+	for bs.filled < bs.bufferSize*3/4 && !bs.closed && !bs.sourceExhausted {
+		bs.cond.Wait()
+	}
+}
+
 // Err implements beep.Streamer.
 func (bs *BufferedStreamer) Err() error {
 	if source, ok := bs.source.(beep.StreamSeeker); ok {
@@ -390,6 +412,15 @@ func (bs *BufferedStreamer) Seek(position int) error {
 	return nil
 }
 
+// Exhausted returns whether the source has ended and every buffered sample
+// has been played.
+func (bs *BufferedStreamer) Exhausted() bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.sourceExhausted && bs.filled == 0
+}
+
 // Position returns the current position.
 func (bs *BufferedStreamer) Position() int {
 	bs.mu.Lock()
@@ -0,0 +1,205 @@
+package player
+
+import (
+	"math"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// Channel processing parameters: the crossfeed cut-off and level of the
+// default Bauer stereophonic-to-binaural setting, and the frequency below
+// which karaoke mode keeps the centre so that bass and kick drum survive.
+const (
+	crossfeedCutoff  = 700.0 // Hz
+	crossfeedLevel   = 4.5   // dB
+	karaokeBassBelow = 150.0 // Hz
+)
+
+// ChannelSettings selects the channel tools of a ChannelMixer.
+type ChannelSettings struct {
+	Mono      bool    // downmix both channels to the centre
+	Balance   float64 // -1 (left only) to 1 (right only)
+	Crossfeed bool    // Bauer crossfeed for headphones
+	Karaoke   bool    // cancel centre-panned vocals
+}
+
+// IsNeutral reports whether the settings leave the audio unchanged.
+func (s ChannelSettings) IsNeutral() bool {
+	return !s.Mono && s.Balance == 0 && !s.Crossfeed && !s.Karaoke
+}
+
+// ChannelMixer applies the channel tools to stereo frames, in the order
+// karaoke, crossfeed, mono, balance. Neutral settings pass the audio
+// through untouched.
+type ChannelMixer struct {
+	mu         sync.Mutex
+	streamer   beep.Streamer
+	settings   ChannelSettings
+	sampleRate float64
+
+	// Crossfeed: a low-passed copy of the opposite channel is mixed into
+	// each side, whose own highs are boosted to keep the tonal balance.
+	loA0, loB1       float64
+	hiA0, hiA1, hiB1 float64
+	crossGain        float64
+	lo, hi, prev     [2]float64
+
+	// Karaoke: centre through two one-pole low-passes, mixed back into the
+	// side signal
+	bassCoef float64
+	bass     [2]float64
+}
+
+// NewChannelMixer creates a mixer with neutral settings.
+func NewChannelMixer(streamer beep.Streamer, sampleRate float64) *ChannelMixer {
+	c := &ChannelMixer{streamer: streamer}
+	c.setSampleRateLocked(sampleRate)
+
+	return c
+}
+
+// Stream implements beep.Streamer.
+func (c *ChannelMixer) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = c.streamer.Stream(samples)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.settings
+	if s.IsNeutral() {
+		return n, ok
+	}
+
+	left, right := balanceGains(s.Balance)
+
+	for i := range samples[:n] {
+		l, r := samples[i][0], samples[i][1]
+
+		if s.Karaoke {
+			l, r = c.karaoke(l, r)
+		}
+
+		if s.Crossfeed {
+			l, r = c.crossfeed(l, r)
+		}
+
+		if s.Mono {
+			mid := (l + r) / 2
+			l, r = mid, mid
+		}
+
+		samples[i] = [2]float64{l * left, r * right}
+	}
+
+	return n, ok
+}
+
+// Err implements beep.Streamer.
+func (c *ChannelMixer) Err() error {
+	return c.streamer.Err()
+}
+
+// karaoke removes what the channels have in common, which is where lead
+// vocals usually sit, but keeps the centre below karaokeBassBelow.
+func (c *ChannelMixer) karaoke(l, r float64) (float64, float64) {
+	mid := (l + r) / 2
+	side := (l - r) / 2
+
+	c.bass[0] += (mid - c.bass[0]) * c.bassCoef
+	c.bass[1] += (c.bass[0] - c.bass[1]) * c.bassCoef
+
+	return c.bass[1] + side, c.bass[1] - side
+}
+
+// crossfeed mixes each channel into the other the way sound from speakers
+// reaches both ears, after the Bauer stereophonic-to-binaural design.
+func (c *ChannelMixer) crossfeed(l, r float64) (float64, float64) {
+	in := [2]float64{l, r}
+
+	for ch := range 2 {
+		c.lo[ch] = c.loA0*in[ch] + c.loB1*c.lo[ch]
+		c.hi[ch] = c.hiA0*in[ch] + c.hiA1*c.prev[ch] + c.hiB1*c.hi[ch]
+	}
+	c.prev = in
+
+	return (c.hi[0] + c.lo[1]) * c.crossGain, (c.hi[1] + c.lo[0]) * c.crossGain
+}
+
+// balanceGains returns the channel gains for a balance: the far side is
+// attenuated, the near side stays at unity.
+func balanceGains(balance float64) (left, right float64) {
+	balance = math.Max(-1, math.Min(1, balance))
+
+	return math.Min(1, 1-balance), math.Min(1, 1+balance)
+}
+
+// SetSettings replaces the channel settings.
+func (c *ChannelMixer) SetSettings(settings ChannelSettings) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	settings.Balance = math.Max(-1, math.Min(1, settings.Balance))
+	if settings.Karaoke != c.settings.Karaoke || settings.Crossfeed != c.settings.Crossfeed {
+		c.resetLocked()
+	}
+	c.settings = settings
+}
+
+// Settings returns the channel settings.
+func (c *ChannelMixer) Settings() ChannelSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.settings
+}
+
+// setSampleRate recomputes the filters for a new rate.
+func (c *ChannelMixer) setSampleRate(sampleRate float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setSampleRateLocked(sampleRate)
+}
+
+func (c *ChannelMixer) setSampleRateLocked(sampleRate float64) {
+	if sampleRate == c.sampleRate {
+		return
+	}
+	c.sampleRate = sampleRate
+
+	// Coefficients as in bs2b
+	gbLo := crossfeedLevel*-5/6 - 3
+	gbHi := crossfeedLevel/6 - 3
+	gLo := math.Pow(10, gbLo/20)
+	gHi := 1 - math.Pow(10, gbHi/20)
+	fcHi := crossfeedCutoff * math.Pow(2, (gbLo-20*math.Log10(gHi))/12)
+
+	x := math.Exp(-2 * math.Pi * crossfeedCutoff / sampleRate)
+	c.loB1 = x
+	c.loA0 = gLo * (1 - x)
+
+	x = math.Exp(-2 * math.Pi * fcHi / sampleRate)
+	c.hiB1 = x
+	c.hiA0 = 1 - gHi*(1-x)
+	c.hiA1 = -x
+
+	c.crossGain = 1 / (1 - gHi + gLo)
+
+	c.bassCoef = 1 - math.Exp(-2*math.Pi*karaokeBassBelow/sampleRate)
+
+	c.resetLocked()
+}
+
+// reset clears the filter state, e.g. after a seek.
+func (c *ChannelMixer) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetLocked()
+}
+
+func (c *ChannelMixer) resetLocked() {
+	c.lo, c.hi, c.prev = [2]float64{}, [2]float64{}, [2]float64{}
+	c.bass = [2]float64{}
+}
@@ -0,0 +1,128 @@
+package player
+
+import (
+	"math"
+	"testing"
+)
+
+// stereoStreamer plays independent sines on the two channels.
+type stereoStreamer struct {
+	left, right sineStreamer
+}
+
+func (s *stereoStreamer) Stream(samples [][2]float64) (int, bool) {
+	l := make([][2]float64, len(samples))
+	n, _ := s.left.Stream(l)
+	s.right.Stream(samples[:n])
+
+	for i := range n {
+		samples[i][0] = l[i][0]
+	}
+
+	return n, true
+}
+
+func (s *stereoStreamer) Err() error { return nil }
+
+func newStereo(left, right float64) *stereoStreamer {
+	return &stereoStreamer{
+		left:  sineStreamer{freq: left, rate: 48000},
+		right: sineStreamer{freq: right, rate: 48000},
+	}
+}
+
+func rms(samples [][2]float64, ch int) float64 {
+	sum := 0.0
+	for _, s := range samples {
+		sum += s[ch] * s[ch]
+	}
+
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestChannelMixerNeutralPassesThrough(t *testing.T) {
+	c := NewChannelMixer(newStereo(440, 660), 48000)
+
+	buf := make([][2]float64, 1000)
+	c.Stream(buf)
+
+	want := newStereo(440, 660)
+	ref := make([][2]float64, 1000)
+	want.Stream(ref)
+
+	for i := range buf {
+		if buf[i] != ref[i] {
+			t.Fatalf("sample %d: got %v, want %v", i, buf[i], ref[i])
+		}
+	}
+}
+
+func TestChannelMixerMonoAndBalance(t *testing.T) {
+	c := NewChannelMixer(newStereo(440, 660), 48000)
+	c.SetSettings(ChannelSettings{Mono: true})
+
+	buf := make([][2]float64, 1000)
+	c.Stream(buf)
+	for i, s := range buf {
+		if s[0] != s[1] {
+			t.Fatalf("mono sample %d: got %v, want equal channels", i, s)
+		}
+	}
+
+	c.SetSettings(ChannelSettings{Balance: 0.5})
+	c.Stream(buf)
+	if got := rms(buf, 0) / rms(buf, 1); math.Abs(got-0.5) > 0.05 {
+		t.Errorf("balance 0.5 left/right: got %.3f, want 0.5", got)
+	}
+
+	c.SetSettings(ChannelSettings{Balance: -3})
+	if got := c.Settings().Balance; got != -1 {
+		t.Errorf("balance clamp: got %v, want -1", got)
+	}
+}
+
+func TestChannelMixerKaraokeCancelsCentre(t *testing.T) {
+	// A 1 kHz "vocal" in the centre
+	c := NewChannelMixer(newStereo(1000, 1000), 48000)
+	c.SetSettings(ChannelSettings{Karaoke: true})
+
+	buf := make([][2]float64, 48000)
+	c.Stream(buf)
+
+	if got := rms(buf[4800:], 0); got > 0.05 {
+		t.Errorf("centre rms: got %.3f, want it cancelled", got)
+	}
+
+	// Bass in the centre stays
+	c = NewChannelMixer(newStereo(50, 50), 48000)
+	c.SetSettings(ChannelSettings{Karaoke: true})
+	c.Stream(buf)
+
+	if got := rms(buf[4800:], 0); got < 0.25 {
+		t.Errorf("bass rms: got %.3f, want it kept", got)
+	}
+}
+
+func TestChannelMixerCrossfeedBleedsLows(t *testing.T) {
+	// A bass line hard left reaches the right ear, a treble line hardly does.
+	for _, tc := range []struct {
+		freq     float64
+		min, max float64
+	}{
+		{100, 0.2, 1},
+		{8000, 0, 0.1},
+	} {
+		src := &stereoStreamer{left: sineStreamer{freq: tc.freq, rate: 48000}}
+		src.right.rate = 48000
+		c := NewChannelMixer(src, 48000)
+		c.SetSettings(ChannelSettings{Crossfeed: true})
+
+		buf := make([][2]float64, 48000)
+		c.Stream(buf)
+
+		ratio := rms(buf[4800:], 1) / rms(buf[4800:], 0)
+		if ratio < tc.min || ratio > tc.max {
+			t.Errorf("%v Hz right/left: got %.3f, want %v to %v", tc.freq, ratio, tc.min, tc.max)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package player
+
+import "math"
+
+// equalPowerGains returns the gains of the outgoing and incoming signal at
+// progress t (0 to 1) through an equal-power crossfade. The squares of the
+// two gains always sum to one, so uncorrelated material keeps a constant
+// perceived loudness across the overlap.
+func equalPowerGains(t float64) (out, in float64) {
+	switch {
+	case t <= 0:
+		return 1, 0
+	case t >= 1:
+		return 0, 1
+	}
+
+	angle := t * math.Pi / 2
+
+	return math.Cos(angle), math.Sin(angle)
+}
+
+// fadeRamp is a short equal-power fade applied to a single stream, used when
+// the user skips tracks so that the cut is not audible as a click.
+type fadeRamp struct {
+	pos    int
+	length int
+	in     bool          // fade in rather than out
+	done   chan struct{} // closed once the ramp has been fully rendered
+}
+
+func newFadeRamp(length int, in bool) *fadeRamp {
+	return &fadeRamp{length: max(length, 1), in: in, done: make(chan struct{})}
+}
+
+// apply scales samples along the ramp. Once a fade-out has completed the
+// samples are silenced, so that nothing leaks through until the stream is
+// replaced.
+func (r *fadeRamp) apply(samples [][2]float64) {
+	for i := range samples {
+		out, in := equalPowerGains(float64(r.pos) / float64(r.length))
+
+		gain := out
+		if r.in {
+			gain = in
+		}
+
+		samples[i][0] *= gain
+		samples[i][1] *= gain
+
+		if r.pos < r.length {
+			r.pos++
+			if r.pos == r.length {
+				close(r.done)
+			}
+		}
+	}
+}
+
+// finished reports whether the whole ramp has been rendered.
+func (r *fadeRamp) finished() bool {
+	return r.pos >= r.length
+}
@@ -0,0 +1,33 @@
+package player
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqualPowerGains(t *testing.T) {
+	for _, tc := range []struct {
+		t       float64
+		out, in float64
+	}{
+		{0, 1, 0},
+		{1, 0, 1},
+		{0.5, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{-1, 1, 0},
+		{2, 0, 1},
+	} {
+		out, in := equalPowerGains(tc.t)
+		if math.Abs(out-tc.out) > 1e-9 || math.Abs(in-tc.in) > 1e-9 {
+			t.Errorf("equalPowerGains(%v): got (%f, %f), want (%f, %f)", tc.t, out, in, tc.out, tc.in)
+		}
+	}
+}
+
+func TestEqualPowerGainsConstantPower(t *testing.T) {
+	for i := 0; i <= 100; i++ {
+		out, in := equalPowerGains(float64(i) / 100)
+		if power := out*out + in*in; math.Abs(power-1) > 1e-9 {
+			t.Errorf("power at %d%%: got %f, want 1", i, power)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package player
 
 import (
+	"fmt"
 	"math"
+	"math/cmplx"
 	"sync"
 
 	"github.com/faiface/beep"
@@ -20,21 +22,100 @@ var BandLabels = [NumBands]string{
 	"31", "63", "125", "250", "500", "1k", "2k", "4k", "8k", "16k",
 }
 
-// EQPreset maps a name to 10 band gains in dB.
+// BandType is the filter shape of an EQ band.
+type BandType string
+
+// Band types accepted in presets.
+const (
+	BandPeaking   BandType = "peaking"
+	BandLowShelf  BandType = "low_shelf"
+	BandHighShelf BandType = "high_shelf"
+	BandHighPass  BandType = "high_pass"
+	BandLowPass   BandType = "low_pass"
+)
+
+// MaxBands is the most filters a preset may have.
+const MaxBands = 16
+
+// defaultBandQ is the Q of the graphic EQ bands and of peaking bands that
+// leave it unset. Shelves and pass filters default to a Butterworth Q.
+const (
+	defaultBandQ = 1.414
+	defaultPassQ = 0.7071
+)
+
+// EQBand describes a single filter of a parametric EQ.
+type EQBand struct {
+	Type BandType
+	Freq float64 // center or corner frequency in Hz
+	Gain float64 // dB; ignored by the pass filters
+	Q    float64
+}
+
+// EQPreset is a named chain of EQ bands with a preamp applied before them.
 type EQPreset struct {
-	Name  string
-	Gains [NumBands]float64
+	Name   string
+	Preamp float64 // dB
+	Bands  []EQBand
+}
+
+// Validate checks a preset and fills in default Q values.
+func (p *EQPreset) Validate() error {
+	if len(p.Bands) == 0 || len(p.Bands) > MaxBands {
+		return fmt.Errorf("a preset needs between 1 and %d bands, got %d", MaxBands, len(p.Bands))
+	}
+
+	for i := range p.Bands {
+		b := &p.Bands[i]
+
+		switch b.Type {
+		case BandPeaking, BandLowShelf, BandHighShelf, BandHighPass, BandLowPass:
+		case "":
+			b.Type = BandPeaking
+		default:
+			return fmt.Errorf("band %d: unknown type %q", i+1, b.Type)
+		}
+
+		if b.Freq <= 0 {
+			return fmt.Errorf("band %d: frequency must be positive, got %g", i+1, b.Freq)
+		}
+
+		if b.Q <= 0 {
+			b.Q = defaultPassQ
+			if b.Type == BandPeaking {
+				b.Q = defaultBandQ
+			}
+		}
+	}
+
+	return nil
+}
+
+// GraphicBands returns the bands of the 10-band graphic EQ with the given
+// gains.
+func GraphicBands(gains [NumBands]float64) []EQBand {
+	bands := make([]EQBand, NumBands)
+	for i := range bands {
+		bands[i] = EQBand{Type: BandPeaking, Freq: DefaultBandFrequencies[i], Gain: gains[i], Q: defaultBandQ}
+	}
+
+	return bands
+}
+
+// graphicPreset builds a built-in preset for the 10-band graphic EQ.
+func graphicPreset(name string, gains [NumBands]float64) EQPreset {
+	return EQPreset{Name: name, Bands: GraphicBands(gains)}
 }
 
 // EQPresets contains built-in equalizer presets.
 var EQPresets = map[string]EQPreset{
-	"flat":         {Name: "Flat", Gains: [10]float64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
-	"bass_boost":   {Name: "Bass Boost", Gains: [10]float64{6, 5, 4, 2, 0, 0, 0, 0, 0, 0}},
-	"treble_boost": {Name: "Treble Boost", Gains: [10]float64{0, 0, 0, 0, 0, 0, 2, 4, 5, 6}},
-	"vocal":        {Name: "Vocal", Gains: [10]float64{-2, -1, 0, 2, 4, 4, 2, 0, -1, -2}},
-	"rock":         {Name: "Rock", Gains: [10]float64{4, 3, 1, 0, -1, -1, 0, 2, 3, 4}},
-	"electronic":   {Name: "Electronic", Gains: [10]float64{5, 4, 2, 0, -2, 0, 2, 4, 4, 3}},
-	"acoustic":     {Name: "Acoustic", Gains: [10]float64{3, 2, 1, 0, 1, 1, 2, 3, 2, 1}},
+	"flat":         graphicPreset("Flat", [10]float64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}),
+	"bass_boost":   graphicPreset("Bass Boost", [10]float64{6, 5, 4, 2, 0, 0, 0, 0, 0, 0}),
+	"treble_boost": graphicPreset("Treble Boost", [10]float64{0, 0, 0, 0, 0, 0, 2, 4, 5, 6}),
+	"vocal":        graphicPreset("Vocal", [10]float64{-2, -1, 0, 2, 4, 4, 2, 0, -1, -2}),
+	"rock":         graphicPreset("Rock", [10]float64{4, 3, 1, 0, -1, -1, 0, 2, 3, 4}),
+	"electronic":   graphicPreset("Electronic", [10]float64{5, 4, 2, 0, -2, 0, 2, 4, 4, 3}),
+	"acoustic":     graphicPreset("Acoustic", [10]float64{3, 2, 1, 0, 1, 1, 2, 3, 2, 1}),
 }
 
 // PresetOrder defines the display order for cycling through presets.
@@ -52,37 +133,38 @@ type biquadCoeffs struct {
 	a1, a2     float64
 }
 
-// biquadFilter represents a single parametric EQ band with stereo state.
+// biquadFilter is one EQ band with stereo state.
 type biquadFilter struct {
 	coeffs biquadCoeffs
 	state  [2]biquadState // 0=left, 1=right
 }
 
-// Equalizer implements beep.Streamer, applying a 10-band parametric EQ.
+// Equalizer implements beep.Streamer, applying a chain of biquad filters
+// built from a list of EQ bands. It starts out as a flat 10-band graphic EQ.
 type Equalizer struct {
 	streamer   beep.Streamer
 	mu         sync.Mutex
-	bands      [NumBands]biquadFilter
-	gains      [NumBands]float64
+	bands      []EQBand
+	filters    []biquadFilter
+	preamp     float64 // dB
+	preampGain float64 // linear
 	sampleRate float64
 	enabled    bool
 }
 
-// NewEqualizer creates a new 10-band equalizer wrapping the given streamer.
+// NewEqualizer creates a new equalizer wrapping the given streamer.
 func NewEqualizer(streamer beep.Streamer, sampleRate float64) *Equalizer {
 	eq := &Equalizer{
 		streamer:   streamer,
 		sampleRate: sampleRate,
 		enabled:    true,
 	}
-	for i := range NumBands {
-		eq.bands[i].coeffs = calcPeakingEQ(DefaultBandFrequencies[i], 0, 1.414, sampleRate)
-	}
+	eq.setBands(GraphicBands([NumBands]float64{}), 0)
 	return eq
 }
 
-// Stream implements beep.Streamer. It reads from the underlying streamer
-// and applies all 10 biquad filters in series to each sample.
+// Stream implements beep.Streamer. It reads from the underlying streamer,
+// applies the preamp and runs each sample through all filters in series.
 func (eq *Equalizer) Stream(samples [][2]float64) (n int, ok bool) {
 	n, ok = eq.streamer.Stream(samples)
 	if n == 0 || !eq.enabled {
@@ -90,9 +172,11 @@ func (eq *Equalizer) Stream(samples [][2]float64) (n int, ok bool) {
 	}
 
 	eq.mu.Lock()
+	applyGain(samples[:n], eq.preampGain)
+
 	for i := range n {
-		for band := range NumBands {
-			f := &eq.bands[band]
+		for band := range eq.filters {
+			f := &eq.filters[band]
 			c := &f.coeffs
 
 			// Left channel
@@ -122,37 +206,75 @@ func (eq *Equalizer) Err() error {
 	return eq.streamer.Err()
 }
 
+// SetPreset replaces the filter chain with the bands and preamp of a
+// validated preset.
+func (eq *Equalizer) SetPreset(preset EQPreset) {
+	eq.mu.Lock()
+	eq.setBands(preset.Bands, preset.Preamp)
+	eq.mu.Unlock()
+}
+
+// setBands rebuilds the filter chain. Callers hold eq.mu.
+func (eq *Equalizer) setBands(bands []EQBand, preamp float64) {
+	eq.bands = make([]EQBand, len(bands))
+	eq.filters = make([]biquadFilter, len(bands))
+
+	for i, b := range bands {
+		b.Gain = clampGain(b.Gain)
+		eq.bands[i] = b
+		eq.filters[i].coeffs = calcBiquad(b, eq.sampleRate)
+	}
+
+	eq.preamp = clampGain(preamp)
+	eq.preampGain = decibelsToGain(eq.preamp)
+}
+
+// Bands returns a copy of the current bands.
+func (eq *Equalizer) Bands() []EQBand {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	return append([]EQBand(nil), eq.bands...)
+}
+
+// Preamp returns the preamp in dB.
+func (eq *Equalizer) Preamp() float64 {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	return eq.preamp
+}
+
 // SetBandGain sets the gain for a single band in dB (-12 to +12).
 func (eq *Equalizer) SetBandGain(band int, gainDB float64) {
-	if band < 0 || band >= NumBands {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	if band < 0 || band >= len(eq.bands) {
 		return
 	}
-	gainDB = clampGain(gainDB)
 
-	eq.mu.Lock()
-	eq.gains[band] = gainDB
-	eq.bands[band].coeffs = calcPeakingEQ(DefaultBandFrequencies[band], gainDB, 1.414, eq.sampleRate)
-	eq.bands[band].state = [2]biquadState{}
-	eq.mu.Unlock()
+	eq.bands[band].Gain = clampGain(gainDB)
+	eq.filters[band].coeffs = calcBiquad(eq.bands[band], eq.sampleRate)
+	eq.filters[band].state = [2]biquadState{}
 }
 
-// SetAllGains sets all band gains at once (e.g., for applying a preset).
+// SetAllGains switches to the 10-band graphic EQ with the given gains.
 func (eq *Equalizer) SetAllGains(gains [NumBands]float64) {
 	eq.mu.Lock()
-	for i := range NumBands {
-		g := clampGain(gains[i])
-		eq.gains[i] = g
-		eq.bands[i].coeffs = calcPeakingEQ(DefaultBandFrequencies[i], g, 1.414, eq.sampleRate)
-		eq.bands[i].state = [2]biquadState{}
-	}
+	eq.setBands(GraphicBands(gains), 0)
 	eq.mu.Unlock()
 }
 
-// GetGains returns a copy of the current band gains.
+// GetGains returns the gains of the first NumBands bands.
 func (eq *Equalizer) GetGains() [NumBands]float64 {
 	eq.mu.Lock()
-	gains := eq.gains
-	eq.mu.Unlock()
+	defer eq.mu.Unlock()
+
+	var gains [NumBands]float64
+	for i := range min(len(eq.bands), NumBands) {
+		gains[i] = eq.bands[i].Gain
+	}
 	return gains
 }
 
@@ -175,9 +297,9 @@ func (eq *Equalizer) IsEnabled() bool {
 func (eq *Equalizer) UpdateSampleRate(sampleRate float64) {
 	eq.mu.Lock()
 	eq.sampleRate = sampleRate
-	for i := range NumBands {
-		eq.bands[i].coeffs = calcPeakingEQ(DefaultBandFrequencies[i], eq.gains[i], 1.414, sampleRate)
-		eq.bands[i].state = [2]biquadState{}
+	for i := range eq.filters {
+		eq.filters[i].coeffs = calcBiquad(eq.bands[i], sampleRate)
+		eq.filters[i].state = [2]biquadState{}
 	}
 	eq.mu.Unlock()
 }
@@ -185,12 +307,107 @@ func (eq *Equalizer) UpdateSampleRate(sampleRate float64) {
 // ResetState resets filter state to avoid transient pops after seek.
 func (eq *Equalizer) ResetState() {
 	eq.mu.Lock()
-	for i := range NumBands {
-		eq.bands[i].state = [2]biquadState{}
+	for i := range eq.filters {
+		eq.filters[i].state = [2]biquadState{}
 	}
 	eq.mu.Unlock()
 }
 
+// FrequencyResponse returns the combined gain in dB of a preamp and a chain
+// of EQ bands at each of freqs, computed from the same coefficients the
+// equalizer uses.
+func FrequencyResponse(bands []EQBand, preamp, sampleRate float64, freqs []float64) []float64 {
+	coeffs := make([]biquadCoeffs, len(bands))
+	for i, b := range bands {
+		b.Gain = clampGain(b.Gain)
+		coeffs[i] = calcBiquad(b, sampleRate)
+	}
+
+	response := make([]float64, len(freqs))
+	for i, f := range freqs {
+		response[i] = clampGain(preamp)
+		for _, c := range coeffs {
+			response[i] += c.responseDB(f, sampleRate)
+		}
+	}
+
+	return response
+}
+
+// responseDB returns the gain of the filter at freq in dB.
+func (c biquadCoeffs) responseDB(freq, sampleRate float64) float64 {
+	z1 := cmplx.Exp(complex(0, -2*math.Pi*freq/sampleRate))
+	z2 := z1 * z1
+
+	num := complex(c.b0, 0) + complex(c.b1, 0)*z1 + complex(c.b2, 0)*z2
+	den := 1 + complex(c.a1, 0)*z1 + complex(c.a2, 0)*z2
+
+	return 20 * math.Log10(cmplx.Abs(num/den))
+}
+
+// calcBiquad computes the coefficients of a band from the RBJ Audio EQ
+// Cookbook formulas.
+func calcBiquad(b EQBand, sampleRate float64) biquadCoeffs {
+	if b.Type == BandPeaking {
+		return calcPeakingEQ(b.Freq, b.Gain, b.Q, sampleRate)
+	}
+
+	// Guard against Nyquist
+	if b.Freq >= sampleRate/2 {
+		return biquadCoeffs{b0: 1}
+	}
+
+	A := math.Pow(10, b.Gain/40.0)
+	w0 := 2.0 * math.Pi * b.Freq / sampleRate
+	sinW0 := math.Sin(w0)
+	cosW0 := math.Cos(w0)
+	alpha := sinW0 / (2.0 * b.Q)
+	sqrtA := 2.0 * math.Sqrt(A) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+
+	switch b.Type {
+	case BandLowShelf:
+		b0 = A * ((A + 1) - (A-1)*cosW0 + sqrtA)
+		b1 = 2 * A * ((A - 1) - (A+1)*cosW0)
+		b2 = A * ((A + 1) - (A-1)*cosW0 - sqrtA)
+		a0 = (A + 1) + (A-1)*cosW0 + sqrtA
+		a1 = -2 * ((A - 1) + (A+1)*cosW0)
+		a2 = (A + 1) + (A-1)*cosW0 - sqrtA
+	case BandHighShelf:
+		b0 = A * ((A + 1) + (A-1)*cosW0 + sqrtA)
+		b1 = -2 * A * ((A - 1) + (A+1)*cosW0)
+		b2 = A * ((A + 1) + (A-1)*cosW0 - sqrtA)
+		a0 = (A + 1) - (A-1)*cosW0 + sqrtA
+		a1 = 2 * ((A - 1) - (A+1)*cosW0)
+		a2 = (A + 1) - (A-1)*cosW0 - sqrtA
+	case BandHighPass:
+		b0 = (1 + cosW0) / 2
+		b1 = -(1 + cosW0)
+		b2 = (1 + cosW0) / 2
+		a0 = 1 + alpha
+		a1 = -2 * cosW0
+		a2 = 1 - alpha
+	case BandLowPass:
+		b0 = (1 - cosW0) / 2
+		b1 = 1 - cosW0
+		b2 = (1 - cosW0) / 2
+		a0 = 1 + alpha
+		a1 = -2 * cosW0
+		a2 = 1 - alpha
+	default:
+		return biquadCoeffs{b0: 1}
+	}
+
+	return biquadCoeffs{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b2 / a0,
+		a1: a1 / a0,
+		a2: a2 / a0,
+	}
+}
+
 // calcPeakingEQ computes normalized biquad coefficients for an RBJ peaking EQ filter.
 func calcPeakingEQ(freq, gainDB, q, sampleRate float64) biquadCoeffs {
 	// Guard against Nyquist
@@ -130,3 +130,97 @@ func TestCalcPeakingEQSymmetry(t *testing.T) {
 		t.Errorf("boost*cut b0 product: got %f, expected close to 1.0", product)
 	}
 }
+
+func TestCalcBiquadBandTypes(t *testing.T) {
+	tests := []struct {
+		band       EQBand
+		freq, want float64
+	}{
+		{EQBand{Type: BandLowShelf, Freq: 200, Gain: 6, Q: defaultPassQ}, 10, 6},
+		{EQBand{Type: BandLowShelf, Freq: 200, Gain: 6, Q: defaultPassQ}, 15000, 0},
+		{EQBand{Type: BandHighShelf, Freq: 5000, Gain: -6, Q: defaultPassQ}, 20000, -6},
+		{EQBand{Type: BandHighShelf, Freq: 5000, Gain: -6, Q: defaultPassQ}, 50, 0},
+		{EQBand{Type: BandHighPass, Freq: 100, Q: defaultPassQ}, 100, -3},
+		{EQBand{Type: BandHighPass, Freq: 100, Q: defaultPassQ}, 5000, 0},
+		{EQBand{Type: BandLowPass, Freq: 8000, Q: defaultPassQ}, 8000, -3},
+		{EQBand{Type: BandLowPass, Freq: 8000, Q: defaultPassQ}, 100, 0},
+		{EQBand{Type: BandPeaking, Freq: 1000, Gain: 4, Q: 2}, 1000, 4},
+	}
+
+	for _, tt := range tests {
+		got := calcBiquad(tt.band, 48000).responseDB(tt.freq, 48000)
+		if math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("%s at %g Hz, response at %g Hz: got %.2f dB, want %.2f dB",
+				tt.band.Type, tt.band.Freq, tt.freq, got, tt.want)
+		}
+	}
+}
+
+func TestEQPresetValidate(t *testing.T) {
+	preset := EQPreset{Bands: []EQBand{
+		{Freq: 1000, Gain: 3},
+		{Type: BandHighPass, Freq: 30},
+	}}
+	if err := preset.Validate(); err != nil {
+		t.Fatalf("Validate: unexpected error: %v", err)
+	}
+
+	if preset.Bands[0].Type != BandPeaking || preset.Bands[0].Q != defaultBandQ {
+		t.Errorf("band 1: got %+v, want a peaking band with Q %v", preset.Bands[0], defaultBandQ)
+	}
+	if preset.Bands[1].Q != defaultPassQ {
+		t.Errorf("band 2 Q: got %v, want %v", preset.Bands[1].Q, defaultPassQ)
+	}
+
+	invalid := []EQPreset{
+		{},
+		{Bands: []EQBand{{Type: "notch", Freq: 1000}}},
+		{Bands: []EQBand{{Freq: 0}}},
+		{Bands: make([]EQBand, MaxBands+1)},
+	}
+	for i, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("invalid preset %d: got no error", i)
+		}
+	}
+}
+
+func TestEqualizerPresetPreamp(t *testing.T) {
+	eq := NewEqualizer(&sineStreamer{freq: 1000, rate: 48000}, 48000)
+	eq.SetPreset(EQPreset{Preamp: -6, Bands: []EQBand{{Type: BandPeaking, Freq: 100, Gain: 0, Q: 1}}})
+
+	samples := make([][2]float64, 100)
+	eq.Stream(samples)
+
+	for i, got := range samples {
+		want := 0.5 * math.Sin(2*math.Pi*1000*float64(i)/48000) * decibelsToGain(-6)
+		if math.Abs(got[0]-want) > 1e-9 {
+			t.Fatalf("sample %d: got %v, want %v", i, got[0], want)
+		}
+	}
+
+	if got := len(eq.Bands()); got != 1 {
+		t.Errorf("Bands: got %d bands, want 1", got)
+	}
+
+	// The graphic EQ helpers switch back to ten bands.
+	eq.SetAllGains([NumBands]float64{3})
+	if got := eq.GetGains(); got[0] != 3 || len(eq.Bands()) != NumBands || eq.Preamp() != 0 {
+		t.Errorf("SetAllGains: got gains %v, %d bands, preamp %v", got, len(eq.Bands()), eq.Preamp())
+	}
+}
+
+func TestFrequencyResponseSumsBands(t *testing.T) {
+	bands := []EQBand{
+		{Type: BandPeaking, Freq: 1000, Gain: 6, Q: 1.414},
+		{Type: BandPeaking, Freq: 1000, Gain: 3, Q: 1.414},
+	}
+
+	got := FrequencyResponse(bands, -2, 48000, []float64{1000, 20})
+	if math.Abs(got[0]-7) > 0.01 {
+		t.Errorf("response at 1 kHz: got %.2f dB, want 7 dB", got[0])
+	}
+	if math.Abs(got[1]+2) > 0.05 {
+		t.Errorf("response at 20 Hz: got %.2f dB, want -2 dB", got[1])
+	}
+}
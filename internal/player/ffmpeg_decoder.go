@@ -0,0 +1,381 @@
+package player
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+)
+
+// ffmpegDecoder decodes containers beep has no native decoder for (Opus in
+// WebM/Ogg, AAC in MP4) by piping 16-bit stereo PCM out of an ffmpeg
+// process, so playing them needs ffmpeg and ffprobe on the PATH. Seeking
+// restarts the process at the new position.
+//
+// A file that is still downloading is fed to ffmpeg through stdin, so that
+// decoding waits for data instead of ending early. ffmpeg cannot seek in a
+// pipe; it decodes from the start and discards up to the target instead.
+type ffmpegDecoder struct {
+	path     string
+	format   beep.Format
+	total    int // length in samples, from ffprobe
+	position int
+
+	// mu serializes Stream with Seek and Close. Stream may block on a
+	// download while holding it, so position, total, err and the input
+	// are guarded by stateMu instead, and interrupt only takes stateMu.
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	reader *bufio.Reader
+	frame  []byte
+	err    error
+
+	download    *PartialDownload
+	file        *os.File // shared by the inputs of a partial download
+	stateMu     sync.Mutex
+	input       *progressiveReader
+	interrupted bool
+}
+
+// audioProbe holds the stream properties reported by ffprobe.
+type audioProbe struct {
+	sampleRate int
+	channels   int
+	duration   time.Duration
+}
+
+// DecodeFFmpeg decodes a file through an ffmpeg subprocess. The file itself
+// is only used for its path and is closed immediately.
+func DecodeFFmpeg(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
+	path := file.Name()
+	file.Close()
+
+	probe, err := probeAudio(path)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(probe.sampleRate),
+		NumChannels: 2, // ffmpeg downmixes/upmixes to stereo
+		Precision:   2,
+	}
+
+	d := &ffmpegDecoder{
+		path:   path,
+		format: format,
+		total:  format.SampleRate.N(probe.duration),
+		frame:  make([]byte, 4),
+	}
+
+	if err := d.start(0); err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	logger.Debug("ffmpeg: decoding %s at %d Hz (%d source channels), %v",
+		path, probe.sampleRate, probe.channels, probe.duration)
+
+	return d, format, nil
+}
+
+// DecodeFFmpegPartial decodes a file that is still being downloaded. The
+// stream headers must already be on disk. duration is the expected length
+// of the track; when zero, ffprobe's estimate from the partial file is used.
+func DecodeFFmpegPartial(download *PartialDownload, duration time.Duration) (beep.StreamSeekCloser, beep.Format, error) {
+	probe, err := probeAudio(download.Path())
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	file, err := os.Open(download.Path())
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to open partial download: %w", err)
+	}
+
+	if duration <= 0 {
+		duration = probe.duration
+	}
+
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(probe.sampleRate),
+		NumChannels: 2,
+		Precision:   2,
+	}
+
+	d := &ffmpegDecoder{
+		path:     download.Path(),
+		format:   format,
+		total:    format.SampleRate.N(duration),
+		frame:    make([]byte, 4),
+		download: download,
+		file:     file,
+	}
+
+	if err := d.start(0); err != nil {
+		file.Close()
+		return nil, beep.Format{}, err
+	}
+
+	logger.Debug("ffmpeg: streaming partial download %s at %d Hz, expected %v",
+		download.Path(), probe.sampleRate, duration)
+
+	return d, format, nil
+}
+
+// probeAudio asks ffprobe for the sample rate, channel count and duration
+// of the first audio stream.
+func probeAudio(path string) (audioProbe, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-select_streams", "a:0",
+		"-show_entries", "stream=sample_rate,channels:format=duration",
+		"-of", "default=noprint_wrappers=1",
+		path,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return audioProbe{}, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	return parseAudioProbe(string(output))
+}
+
+// parseAudioProbe parses ffprobe key=value output.
+func parseAudioProbe(output string) (audioProbe, error) {
+	var probe audioProbe
+
+	for line := range strings.SplitSeq(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "sample_rate":
+			probe.sampleRate, _ = strconv.Atoi(value)
+		case "channels":
+			probe.channels, _ = strconv.Atoi(value)
+		case "duration":
+			probe.duration = ParseDurationOutput(value)
+		}
+	}
+
+	if probe.sampleRate <= 0 {
+		return probe, fmt.Errorf("no audio stream found")
+	}
+
+	return probe, nil
+}
+
+// start launches ffmpeg decoding from the given sample position.
+func (d *ffmpegDecoder) start(position int) error {
+	var seek []string
+	if position > 0 {
+		// Microseconds are finer than a sample at any supported rate
+		seek = []string{"-ss", strconv.FormatFloat(d.format.SampleRate.D(position).Seconds(), 'f', 6, 64)}
+	}
+
+	var input *progressiveReader
+
+	args := []string{"-v", "error"}
+	if d.download != nil {
+		// As an output option, -ss decodes and discards up to the position.
+		input = d.download.newReader(d.file)
+		args = append(args, "-i", "pipe:0")
+		args = append(args, seek...)
+	} else {
+		args = append(args, "-nostdin")
+		args = append(args, seek...)
+		args = append(args, "-i", d.path)
+	}
+
+	args = append(args,
+		"-vn",
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"-ac", "2",
+		"-ar", strconv.Itoa(int(d.format.SampleRate)),
+		"-",
+	)
+
+	cmd := exec.Command("ffmpeg", args...)
+	if input != nil {
+		cmd.Stdin = input
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create ffmpeg pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	d.cmd = cmd
+	d.stdout = stdout
+	d.reader = bufio.NewReaderSize(stdout, 64*1024)
+
+	d.stateMu.Lock()
+	d.position = position
+	d.input = input
+	d.interrupted = false
+	d.stateMu.Unlock()
+
+	return nil
+}
+
+// interrupt wakes a Stream call that is waiting for downloaded data by
+// ending ffmpeg's input. It is safe to call concurrently with Stream.
+func (d *ffmpegDecoder) interrupt() {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	if d.input != nil {
+		d.interrupted = true
+		d.input.Close()
+	}
+}
+
+// stop terminates the running ffmpeg process, if any.
+func (d *ffmpegDecoder) stop() {
+	if d.cmd == nil {
+		return
+	}
+
+	// exec waits for the stdin copy, which may be blocked on the download.
+	d.interrupt()
+
+	if d.cmd.Process != nil {
+		_ = d.cmd.Process.Kill()
+	}
+
+	_ = d.cmd.Wait()
+	d.cmd = nil
+	d.stdout = nil
+	d.reader = nil
+}
+
+// Stream implements beep.Streamer.
+func (d *ffmpegDecoder) Stream(samples [][2]float64) (n int, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.reader == nil {
+		return 0, false
+	}
+
+	for n < len(samples) {
+		if _, err := io.ReadFull(d.reader, d.frame); err != nil {
+			return d.handleReadError(n, err)
+		}
+
+		samples[n][0] = float64(int16(binary.LittleEndian.Uint16(d.frame[0:2]))) / 32768
+		samples[n][1] = float64(int16(binary.LittleEndian.Uint16(d.frame[2:4]))) / 32768
+		n++
+	}
+
+	d.stateMu.Lock()
+	d.position += n
+	d.stateMu.Unlock()
+
+	return n, true
+}
+
+// handleReadError ends the stream after n more samples, unless the input
+// was only cut short by a seek or close.
+func (d *ffmpegDecoder) handleReadError(n int, err error) (int, bool) {
+	d.stateMu.Lock()
+	d.position += n
+	interrupted := d.interrupted
+
+	if !interrupted {
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			d.err = err
+		}
+
+		// The real end of the stream is authoritative over ffprobe.
+		if d.position < d.total {
+			d.total = d.position
+		}
+	}
+	d.stateMu.Unlock()
+
+	if interrupted {
+		return n, true
+	}
+
+	d.stop()
+
+	return n, n > 0
+}
+
+// Err implements beep.Streamer.
+func (d *ffmpegDecoder) Err() error {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	return d.err
+}
+
+// Len implements beep.StreamSeeker.
+func (d *ffmpegDecoder) Len() int {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	return d.total
+}
+
+// Position implements beep.StreamSeeker.
+func (d *ffmpegDecoder) Position() int {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	return d.position
+}
+
+// Seek implements beep.StreamSeeker. On a partial download the new process
+// waits for the data at the target position to arrive.
+func (d *ffmpegDecoder) Seek(p int) error {
+	d.interrupt()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if total := d.Len(); p < 0 || (total > 0 && p > total) {
+		return fmt.Errorf("ffmpeg: seek position %d out of range [0, %d]", p, total)
+	}
+
+	d.stop()
+
+	return d.start(p)
+}
+
+// Close implements beep.StreamSeekCloser.
+func (d *ffmpegDecoder) Close() error {
+	d.interrupt()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stop()
+
+	if d.file != nil {
+		d.file.Close()
+		d.file = nil
+	}
+
+	return nil
+}
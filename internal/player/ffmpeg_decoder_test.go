@@ -0,0 +1,158 @@
+package player
+
+import (
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+func TestParseAudioProbe(t *testing.T) {
+	output := "sample_rate=48000\nchannels=2\nduration=212.341000\n"
+
+	probe, err := parseAudioProbe(output)
+	if err != nil {
+		t.Fatalf("parseAudioProbe: unexpected error: %v", err)
+	}
+	if probe.sampleRate != 48000 {
+		t.Errorf("sampleRate: got %d, want 48000", probe.sampleRate)
+	}
+	if probe.channels != 2 {
+		t.Errorf("channels: got %d, want 2", probe.channels)
+	}
+	if want := 212341 * time.Millisecond; probe.duration != want {
+		t.Errorf("duration: got %v, want %v", probe.duration, want)
+	}
+}
+
+func TestParseAudioProbeNoStream(t *testing.T) {
+	if _, err := parseAudioProbe("duration=12.0\n"); err == nil {
+		t.Error("parseAudioProbe: expected error without an audio stream")
+	}
+}
+
+// writeTestAudio has ffmpeg render a two second 440 Hz tone at 48 kHz into
+// a file of the given extension, skipping the test without ffmpeg.
+func writeTestAudio(t *testing.T, ext string) string {
+	t.Helper()
+
+	for _, tool := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "tone"+ext)
+
+	cmd := exec.Command("ffmpeg", "-v", "error", "-nostdin",
+		"-f", "lavfi", "-i", "sine=frequency=440:sample_rate=48000:duration=2",
+		"-ac", "2", path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ffmpeg: %v: %s", err, output)
+	}
+
+	return path
+}
+
+func openFFmpeg(t *testing.T, path string) beep.StreamSeekCloser {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	streamer, format, err := DecodeFFmpeg(file)
+	if err != nil {
+		t.Fatalf("DecodeFFmpeg: %v", err)
+	}
+	t.Cleanup(func() { streamer.Close() })
+
+	if format.SampleRate != 48000 {
+		t.Errorf("sample rate: got %d, want 48000", format.SampleRate)
+	}
+
+	return streamer
+}
+
+func streamAll(s beep.Streamer) [][2]float64 {
+	var out [][2]float64
+
+	buf := make([][2]float64, 4096)
+	for {
+		n, ok := s.Stream(buf)
+		out = append(out, buf[:n]...)
+
+		if !ok {
+			return out
+		}
+	}
+}
+
+func TestFFmpegDecode(t *testing.T) {
+	for _, ext := range []string{".wav", ".m4a"} {
+		t.Run(ext, func(t *testing.T) {
+			d := openFFmpeg(t, writeTestAudio(t, ext))
+
+			samples := streamAll(d)
+			if err := d.Err(); err != nil {
+				t.Fatalf("Err: %v", err)
+			}
+
+			// Lossy encoders may pad the end by up to a frame
+			if len(samples) < 96000 || len(samples) > 96000+2048 {
+				t.Errorf("decoded %d samples, want about 96000", len(samples))
+			}
+			// ffprobe's estimate gives way to the real end once it is reached
+			if d.Len() > len(samples) || d.Len() < len(samples)-2048 {
+				t.Errorf("Len after the end: got %d, want about %d", d.Len(), len(samples))
+			}
+
+			var peak float64
+			for _, s := range samples {
+				peak = max(peak, math.Abs(s[0]))
+			}
+
+			// lavfi's sine has an amplitude of 1/8
+			if peak < 0.1 || peak > 0.15 {
+				t.Errorf("peak: got %.3f, want about 0.125", peak)
+			}
+		})
+	}
+}
+
+func TestFFmpegSeekIsSampleAccurate(t *testing.T) {
+	path := writeTestAudio(t, ".wav")
+	want := streamAll(openFFmpeg(t, path))
+
+	d := openFFmpeg(t, path)
+
+	// Positions that are whole microseconds, as passed to ffmpeg
+	for _, target := range []int{48000, 12342, 0, 95004} {
+		if err := d.Seek(target); err != nil {
+			t.Fatalf("Seek(%d): %v", target, err)
+		}
+		if d.Position() != target {
+			t.Errorf("Position after Seek(%d): got %d", target, d.Position())
+		}
+
+		samples := make([][2]float64, 500)
+		if n, _ := d.Stream(samples); n != len(samples) {
+			t.Fatalf("Stream after Seek(%d): got %d samples, want %d", target, n, len(samples))
+		}
+
+		for i := range samples {
+			if samples[i] != want[target+i] {
+				t.Fatalf("Seek(%d): sample %d: got %v, want %v", target, target+i, samples[i], want[target+i])
+			}
+		}
+	}
+
+	if err := d.Seek(len(want) + 1); err == nil {
+		t.Error("Seek past end: expected error")
+	}
+}
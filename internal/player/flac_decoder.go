@@ -0,0 +1,974 @@
+package player
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+
+	"github.com/faiface/beep"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+)
+
+var (
+	// errFLACSync is returned when no frame header starts at the given offset.
+	errFLACSync = errors.New("flac: frame sync not found")
+
+	// errFLACChecksum is returned for a frame whose CRC-16 does not match.
+	errFLACChecksum = errors.New("flac: frame checksum mismatch")
+)
+
+// flacStreamInfo holds the fields of the STREAMINFO metadata block that the
+// decoder needs.
+type flacStreamInfo struct {
+	minBlockSize  int
+	maxBlockSize  int
+	maxFrameSize  int // 0 if unknown
+	sampleRate    int
+	channels      int
+	bitsPerSample int
+	totalSamples  int64 // 0 if unknown
+}
+
+// flacFrameHeader is a parsed frame header.
+type flacFrameHeader struct {
+	blockSize     int
+	sampleRate    int
+	assignment    int // channel assignment, 8-10 are the stereo decorrelation modes
+	channels      int
+	bitsPerSample int
+	firstSample   int64
+	size          int // header length in bytes, including the CRC-8
+}
+
+// flacDecoder is a native FLAC decoder implementing beep.StreamSeekCloser.
+// Frames are read from the file as they are decoded, through a window that
+// holds at least one whole frame. Seeking binary-searches the frame headers
+// and then discards samples up to the exact target. Mono streams are
+// duplicated to both channels and streams with more than two channels keep
+// the front left and right.
+type flacDecoder struct {
+	file       *os.File
+	size       int // file size in bytes
+	window     []byte
+	winStart   int // file offset of window[0]
+	frameBytes int // upper bound on the size of a frame
+	info       flacStreamInfo
+	firstFrame int // byte offset of the first frame
+	offset     int // byte offset of the next frame to decode
+	scale      float64
+
+	block      [][2]float64 // decoded samples of the current frame
+	blockStart int64
+	blockPos   int
+	position   int
+	subframes  [][]int64
+	err        error
+}
+
+// DecodeFLAC decodes a FLAC file. Length comes from STREAMINFO and seeking
+// is sample accurate.
+func DecodeFLAC(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, beep.Format{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	info, firstFrame, err := parseFLACMetadata(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, beep.Format{}, fmt.Errorf("failed to decode FLAC: %w", err)
+	}
+
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(info.sampleRate),
+		NumChannels: min(info.channels, 2),
+		Precision:   (info.bitsPerSample + 7) / 8,
+	}
+
+	// A frame is never larger than its samples stored verbatim, plus the
+	// side channel's extra bit, the frame and subframe headers and padding.
+	frameBytes := info.maxFrameSize
+	if frameBytes == 0 {
+		frameBytes = (max(info.maxBlockSize, 1)*info.channels*(info.bitsPerSample+1)+7)/8 + 64
+	}
+
+	return &flacDecoder{
+		file:       file,
+		size:       int(stat.Size()),
+		frameBytes: max(frameBytes, flacMinWindow),
+		info:       info,
+		firstFrame: firstFrame,
+		offset:     firstFrame,
+		scale:      1 / float64(int64(1)<<(info.bitsPerSample-1)),
+	}, format, nil
+}
+
+// flacMinWindow is the smallest read from the file, so that small frames
+// are read many at a time.
+const flacMinWindow = 64 << 10
+
+// bytes returns the file contents from off, at least n bytes of them unless
+// the file ends first. The slice is only valid until the next call.
+func (d *flacDecoder) bytes(off, n int) ([]byte, error) {
+	end := min(off+n, d.size)
+	if off >= d.winStart && end <= d.winStart+len(d.window) {
+		return d.window[off-d.winStart:], nil
+	}
+
+	length := min(max(n, d.frameBytes*2), d.size-off)
+	if length <= 0 {
+		return nil, io.EOF
+	}
+
+	if cap(d.window) < length {
+		d.window = make([]byte, length)
+	}
+	d.window = d.window[:length]
+
+	read, err := d.file.ReadAt(d.window, int64(off))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("flac: %w", err)
+	}
+
+	d.window = d.window[:read]
+	d.winStart = off
+
+	return d.window, nil
+}
+
+// parseFLACMetadata checks the stream marker, reads STREAMINFO and skips the
+// remaining metadata blocks. It returns the offset of the first frame.
+func parseFLACMetadata(r *bufio.Reader) (flacStreamInfo, int, error) {
+	var info flacStreamInfo
+
+	var marker [4]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || string(marker[:]) != "fLaC" {
+		return info, 0, fmt.Errorf("not a FLAC stream")
+	}
+
+	pos := 4
+	haveInfo := false
+
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return info, 0, io.ErrUnexpectedEOF
+		}
+
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		pos += 4 + length
+
+		if blockType != 0 {
+			if _, err := r.Discard(length); err != nil {
+				return info, 0, io.ErrUnexpectedEOF
+			}
+		} else {
+			if length < 34 {
+				return info, 0, fmt.Errorf("STREAMINFO too short")
+			}
+
+			b := make([]byte, length)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return info, 0, io.ErrUnexpectedEOF
+			}
+
+			packed := binary.BigEndian.Uint64(b[10:18])
+			info = flacStreamInfo{
+				minBlockSize:  int(binary.BigEndian.Uint16(b[0:2])),
+				maxBlockSize:  int(binary.BigEndian.Uint16(b[2:4])),
+				maxFrameSize:  int(b[7])<<16 | int(b[8])<<8 | int(b[9]),
+				sampleRate:    int(packed >> 44),
+				channels:      int(packed>>41&0x7) + 1,
+				bitsPerSample: int(packed>>36&0x1f) + 1,
+				totalSamples:  int64(packed & (1<<36 - 1)),
+			}
+			haveInfo = true
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if !haveInfo {
+		return info, 0, fmt.Errorf("missing STREAMINFO")
+	}
+
+	if info.sampleRate == 0 {
+		return info, 0, fmt.Errorf("invalid sample rate")
+	}
+
+	return info, pos, nil
+}
+
+// flacSampleRates maps frame header sample rate codes 1-11 to rates.
+var flacSampleRates = [...]int{0, 88200, 176400, 192000, 8000, 16000, 22050, 24000, 32000, 44100, 48000, 96000}
+
+// flacSampleSizes maps frame header sample size codes to bits per sample;
+// 0 means "from STREAMINFO" and -1 is reserved.
+var flacSampleSizes = [...]int{0, 8, 12, -1, 16, 20, 24, 32}
+
+// parseFrameHeader parses the frame header at the start of data and
+// verifies its CRC-8.
+func (d *flacDecoder) parseFrameHeader(data []byte) (flacFrameHeader, error) {
+	var h flacFrameHeader
+
+	if len(data) < 4 || data[0] != 0xff || data[1]&0xfe != 0xf8 {
+		return h, errFLACSync
+	}
+
+	variable := data[1]&0x01 != 0
+	sizeCode := int(data[2] >> 4)
+	rateCode := int(data[2] & 0x0f)
+	h.assignment = int(data[3] >> 4)
+	bpsCode := int(data[3] >> 1 & 0x07)
+
+	if data[3]&0x01 != 0 || sizeCode == 0 || rateCode == 15 || h.assignment > 10 || bpsCode == 3 {
+		return h, errFLACSync
+	}
+
+	pos := 4
+
+	// Frame or sample number, coded like UTF-8 with up to 7 bytes.
+	if pos >= len(data) {
+		return h, errFLACSync
+	}
+
+	lead := bits.LeadingZeros8(^data[pos])
+	if lead == 1 || lead > 7 {
+		return h, errFLACSync
+	}
+
+	number := uint64(data[pos])
+	if lead > 0 {
+		number &= 0x7f >> lead
+	}
+	pos++
+
+	for i := 1; i < lead; i++ {
+		if pos >= len(data) || data[pos]&0xc0 != 0x80 {
+			return h, errFLACSync
+		}
+
+		number = number<<6 | uint64(data[pos]&0x3f)
+		pos++
+	}
+
+	switch {
+	case sizeCode == 1:
+		h.blockSize = 192
+	case sizeCode <= 5:
+		h.blockSize = 576 << (sizeCode - 2)
+	case sizeCode == 6:
+		if pos+1 > len(data) {
+			return h, errFLACSync
+		}
+		h.blockSize = int(data[pos]) + 1
+		pos++
+	case sizeCode == 7:
+		if pos+2 > len(data) {
+			return h, errFLACSync
+		}
+		h.blockSize = int(binary.BigEndian.Uint16(data[pos:])) + 1
+		pos += 2
+	default:
+		h.blockSize = 256 << (sizeCode - 8)
+	}
+
+	switch {
+	case rateCode == 0:
+		h.sampleRate = d.info.sampleRate
+	case rateCode <= 11:
+		h.sampleRate = flacSampleRates[rateCode]
+	case rateCode == 12:
+		if pos+1 > len(data) {
+			return h, errFLACSync
+		}
+		h.sampleRate = int(data[pos]) * 1000
+		pos++
+	default:
+		if pos+2 > len(data) {
+			return h, errFLACSync
+		}
+		h.sampleRate = int(binary.BigEndian.Uint16(data[pos:]))
+		if rateCode == 14 {
+			h.sampleRate *= 10
+		}
+		pos += 2
+	}
+
+	h.channels = h.assignment + 1
+	if h.assignment >= 8 {
+		h.channels = 2
+	}
+
+	h.bitsPerSample = flacSampleSizes[bpsCode]
+	if bpsCode == 0 {
+		h.bitsPerSample = d.info.bitsPerSample
+	}
+
+	if pos >= len(data) || crc8(data[:pos]) != data[pos] {
+		return h, errFLACSync
+	}
+	pos++
+
+	// A valid header that disagrees with STREAMINFO is a false sync.
+	if h.channels != d.info.channels || h.sampleRate != d.info.sampleRate {
+		return h, errFLACSync
+	}
+
+	h.firstSample = int64(number)
+	if !variable {
+		h.firstSample *= int64(d.info.maxBlockSize)
+	}
+
+	h.size = pos
+
+	return h, nil
+}
+
+// decodeFrame decodes the next frame into d.block. A frame that fails to
+// decode or whose CRC-16 does not match plays as silence, and decoding
+// resumes at the next frame header; bytes that do not start a frame, such
+// as trailing tags, are skipped the same way.
+func (d *flacDecoder) decodeFrame() error {
+	for {
+		if d.offset >= d.size {
+			return io.EOF
+		}
+
+		data, err := d.bytes(d.offset, d.frameBytes)
+		if err != nil {
+			return err
+		}
+
+		h, err := d.parseFrameHeader(data)
+		if err != nil {
+			if !d.resync(d.offset + 1) {
+				return io.EOF
+			}
+
+			continue
+		}
+
+		size, err := d.decodeFrameBody(data, h)
+
+		// STREAMINFO may understate the largest frame
+		for n := len(data); errors.Is(err, io.ErrUnexpectedEOF) && d.offset+n < d.size; {
+			n *= 2
+			if data, err = d.bytes(d.offset, n); err == nil {
+				size, err = d.decodeFrameBody(data, h)
+			}
+		}
+
+		if err != nil {
+			logger.Warn("FLAC frame at sample %d is corrupt, playing silence: %v", h.firstSample, err)
+			d.silence(h)
+
+			if !d.resync(d.offset + h.size) {
+				d.offset = d.size
+			}
+
+			return nil
+		}
+
+		d.offset += size
+
+		return nil
+	}
+}
+
+// decodeFrameBody decodes the subframes of the frame at the start of data
+// into d.block and checks the CRC-16 footer. It returns the frame size.
+func (d *flacDecoder) decodeFrameBody(data []byte, h flacFrameHeader) (int, error) {
+	br := &bitReader{data: data[h.size:]}
+
+	for len(d.subframes) < h.channels {
+		d.subframes = append(d.subframes, nil)
+	}
+
+	for ch := range h.channels {
+		if cap(d.subframes[ch]) < h.blockSize {
+			d.subframes[ch] = make([]int64, h.blockSize)
+		}
+		d.subframes[ch] = d.subframes[ch][:h.blockSize]
+
+		bps := h.bitsPerSample
+		if (h.assignment == 8 || h.assignment == 10) && ch == 1 || h.assignment == 9 && ch == 0 {
+			bps++ // side channel
+		}
+
+		if err := decodeSubframe(br, d.subframes[ch], bps); err != nil {
+			return 0, err
+		}
+	}
+
+	br.align()
+
+	end := h.size + br.consumed()
+	if end+2 > len(data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if crc16(data[:end]) != binary.BigEndian.Uint16(data[end:]) {
+		return 0, errFLACChecksum
+	}
+
+	decorrelate(d.subframes, h.assignment)
+
+	d.resizeBlock(h.blockSize)
+
+	left := d.subframes[0]
+	right := left
+	if h.channels > 1 {
+		right = d.subframes[1]
+	}
+
+	for i := range d.block {
+		d.block[i] = [2]float64{float64(left[i]) * d.scale, float64(right[i]) * d.scale}
+	}
+
+	d.blockStart = h.firstSample
+	d.blockPos = 0
+
+	return end + 2, nil
+}
+
+// silence fills d.block with the length of a frame that could not be
+// decoded, so that the rest of the track keeps its timing.
+func (d *flacDecoder) silence(h flacFrameHeader) {
+	d.resizeBlock(h.blockSize)
+	clear(d.block)
+
+	d.blockStart = h.firstSample
+	d.blockPos = 0
+}
+
+func (d *flacDecoder) resizeBlock(n int) {
+	if cap(d.block) < n {
+		d.block = make([][2]float64, n)
+	}
+	d.block = d.block[:n]
+}
+
+// resync moves d.offset to the next frame header at or after from.
+func (d *flacDecoder) resync(from int) bool {
+	off, _, ok := d.syncFrom(from, d.size)
+	if ok {
+		d.offset = off
+	}
+
+	return ok
+}
+
+// decodeSubframe decodes one channel of a frame into out.
+func decodeSubframe(br *bitReader, out []int64, bps int) error {
+	header, err := br.read(8)
+	if err != nil {
+		return err
+	}
+
+	if header&0x80 != 0 {
+		return fmt.Errorf("invalid subframe padding")
+	}
+
+	kind := int(header >> 1 & 0x3f)
+
+	wasted := 0
+	if header&0x01 != 0 {
+		k, err := br.readUnary()
+		if err != nil {
+			return err
+		}
+		wasted = int(k) + 1
+		bps -= wasted
+	}
+
+	if bps <= 0 {
+		return fmt.Errorf("invalid wasted bits")
+	}
+
+	switch {
+	case kind == 0:
+		v, err := br.readSigned(uint(bps))
+		if err != nil {
+			return err
+		}
+		for i := range out {
+			out[i] = v
+		}
+	case kind == 1:
+		for i := range out {
+			if out[i], err = br.readSigned(uint(bps)); err != nil {
+				return err
+			}
+		}
+	case kind >= 8 && kind <= 12:
+		if err := decodeFixed(br, out, kind&0x07, bps); err != nil {
+			return err
+		}
+	case kind >= 32:
+		if err := decodeLPC(br, out, kind&0x1f+1, bps); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("reserved subframe type %d", kind)
+	}
+
+	if wasted > 0 {
+		for i := range out {
+			out[i] <<= wasted
+		}
+	}
+
+	return nil
+}
+
+// decodeFixed decodes a subframe using one of the fixed polynomial
+// predictors.
+func decodeFixed(br *bitReader, out []int64, order, bps int) error {
+	if order > len(out) {
+		return fmt.Errorf("predictor order exceeds block size")
+	}
+
+	if err := readWarmup(br, out[:order], bps); err != nil {
+		return err
+	}
+
+	if err := decodeResidual(br, out, order); err != nil {
+		return err
+	}
+
+	for i := order; i < len(out); i++ {
+		switch order {
+		case 1:
+			out[i] += out[i-1]
+		case 2:
+			out[i] += 2*out[i-1] - out[i-2]
+		case 3:
+			out[i] += 3*out[i-1] - 3*out[i-2] + out[i-3]
+		case 4:
+			out[i] += 4*out[i-1] - 6*out[i-2] + 4*out[i-3] - out[i-4]
+		}
+	}
+
+	return nil
+}
+
+// decodeLPC decodes a subframe using transmitted linear prediction
+// coefficients.
+func decodeLPC(br *bitReader, out []int64, order, bps int) error {
+	if order > len(out) {
+		return fmt.Errorf("predictor order exceeds block size")
+	}
+
+	if err := readWarmup(br, out[:order], bps); err != nil {
+		return err
+	}
+
+	precision, err := br.read(4)
+	if err != nil {
+		return err
+	}
+
+	if precision == 15 {
+		return fmt.Errorf("invalid coefficient precision")
+	}
+
+	shift, err := br.readSigned(5)
+	if err != nil {
+		return err
+	}
+
+	if shift < 0 {
+		return fmt.Errorf("negative prediction shift")
+	}
+
+	coeffs := make([]int64, order)
+	for i := range coeffs {
+		if coeffs[i], err = br.readSigned(uint(precision) + 1); err != nil {
+			return err
+		}
+	}
+
+	if err := decodeResidual(br, out, order); err != nil {
+		return err
+	}
+
+	for i := order; i < len(out); i++ {
+		var sum int64
+		for j, c := range coeffs {
+			sum += c * out[i-1-j]
+		}
+		out[i] += sum >> shift
+	}
+
+	return nil
+}
+
+func readWarmup(br *bitReader, out []int64, bps int) error {
+	var err error
+	for i := range out {
+		if out[i], err = br.readSigned(uint(bps)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeResidual reads the Rice-coded prediction residual into
+// out[order:].
+func decodeResidual(br *bitReader, out []int64, order int) error {
+	method, err := br.read(2)
+	if err != nil {
+		return err
+	}
+
+	paramBits, escape := uint(4), uint64(15)
+	switch method {
+	case 0:
+	case 1:
+		paramBits, escape = 5, 31
+	default:
+		return fmt.Errorf("reserved residual coding method %d", method)
+	}
+
+	partitionOrder, err := br.read(4)
+	if err != nil {
+		return err
+	}
+
+	partitions := 1 << partitionOrder
+	partitionLen := len(out) >> partitionOrder
+	if len(out)%partitions != 0 || partitionLen < order {
+		return fmt.Errorf("invalid residual partition order %d", partitionOrder)
+	}
+
+	i := order
+	for p := range partitions {
+		n := partitionLen
+		if p == 0 {
+			n -= order
+		}
+
+		param, err := br.read(paramBits)
+		if err != nil {
+			return err
+		}
+
+		if param == escape {
+			raw, err := br.read(5)
+			if err != nil {
+				return err
+			}
+
+			for end := i + n; i < end; i++ {
+				if out[i], err = br.readSigned(uint(raw)); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		for end := i + n; i < end; i++ {
+			q, err := br.readUnary()
+			if err != nil {
+				return err
+			}
+
+			low, err := br.read(uint(param))
+			if err != nil {
+				return err
+			}
+
+			v := q<<param | low
+			out[i] = int64(v>>1) ^ -int64(v&1)
+		}
+	}
+
+	return nil
+}
+
+// decorrelate undoes the inter-channel decorrelation of a stereo frame.
+func decorrelate(ch [][]int64, assignment int) {
+	switch assignment {
+	case 8: // left/side
+		for i, side := range ch[1] {
+			ch[1][i] = ch[0][i] - side
+		}
+	case 9: // side/right
+		for i, side := range ch[0] {
+			ch[0][i] = side + ch[1][i]
+		}
+	case 10: // mid/side
+		for i, side := range ch[1] {
+			mid := ch[0][i]<<1 | side&1
+			ch[0][i] = (mid + side) >> 1
+			ch[1][i] = (mid - side) >> 1
+		}
+	}
+}
+
+// Stream implements beep.Streamer.
+func (d *flacDecoder) Stream(samples [][2]float64) (n int, ok bool) {
+	if d.err != nil {
+		return 0, false
+	}
+
+	for n < len(samples) {
+		if d.blockPos >= len(d.block) {
+			if err := d.decodeFrame(); err != nil {
+				if err != io.EOF {
+					d.err = err
+				}
+				d.block = d.block[:0]
+				break
+			}
+		}
+
+		c := copy(samples[n:], d.block[d.blockPos:])
+		n += c
+		d.blockPos += c
+		d.position += c
+	}
+
+	return n, n > 0
+}
+
+// Err implements beep.Streamer.
+func (d *flacDecoder) Err() error {
+	return d.err
+}
+
+// Len implements beep.StreamSeeker.
+func (d *flacDecoder) Len() int {
+	return int(d.info.totalSamples)
+}
+
+// Position implements beep.StreamSeeker.
+func (d *flacDecoder) Position() int {
+	return d.position
+}
+
+// Seek implements beep.StreamSeeker.
+func (d *flacDecoder) Seek(p int) error {
+	if p < 0 || (d.info.totalSamples > 0 && int64(p) > d.info.totalSamples) {
+		return fmt.Errorf("flac: seek position %d out of range [0, %d]", p, d.info.totalSamples)
+	}
+
+	d.offset = d.findFrame(int64(p))
+	d.block = d.block[:0]
+	d.blockPos = 0
+	d.err = nil
+
+	for {
+		if err := d.decodeFrame(); err != nil {
+			if err != io.EOF {
+				return err
+			}
+			d.block = d.block[:0]
+			break
+		}
+
+		if d.blockStart+int64(len(d.block)) > int64(p) {
+			d.blockPos = int(max(int64(p)-d.blockStart, 0))
+			break
+		}
+	}
+
+	d.position = p
+
+	return nil
+}
+
+// findFrame returns the offset of the last frame starting at or before the
+// target sample, by binary search over byte offsets.
+func (d *flacDecoder) findFrame(target int64) int {
+	best := d.firstFrame
+	lo, hi := d.firstFrame, d.size
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		off, h, ok := d.syncFrom(mid, hi)
+		if !ok || h.firstSample > target {
+			hi = mid
+			continue
+		}
+
+		best = off
+		if h.firstSample+int64(h.blockSize) > target {
+			break
+		}
+
+		lo = off + 1
+	}
+
+	return best
+}
+
+// flacMaxHeader is the longest possible frame header.
+const flacMaxHeader = 16
+
+// syncFrom scans [from, limit) for the first valid frame header.
+func (d *flacDecoder) syncFrom(from, limit int) (int, flacFrameHeader, bool) {
+	for off := from; off+1 < limit; {
+		data, err := d.bytes(off, flacMinWindow)
+		if err != nil || len(data) < 2 {
+			break
+		}
+
+		// Leave a header that may straddle the end of the window to the
+		// next pass, unless the file ends there.
+		scan := min(len(data), limit-off)
+		if off+len(data) < d.size {
+			scan = min(scan, len(data)-flacMaxHeader)
+		}
+
+		for i := 0; i+1 < scan; i++ {
+			if data[i] != 0xff || data[i+1]&0xfe != 0xf8 {
+				continue
+			}
+
+			if h, err := d.parseFrameHeader(data[i:]); err == nil {
+				return off + i, h, true
+			}
+		}
+
+		off += max(scan-1, 1)
+	}
+
+	return 0, flacFrameHeader{}, false
+}
+
+// Close implements beep.StreamSeekCloser.
+func (d *flacDecoder) Close() error {
+	return d.file.Close()
+}
+
+// crc8 computes the frame header checksum (polynomial x^8+x^2+x+1).
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// crc16 computes the frame checksum (polynomial x^16+x^15+x^2+1).
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// bitReader reads big-endian bit fields from a byte slice.
+type bitReader struct {
+	data  []byte
+	off   int    // next byte to load into the cache
+	cache uint64 // unread bits, left aligned
+	n     uint   // number of valid bits in cache
+}
+
+func (b *bitReader) refill() {
+	for b.n <= 56 && b.off < len(b.data) {
+		b.cache |= uint64(b.data[b.off]) << (56 - b.n)
+		b.off++
+		b.n += 8
+	}
+}
+
+// read returns the next k bits (k <= 57) as an unsigned value.
+func (b *bitReader) read(k uint) (uint64, error) {
+	if k == 0 {
+		return 0, nil
+	}
+
+	if b.n < k {
+		b.refill()
+		if b.n < k {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+
+	v := b.cache >> (64 - k)
+	b.cache <<= k
+	b.n -= k
+
+	return v, nil
+}
+
+// readSigned returns the next k bits as a two's complement value.
+func (b *bitReader) readSigned(k uint) (int64, error) {
+	v, err := b.read(k)
+	if err != nil || k == 0 {
+		return 0, err
+	}
+
+	return int64(v<<(64-k)) >> (64 - k), nil
+}
+
+// readUnary counts zero bits up to and including the next one bit.
+func (b *bitReader) readUnary() (uint64, error) {
+	var count uint64
+
+	for {
+		if b.n == 0 {
+			b.refill()
+			if b.n == 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+		}
+
+		lz := uint(bits.LeadingZeros64(b.cache))
+		if lz < b.n {
+			b.cache <<= lz + 1
+			b.n -= lz + 1
+			return count + uint64(lz), nil
+		}
+
+		count += uint64(b.n)
+		b.cache = 0
+		b.n = 0
+	}
+}
+
+// align discards bits up to the next byte boundary.
+func (b *bitReader) align() {
+	drop := b.n % 8
+	b.cache <<= drop
+	b.n -= drop
+}
+
+// consumed returns the number of whole bytes read so far.
+func (b *bitReader) consumed() int {
+	return b.off - int(b.n/8)
+}
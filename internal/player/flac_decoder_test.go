@@ -0,0 +1,238 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// referenceFLAC holds the first 41715 bytes of a mono 16-bit file encoded
+// by FFmpeg (libavformat 56.25.101), from the test data of
+// github.com/gabriel-vasile/mimetype (MIT), not by anything here. The
+// cut leaves 37 whole frames and a truncated last one. The CRC-16 of a
+// whole frame only matches when its subframes were parsed bit for bit.
+const referenceFLAC = "testdata/reference.flac"
+
+// bitWriter builds big-endian bit fields for hand-made bitstreams.
+type bitWriter struct {
+	buf []byte
+	cur byte
+	n   uint
+}
+
+func (w *bitWriter) write(v uint64, k uint) {
+	for i := int(k) - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>uint(i))&1
+		w.n++
+		if w.n == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.n = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) writeSigned(v int64, k uint) {
+	w.write(uint64(v)&(1<<k-1), k)
+}
+
+func (w *bitWriter) align() {
+	for w.n != 0 {
+		w.write(0, 1)
+	}
+}
+
+func openFLAC(t *testing.T, path string) *flacDecoder {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	streamer, _, err := DecodeFLAC(file)
+	if err != nil {
+		t.Fatalf("DecodeFLAC: %v", err)
+	}
+	t.Cleanup(func() { streamer.Close() })
+
+	return streamer.(*flacDecoder)
+}
+
+// decodeAll streams a decoder to its end.
+func decodeAll(t *testing.T, d *flacDecoder) [][2]float64 {
+	t.Helper()
+
+	var out [][2]float64
+
+	buf := make([][2]float64, 1000)
+	for {
+		n, ok := d.Stream(buf)
+		out = append(out, buf[:n]...)
+
+		if !ok {
+			break
+		}
+	}
+
+	if err := d.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+
+	return out
+}
+
+func TestFLACDecodesReferenceFrames(t *testing.T) {
+	d := openFLAC(t, referenceFLAC)
+
+	frames := 0
+	for {
+		data, err := d.bytes(d.offset, d.frameBytes)
+		if err != nil {
+			t.Fatalf("frame %d: %v", frames, err)
+		}
+
+		h, err := d.parseFrameHeader(data)
+		if err != nil {
+			t.Fatalf("frame %d: header: %v", frames, err)
+		}
+
+		size, err := d.decodeFrameBody(data, h)
+		if err != nil {
+			if d.offset+len(data) < d.size {
+				t.Fatalf("frame %d at sample %d: %v", frames, h.firstSample, err)
+			}
+
+			// The truncated last frame
+			if end := h.firstSample + int64(h.blockSize); end != d.info.totalSamples {
+				t.Errorf("last frame: ends at sample %d, want %d", end, d.info.totalSamples)
+			}
+
+			break
+		}
+
+		d.offset += size
+		frames++
+	}
+
+	if frames != 37 {
+		t.Errorf("decoded %d whole frames, want 37", frames)
+	}
+}
+
+func TestFLACPlaysTruncatedFrameAsSilence(t *testing.T) {
+	d := openFLAC(t, referenceFLAC)
+
+	samples := decodeAll(t, d)
+	if len(samples) != d.Len() {
+		t.Fatalf("decoded %d samples, Len reports %d", len(samples), d.Len())
+	}
+
+	last := int(d.info.totalSamples % int64(d.info.maxBlockSize))
+	for i, s := range samples[len(samples)-last:] {
+		if s != [2]float64{} {
+			t.Fatalf("sample %d of the truncated frame: got %v, want silence", i, s)
+		}
+	}
+}
+
+func TestFLACSeekIsSampleAccurate(t *testing.T) {
+	want := decodeAll(t, openFLAC(t, referenceFLAC))
+	total := len(want)
+
+	// Read in windows of about two frames, so that seeking and decoding
+	// cross many window boundaries
+	d := openFLAC(t, referenceFLAC)
+	d.frameBytes = d.info.maxFrameSize
+
+	for _, target := range []int{total / 2, 0, d.info.maxBlockSize - 1, d.info.maxBlockSize, total - 1, 1234} {
+		if err := d.Seek(target); err != nil {
+			t.Fatalf("Seek(%d): %v", target, err)
+		}
+		if d.Position() != target {
+			t.Errorf("Position after Seek(%d): got %d", target, d.Position())
+		}
+
+		samples := make([][2]float64, min(500, total-target))
+		n, _ := d.Stream(samples)
+		if n != len(samples) {
+			t.Fatalf("Stream after Seek(%d): got %d samples, want %d", target, n, len(samples))
+		}
+
+		for i := range samples {
+			if samples[i] != want[target+i] {
+				t.Fatalf("Seek(%d): sample %d: got %v, want %v", target, target+i, samples[i], want[target+i])
+			}
+		}
+	}
+
+	if err := d.Seek(total); err != nil {
+		t.Fatalf("Seek to end: %v", err)
+	}
+	if n, ok := d.Stream(make([][2]float64, 10)); n != 0 || ok {
+		t.Errorf("Stream at end: got (%d, %v), want (0, false)", n, ok)
+	}
+
+	if err := d.Seek(total + 1); err == nil {
+		t.Error("Seek past end: expected error")
+	}
+}
+
+func TestFLACResyncsAfterCorruptFrame(t *testing.T) {
+	ref := openFLAC(t, referenceFLAC)
+	want := decodeAll(t, ref)
+
+	data, err := os.ReadFile(referenceFLAC)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	// Damage the middle of the file, well inside the frames
+	middle := len(data) / 2
+	for i := middle; i < middle+16; i++ {
+		data[i] ^= 0x5a
+	}
+
+	path := filepath.Join(t.TempDir(), "corrupt.flac")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	got := decodeAll(t, openFLAC(t, path))
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d samples, want %d with the damaged frame as silence", len(got), len(want))
+	}
+
+	// The damage spans at most two frames; everything else is intact
+	frame := ref.info.maxBlockSize
+	differing := 0
+
+	for i := range got {
+		if got[i] != want[i] {
+			differing++
+		}
+	}
+
+	if differing == 0 || differing > 2*frame {
+		t.Errorf("%d samples differ, want between 1 and %d", differing, 2*frame)
+	}
+
+	if got[len(got)-1] != want[len(want)-1] {
+		t.Error("decoding did not resume after the damaged frame")
+	}
+}
+
+func TestBitReaderUnaryAcrossWords(t *testing.T) {
+	w := &bitWriter{}
+	w.write(0, 70)
+	w.write(1, 1)
+	w.writeSigned(-5, 33)
+	w.align()
+
+	br := &bitReader{data: w.buf}
+	if q, err := br.readUnary(); err != nil || q != 70 {
+		t.Errorf("readUnary: got (%d, %v), want (70, nil)", q, err)
+	}
+	if v, err := br.readSigned(33); err != nil || v != -5 {
+		t.Errorf("readSigned: got (%d, %v), want (-5, nil)", v, err)
+	}
+}
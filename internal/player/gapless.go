@@ -0,0 +1,330 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// preloadedTrack holds a decoded track that is ready to take over playback.
+type preloadedTrack struct {
+	file     string
+	streamer beep.StreamSeekCloser
+	buffered *BufferedStreamer
+	format   beep.Format
+	duration time.Duration
+
+	// crossfade is the number of samples over which this track overlaps
+	// the end of the previous one; zero hands off gaplessly.
+	crossfade int
+
+	// gain is the linear loudness normalization factor for this track.
+	gain float64
+}
+
+// close releases the decoder and buffer of a preloaded track.
+func (t *preloadedTrack) close() {
+	if t.buffered != nil {
+		t.buffered.Drain()
+		t.buffered.Close()
+	}
+
+	if t.streamer != nil {
+		t.streamer.Close()
+	}
+}
+
+// GaplessStreamer plays the current track and, when it is exhausted,
+// continues with the preloaded next track inside the same Stream call,
+// so there is no gap at the track boundary. If the next track asks for a
+// crossfade, the two tracks are mixed with equal-power curves over the
+// last samples of the current one instead.
+type GaplessStreamer struct {
+	mu        sync.Mutex
+	current   *BufferedStreamer
+	gain      float64 // normalization gain of the current track
+	next      *preloadedTrack
+	handedOff *preloadedTrack
+
+	// Crossfade state: outgoing is the track being faded out underneath
+	// current, and retired holds its resources once the player has let go
+	// of them, to be released when the fade completes.
+	outgoing     *BufferedStreamer
+	outgoingGain float64
+	retired      *preloadedTrack
+	fadePos      int
+	fadeLen      int
+	mix          [][2]float64
+
+	ramp *fadeRamp
+}
+
+// NewGaplessStreamer creates a gapless streamer starting with the given track.
+func NewGaplessStreamer(current *BufferedStreamer) *GaplessStreamer {
+	return &GaplessStreamer{current: current, gain: 1}
+}
+
+// Stream implements beep.Streamer.
+func (g *GaplessStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	// Wait for the initial fill of the current track without holding the
+	// lock, which the player takes to install or hand over tracks.
+	g.mu.Lock()
+	current := g.current
+	g.mu.Unlock()
+
+	if current != nil {
+		current.WaitFilled()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.current == nil {
+		return 0, false
+	}
+
+	if g.outgoing == nil && g.next != nil && g.next.crossfade > 0 {
+		g.maybeStartCrossfade()
+	}
+
+	if g.outgoing != nil {
+		n, ok = g.streamCrossfade(samples)
+	} else {
+		n, ok = g.streamGapless(samples)
+	}
+
+	if g.ramp != nil {
+		g.ramp.apply(samples[:n])
+
+		if g.ramp.in && g.ramp.finished() {
+			g.ramp = nil
+		}
+	}
+
+	return n, ok
+}
+
+// streamGapless streams the current track and switches to the next one at
+// the exact sample where the current track ended.
+func (g *GaplessStreamer) streamGapless(samples [][2]float64) (n int, ok bool) {
+	n, ok = g.current.Stream(samples)
+	applyGain(samples[:n], g.gain)
+
+	if n == len(samples) || g.next == nil || !g.current.Exhausted() {
+		return n, ok
+	}
+
+	g.handedOff = g.next
+	g.current = g.next.buffered
+	g.gain = g.next.gain
+	g.next = nil
+
+	m, nextOK := g.current.Stream(samples[n:])
+	applyGain(samples[n:n+m], g.gain)
+
+	return n + m, n+m > 0 || nextOK
+}
+
+// maybeStartCrossfade starts mixing in the next track once the current one
+// is within the crossfade length of its end. The overlap never exceeds half
+// of the current track, so short tracks are still heard on their own.
+func (g *GaplessStreamer) maybeStartCrossfade() {
+	total := g.current.Len()
+	if total <= 0 {
+		return
+	}
+
+	remaining := total - g.current.Position()
+	if remaining > min(g.next.crossfade, total/2) {
+		return
+	}
+
+	g.outgoing = g.current
+	g.outgoingGain = g.gain
+	g.handedOff = g.next
+	g.current = g.next.buffered
+	g.gain = g.next.gain
+	g.next = nil
+	g.fadePos = 0
+	g.fadeLen = max(remaining, 1)
+}
+
+// streamCrossfade mixes the fading-out track with the incoming one.
+func (g *GaplessStreamer) streamCrossfade(samples [][2]float64) (n int, ok bool) {
+	if cap(g.mix) < len(samples) {
+		g.mix = make([][2]float64, len(samples))
+	}
+
+	mix := g.mix[:len(samples)]
+
+	n, ok = g.current.Stream(samples)
+	m, _ := g.outgoing.Stream(mix)
+
+	for i := n; i < m; i++ {
+		samples[i] = [2]float64{}
+	}
+
+	total := max(n, m)
+	for i := range total {
+		out, in := equalPowerGains(float64(g.fadePos+i) / float64(g.fadeLen))
+		out *= g.outgoingGain
+		in *= g.gain
+
+		var tail [2]float64
+		if i < m {
+			tail = mix[i]
+		}
+
+		samples[i][0] = samples[i][0]*in + tail[0]*out
+		samples[i][1] = samples[i][1]*in + tail[1]*out
+	}
+
+	g.fadePos += total
+
+	if g.fadePos >= g.fadeLen || g.outgoing.Exhausted() {
+		g.finishCrossfade()
+	}
+
+	return total, ok || m > 0
+}
+
+// finishCrossfade drops the faded-out track. If the player has already
+// handed over its resources they are released off the audio goroutine.
+func (g *GaplessStreamer) finishCrossfade() {
+	g.outgoing = nil
+
+	if g.retired != nil {
+		go g.retired.close()
+		g.retired = nil
+	}
+}
+
+// applyGain scales samples by a linear gain.
+func applyGain(samples [][2]float64, gain float64) {
+	if gain == 1 {
+		return
+	}
+
+	for i := range samples {
+		samples[i][0] *= gain
+		samples[i][1] *= gain
+	}
+}
+
+// setGain sets the normalization gain of the current track.
+func (g *GaplessStreamer) setGain(gain float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.gain = gain
+}
+
+// Err implements beep.Streamer.
+func (g *GaplessStreamer) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.current == nil {
+		return nil
+	}
+
+	return g.current.Err()
+}
+
+// setNext installs the track to switch to when the current one ends.
+// Returns the previously installed track, if any, so the caller can close it.
+func (g *GaplessStreamer) setNext(next *preloadedTrack) *preloadedTrack {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	prev := g.next
+	g.next = next
+
+	return prev
+}
+
+// hasNext returns whether a preloaded track is waiting to take over.
+func (g *GaplessStreamer) hasNext() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.next != nil
+}
+
+// takeHandedOff returns the track that took over playback since the last call.
+func (g *GaplessStreamer) takeHandedOff() *preloadedTrack {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t := g.handedOff
+	g.handedOff = nil
+
+	return t
+}
+
+// retire takes ownership of the previous track's resources after a
+// hand-off. They are closed right away unless the track is still fading
+// out, in which case they are closed when the crossfade finishes.
+func (g *GaplessStreamer) retire(prev *preloadedTrack) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.outgoing != nil && g.outgoing == prev.buffered {
+		g.retired = prev
+		return
+	}
+
+	prev.close()
+}
+
+// detach removes every track the streamer holds besides the current one
+// (the pending next track, an unclaimed hand-off and a retired crossfade
+// source) and drains them so that Stream stops reading from them.
+// The caller is responsible for closing the returned tracks.
+func (g *GaplessStreamer) detach() []*preloadedTrack {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var tracks []*preloadedTrack
+	for _, t := range []*preloadedTrack{g.next, g.handedOff, g.retired} {
+		if t != nil {
+			t.buffered.Drain()
+			tracks = append(tracks, t)
+		}
+	}
+
+	g.next = nil
+	g.handedOff = nil
+	g.retired = nil
+	g.outgoing = nil
+
+	return tracks
+}
+
+// fadeOut starts a short fade-out and returns a channel that is closed
+// once it has been rendered. The stream stays silent afterwards.
+func (g *GaplessStreamer) fadeOut(length int) <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.ramp = newFadeRamp(length, false)
+
+	return g.ramp.done
+}
+
+// clearFade drops the fade, playing at full level again.
+func (g *GaplessStreamer) clearFade() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.ramp = nil
+}
+
+// fadeIn starts a short fade-in from silence.
+func (g *GaplessStreamer) fadeIn(length int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.ramp = newFadeRamp(length, true)
+}
@@ -0,0 +1,241 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// constStreamer yields a fixed number of samples with a constant value.
+type constStreamer struct {
+	value     float64
+	remaining int
+}
+
+func (c *constStreamer) Stream(samples [][2]float64) (int, bool) {
+	if c.remaining == 0 {
+		return 0, false
+	}
+	n := min(len(samples), c.remaining)
+	for i := range n {
+		samples[i] = [2]float64{c.value, c.value}
+	}
+	c.remaining -= n
+	return n, true
+}
+
+func (c *constStreamer) Err() error { return nil }
+
+var testFormat = beep.Format{SampleRate: 1000, NumChannels: 2, Precision: 2}
+
+func TestGaplessStreamerHandsOffAtBoundary(t *testing.T) {
+	current := NewBufferedStreamer(&constStreamer{value: 1, remaining: 100}, testFormat, 4.0)
+	defer current.Close()
+	next := NewBufferedStreamer(&constStreamer{value: 2, remaining: 100}, testFormat, 4.0)
+	defer next.Close()
+
+	g := NewGaplessStreamer(current)
+	g.setNext(&preloadedTrack{file: "next.mp3", buffered: next, format: testFormat, gain: 1})
+
+	samples := make([][2]float64, 150)
+	n, ok := g.Stream(samples)
+	if n != 150 || !ok {
+		t.Fatalf("Stream: got (%d, %t), want (150, true)", n, ok)
+	}
+
+	for i := range 100 {
+		if samples[i][0] != 1 {
+			t.Fatalf("samples[%d]: got %f, want 1 (current track)", i, samples[i][0])
+		}
+	}
+	for i := 100; i < 150; i++ {
+		if samples[i][0] != 2 {
+			t.Fatalf("samples[%d]: got %f, want 2 (next track)", i, samples[i][0])
+		}
+	}
+
+	handed := g.takeHandedOff()
+	if handed == nil || handed.file != "next.mp3" {
+		t.Fatalf("takeHandedOff: got %v, want next.mp3", handed)
+	}
+	if g.takeHandedOff() != nil {
+		t.Error("takeHandedOff: hand-off reported twice")
+	}
+	if g.hasNext() {
+		t.Error("hasNext: got true after hand-off")
+	}
+}
+
+func TestGaplessStreamerWithoutNextEnds(t *testing.T) {
+	current := NewBufferedStreamer(&constStreamer{value: 1, remaining: 100}, testFormat, 4.0)
+	defer current.Close()
+
+	g := NewGaplessStreamer(current)
+
+	samples := make([][2]float64, 150)
+	n, _ := g.Stream(samples)
+	if n != 100 {
+		t.Errorf("Stream: got %d samples, want 100", n)
+	}
+	if g.takeHandedOff() != nil {
+		t.Error("takeHandedOff: got hand-off without a preloaded track")
+	}
+}
+
+// blockingStreamer is a source that yields nothing until release is closed,
+// like a slow disk.
+type blockingStreamer struct {
+	constStreamer
+	release chan struct{}
+}
+
+func (b *blockingStreamer) Stream(samples [][2]float64) (int, bool) {
+	<-b.release
+	return b.constStreamer.Stream(samples)
+}
+
+func TestGaplessStreamerDoesNotLockWhileFilling(t *testing.T) {
+	source := &blockingStreamer{constStreamer: constStreamer{value: 1, remaining: 100}, release: make(chan struct{})}
+	current := NewBufferedStreamer(source, testFormat, 4.0)
+	defer current.Close()
+
+	g := NewGaplessStreamer(current)
+
+	streamed := make(chan int)
+	go func() {
+		n, _ := g.Stream(make([][2]float64, 50))
+		streamed <- n
+	}()
+
+	// Give Stream time to start waiting for the fill
+	time.Sleep(20 * time.Millisecond)
+
+	installed := make(chan struct{})
+	go func() {
+		g.setNext(nil)
+		close(installed)
+	}()
+
+	select {
+	case <-installed:
+	case <-time.After(time.Second):
+		t.Fatal("setNext: blocked while the current track was filling")
+	}
+
+	close(source.release)
+
+	if n := <-streamed; n != 50 {
+		t.Errorf("Stream: got %d samples, want 50", n)
+	}
+}
+
+// constSeeker is a constStreamer that also reports its length, which the
+// crossfade needs to know when the tail of a track begins.
+type constSeeker struct {
+	constStreamer
+	total int
+}
+
+func (c *constSeeker) Len() int      { return c.total }
+func (c *constSeeker) Position() int { return c.total - c.remaining }
+func (c *constSeeker) Seek(p int) error {
+	c.remaining = c.total - p
+	return nil
+}
+
+func newConstSeeker(value float64, total int) *constSeeker {
+	return &constSeeker{constStreamer: constStreamer{value: value, remaining: total}, total: total}
+}
+
+func TestGaplessStreamerCrossfade(t *testing.T) {
+	current := NewBufferedStreamer(newConstSeeker(1, 100), testFormat, 4.0)
+	defer current.Close()
+	next := NewBufferedStreamer(newConstSeeker(1, 100), testFormat, 4.0)
+	defer next.Close()
+
+	g := NewGaplessStreamer(current)
+	g.setNext(&preloadedTrack{file: "next.mp3", buffered: next, format: testFormat, crossfade: 20, gain: 1})
+
+	// Play up to the start of the overlap.
+	head := make([][2]float64, 80)
+	if n, _ := g.Stream(head); n != 80 {
+		t.Fatalf("Stream head: got %d samples, want 80", n)
+	}
+	if g.takeHandedOff() != nil {
+		t.Fatal("takeHandedOff: hand-off before the crossfade started")
+	}
+
+	samples := make([][2]float64, 40)
+	n, ok := g.Stream(samples)
+	if n != 40 || !ok {
+		t.Fatalf("Stream: got (%d, %t), want (40, true)", n, ok)
+	}
+
+	// Both tracks are 1, so the mix follows cos + sin: 1 at the edges,
+	// peaking at sqrt(2) halfway through the overlap.
+	if samples[0][0] != 1 {
+		t.Errorf("samples[0]: got %f, want 1", samples[0][0])
+	}
+	if got := samples[10][0]; got < 1.41 || got > 1.42 {
+		t.Errorf("samples[10]: got %f, want ~1.414", got)
+	}
+	for i := 20; i < 40; i++ {
+		if samples[i][0] != 1 {
+			t.Fatalf("samples[%d]: got %f, want 1 (next track only)", i, samples[i][0])
+		}
+	}
+
+	if handed := g.takeHandedOff(); handed == nil || handed.file != "next.mp3" {
+		t.Fatalf("takeHandedOff: got %v, want next.mp3", handed)
+	}
+	if next.Position() != 40 {
+		t.Errorf("next position: got %d, want 40", next.Position())
+	}
+}
+
+func TestGaplessStreamerFadeOutSilences(t *testing.T) {
+	current := NewBufferedStreamer(newConstSeeker(1, 200), testFormat, 4.0)
+	defer current.Close()
+
+	g := NewGaplessStreamer(current)
+	done := g.fadeOut(10)
+
+	samples := make([][2]float64, 20)
+	g.Stream(samples)
+
+	select {
+	case <-done:
+	default:
+		t.Fatal("fadeOut: done not closed after the ramp was rendered")
+	}
+
+	if samples[0][0] != 1 {
+		t.Errorf("samples[0]: got %f, want 1", samples[0][0])
+	}
+	for i := 10; i < 20; i++ {
+		if samples[i][0] != 0 {
+			t.Fatalf("samples[%d]: got %f, want 0 after fade-out", i, samples[i][0])
+		}
+	}
+}
+
+func TestGaplessStreamerClearFadeRestoresLevel(t *testing.T) {
+	current := NewBufferedStreamer(newConstSeeker(1, 200), testFormat, 4.0)
+	defer current.Close()
+
+	g := NewGaplessStreamer(current)
+	g.fadeOut(10)
+
+	samples := make([][2]float64, 20)
+	g.Stream(samples)
+
+	g.clearFade()
+	g.Stream(samples)
+
+	for i := range samples {
+		if samples[i][0] != 1 {
+			t.Fatalf("samples[%d]: got %f, want 1 after clearFade", i, samples[i][0])
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package player
+
+import (
+	"math"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// Limiter parameters: the ceiling the limiter holds peaks to, how far it
+// looks ahead to lower the gain before a peak arrives, and how fast the
+// gain recovers afterwards.
+const (
+	limiterCeiling   = 0.966 // -0.3 dBFS
+	limiterLookahead = 0.005 // seconds
+	limiterRelease   = 0.100 // seconds
+)
+
+// Limiter is the last stage of the chain. It applies a preamp and then,
+// when enabled, a look-ahead peak limiter that keeps samples within the
+// ceiling without the distortion of hard clipping. Whether enabled or not,
+// it counts the samples that would have clipped at the output.
+type Limiter struct {
+	mu         sync.Mutex
+	streamer   beep.Streamer
+	enabled    bool
+	preamp     float64 // dB
+	preampGain float64
+
+	delay [][2]float64 // lookahead ring
+	pos   int
+
+	// The lowest gain any sample in the lookahead needs, as a monotonic
+	// queue of (sample number, gain) pairs in fixed rings.
+	needAt []int
+	need   []float64
+	head   int
+	count  int
+	sample int
+
+	gain    float64 // gain applied to the delayed output
+	attack  float64 // per-sample smoothing towards a lower target
+	release float64 // per-sample smoothing towards unity
+	clipped int
+	rate    float64
+}
+
+// NewLimiter creates an enabled limiter with no preamp.
+func NewLimiter(streamer beep.Streamer, sampleRate float64) *Limiter {
+	l := &Limiter{
+		streamer:   streamer,
+		enabled:    true,
+		preampGain: 1,
+	}
+	l.setSampleRateLocked(sampleRate)
+
+	return l
+}
+
+// Stream implements beep.Streamer.
+func (l *Limiter) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = l.streamer.Stream(samples)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range samples[:n] {
+		s := samples[i]
+		s[0] *= l.preampGain
+		s[1] *= l.preampGain
+
+		peak := math.Max(math.Abs(s[0]), math.Abs(s[1]))
+		if peak > 1 {
+			l.clipped++
+		}
+
+		if !l.enabled {
+			samples[i] = s
+			continue
+		}
+
+		samples[i] = l.process(s, peak)
+	}
+
+	return n, ok
+}
+
+// process pushes a sample into the lookahead and returns the delayed one
+// at the current gain.
+func (l *Limiter) process(s [2]float64, peak float64) [2]float64 {
+	// Aim for the gain the loudest sample in the delay line needs, so that
+	// the gain is already down when a peak comes out.
+	target := l.pushNeed(math.Min(1, limiterCeiling/math.Max(peak, 1e-12)))
+
+	if target < l.gain {
+		l.gain += (target - l.gain) * l.attack
+	} else {
+		l.gain += (target - l.gain) * l.release
+	}
+
+	out := l.delay[l.pos]
+	l.delay[l.pos] = s
+	l.pos = (l.pos + 1) % len(l.delay)
+
+	out[0] *= l.gain
+	out[1] *= l.gain
+
+	// The smoothed gain can lag a sudden peak slightly; never let it out.
+	out[0] = math.Max(-limiterCeiling, math.Min(limiterCeiling, out[0]))
+	out[1] = math.Max(-limiterCeiling, math.Min(limiterCeiling, out[1]))
+
+	return out
+}
+
+// pushNeed adds the gain the newest sample needs and returns the lowest
+// gain needed within the lookahead.
+func (l *Limiter) pushNeed(need float64) float64 {
+	size := len(l.need)
+
+	// Drop entries no lower than the new one; they can never be the minimum
+	for l.count > 0 {
+		last := (l.head + l.count - 1) % size
+		if l.need[last] > need {
+			break
+		}
+		l.count--
+	}
+
+	tail := (l.head + l.count) % size
+	l.needAt[tail] = l.sample
+	l.need[tail] = need
+	l.count++
+
+	// Drop entries that have left the delay line, keeping the one that
+	// comes out now
+	for l.needAt[l.head] < l.sample-len(l.delay) {
+		l.head = (l.head + 1) % size
+		l.count--
+	}
+
+	l.sample++
+
+	return l.need[l.head]
+}
+
+// Err implements beep.Streamer.
+func (l *Limiter) Err() error {
+	return l.streamer.Err()
+}
+
+// SetPreamp sets the gain applied before limiting, in dB.
+func (l *Limiter) SetPreamp(db float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.preamp = db
+	l.preampGain = math.Pow(10, db/20)
+}
+
+// Preamp returns the preamp in dB.
+func (l *Limiter) Preamp() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.preamp
+}
+
+// SetEnabled turns limiting on or off. Clipped samples are counted either
+// way.
+func (l *Limiter) SetEnabled(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if enabled && !l.enabled {
+		l.resetLocked()
+	}
+	l.enabled = enabled
+}
+
+// IsEnabled returns whether the limiter is active.
+func (l *Limiter) IsEnabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.enabled
+}
+
+// Clipped returns the number of samples that went over full scale since the
+// last ResetClipped.
+func (l *Limiter) Clipped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.clipped
+}
+
+// ResetClipped starts counting clipped samples from zero, e.g. for a new
+// track.
+func (l *Limiter) ResetClipped() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clipped = 0
+}
+
+// setSampleRate resizes the lookahead for a new output rate.
+func (l *Limiter) setSampleRate(sampleRate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.setSampleRateLocked(sampleRate)
+}
+
+func (l *Limiter) setSampleRateLocked(sampleRate float64) {
+	if sampleRate == l.rate && l.delay != nil {
+		return
+	}
+
+	l.rate = sampleRate
+	l.delay = make([][2]float64, max(int(sampleRate*limiterLookahead), 1))
+	l.needAt = make([]int, len(l.delay)+2)
+	l.need = make([]float64, len(l.delay)+2)
+
+	// Reach the target within the lookahead, recover over the release
+	l.attack = 1 - math.Exp(-5/float64(len(l.delay)))
+	l.release = 1 - math.Exp(-1/(sampleRate*limiterRelease))
+
+	l.resetLocked()
+}
+
+// reset clears the lookahead, e.g. after a seek.
+func (l *Limiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.resetLocked()
+}
+
+func (l *Limiter) resetLocked() {
+	clear(l.delay)
+	l.pos = 0
+	l.head = 0
+	l.count = 0
+	l.gain = 1
+}
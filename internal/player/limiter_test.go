@@ -0,0 +1,80 @@
+package player
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLimiterHoldsCeiling(t *testing.T) {
+	l := NewLimiter(&sineStreamer{freq: 100, rate: 48000}, 48000)
+	l.SetPreamp(12) // 0.5 becomes ~2.0
+
+	buf := make([][2]float64, 48000)
+	l.Stream(buf)
+
+	peak := 0.0
+	for _, s := range buf {
+		peak = math.Max(peak, math.Abs(s[0]))
+	}
+
+	if peak > limiterCeiling {
+		t.Errorf("peak: got %v, want <= %v", peak, limiterCeiling)
+	}
+	if peak < 0.9 {
+		t.Errorf("peak: got %v, want the signal limited, not silenced", peak)
+	}
+	if l.Clipped() == 0 {
+		t.Error("clipped: got 0, want the samples over full scale counted")
+	}
+}
+
+func TestLimiterTransparentBelowCeiling(t *testing.T) {
+	l := NewLimiter(&sineStreamer{freq: 440, rate: 48000}, 48000)
+
+	buf := make([][2]float64, 4800)
+	l.Stream(buf)
+
+	// The output is the input delayed by the lookahead.
+	delay := len(l.delay)
+	for i := delay; i < len(buf); i++ {
+		want := 0.5 * math.Sin(2*math.Pi*440*float64(i-delay)/48000)
+		if math.Abs(buf[i][0]-want) > 1e-9 {
+			t.Fatalf("sample %d: got %v, want %v", i, buf[i][0], want)
+		}
+	}
+
+	if l.Clipped() != 0 {
+		t.Errorf("clipped: got %d, want 0", l.Clipped())
+	}
+}
+
+func TestLimiterDisabledCountsClips(t *testing.T) {
+	l := NewLimiter(&sineStreamer{freq: 440, rate: 48000}, 48000)
+	l.SetEnabled(false)
+	l.SetPreamp(12)
+
+	buf := make([][2]float64, 4800)
+	l.Stream(buf)
+
+	if got, want := buf[10][0], 0.5*math.Pow(10, 12.0/20)*math.Sin(2*math.Pi*440*10/48000); math.Abs(got-want) > 1e-9 {
+		t.Errorf("sample: got %v, want %v", got, want)
+	}
+	if l.Clipped() == 0 {
+		t.Error("clipped: got 0, want the samples over full scale counted")
+	}
+
+	l.ResetClipped()
+	if l.Clipped() != 0 {
+		t.Errorf("clipped after reset: got %d, want 0", l.Clipped())
+	}
+}
+
+func TestLimiterDoesNotAllocate(t *testing.T) {
+	l := NewLimiter(&sineStreamer{freq: 440, rate: 44100}, 44100)
+	l.SetPreamp(12)
+	buf := make([][2]float64, 512)
+
+	if allocs := testing.AllocsPerRun(100, func() { l.Stream(buf) }); allocs != 0 {
+		t.Errorf("Stream allocations: got %v, want 0", allocs)
+	}
+}
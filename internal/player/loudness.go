@@ -0,0 +1,326 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// ReferenceLoudness is the target integrated loudness in LUFS that
+// normalization gains are computed against (the ReplayGain 2.0 reference).
+const ReferenceLoudness = -18.0
+
+// TruePeakCeiling is the highest true peak in dBTP that normalization may
+// raise a track to.
+const TruePeakCeiling = -1.0
+
+// MaxNormalizationBoost is the most in dB that normalization raises a
+// track, so that quiet intros, spoken word and badly measured files are not
+// blown up along with their noise floor.
+const MaxNormalizationBoost = 6.0
+
+// ErrNoLoudness reports a file with nothing above the absolute gate, such
+// as silence, whose loudness cannot be measured.
+var ErrNoLoudness = errors.New("no audible material to measure")
+
+// Gating thresholds from ITU-R BS.1770-4 / EBU R128.
+const (
+	absoluteGateLUFS = -70.0
+	relativeGateLU   = -10.0
+)
+
+// oversampling factor and taps per phase for the true-peak interpolator.
+const (
+	truePeakFactor = 4
+	truePeakTaps   = 12
+)
+
+// loudnessMeter measures integrated loudness and true peak of a stream of
+// samples, following ITU-R BS.1770: K-weighting, 400 ms blocks with 75%
+// overlap, and absolute plus relative gating.
+type loudnessMeter struct {
+	channels int
+	shelf    biquadFilter
+	highpass biquadFilter
+
+	// Sum of squared K-weighted samples per 100 ms step, per channel
+	// summed. The last four steps form one 400 ms gating block.
+	stepLen   int
+	stepPos   int
+	stepSum   float64
+	steps     [4]float64
+	stepCount int
+	blocks    []float64 // mean square of each gating block
+
+	history [2][truePeakTaps]float64
+	histPos int
+	phases  [truePeakFactor][truePeakTaps]float64
+	peakLin float64
+}
+
+func newLoudnessMeter(format beep.Format) *loudnessMeter {
+	rate := float64(format.SampleRate)
+	m := &loudnessMeter{
+		channels: format.NumChannels,
+		stepLen:  max(format.SampleRate.N(100*time.Millisecond), 1),
+	}
+
+	m.shelf.coeffs = kWeightingShelf(rate)
+	m.highpass.coeffs = kWeightingHighpass(rate)
+	m.phases = truePeakPhases()
+
+	return m
+}
+
+// kWeightingShelf returns the high-shelf stage of the K-weighting filter
+// for the given sample rate.
+func kWeightingShelf(rate float64) biquadCoeffs {
+	const (
+		f0 = 1681.974450955533
+		g  = 3.999843853973347
+		q  = 0.7071752369554196
+	)
+
+	k := math.Tan(math.Pi * f0 / rate)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+
+	return biquadCoeffs{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+}
+
+// kWeightingHighpass returns the RLB high-pass stage of the K-weighting
+// filter for the given sample rate.
+func kWeightingHighpass(rate float64) biquadCoeffs {
+	const (
+		f0 = 38.13547087602444
+		q  = 0.5003270373238773
+	)
+
+	k := math.Tan(math.Pi * f0 / rate)
+	a0 := 1 + k/q + k*k
+
+	return biquadCoeffs{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+}
+
+// truePeakPhases builds the polyphase windowed-sinc interpolator used to
+// estimate inter-sample peaks.
+func truePeakPhases() [truePeakFactor][truePeakTaps]float64 {
+	var phases [truePeakFactor][truePeakTaps]float64
+
+	const length = truePeakFactor * truePeakTaps
+	center := float64(length-1) / 2
+
+	for n := range length {
+		x := (float64(n) - center) / truePeakFactor
+
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+
+		window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(n)/float64(length-1))
+		phases[n%truePeakFactor][n/truePeakFactor] = sinc * window
+	}
+
+	return phases
+}
+
+func (f *biquadFilter) process(ch int, x float64) float64 {
+	c := &f.coeffs
+	s := &f.state[ch]
+	y := c.b0*x + c.b1*s.x1 + c.b2*s.x2 - c.a1*s.y1 - c.a2*s.y2
+	s.x2, s.x1 = s.x1, x
+	s.y2, s.y1 = s.y1, y
+
+	return y
+}
+
+// write feeds samples into the meter.
+func (m *loudnessMeter) write(samples [][2]float64) {
+	for _, s := range samples {
+		for ch := range m.channels {
+			z := m.highpass.process(ch, m.shelf.process(ch, s[ch]))
+			m.stepSum += z * z
+		}
+
+		m.trackPeak(s)
+
+		m.stepPos++
+		if m.stepPos == m.stepLen {
+			m.endStep()
+		}
+	}
+}
+
+// endStep closes a 100 ms step and, once four are available, records the
+// 400 ms block that ends with it.
+func (m *loudnessMeter) endStep() {
+	copy(m.steps[:], m.steps[1:])
+	m.steps[3] = m.stepSum
+	m.stepSum = 0
+	m.stepPos = 0
+	m.stepCount++
+
+	if m.stepCount >= 4 {
+		total := m.steps[0] + m.steps[1] + m.steps[2] + m.steps[3]
+		m.blocks = append(m.blocks, total/float64(4*m.stepLen))
+	}
+}
+
+// trackPeak updates the true peak with the sample and the interpolated
+// values between it and its predecessors.
+func (m *loudnessMeter) trackPeak(s [2]float64) {
+	m.histPos = (m.histPos + 1) % truePeakTaps
+
+	for ch := range m.channels {
+		m.history[ch][m.histPos] = s[ch]
+		m.peakLin = math.Max(m.peakLin, math.Abs(s[ch]))
+
+		for p := range truePeakFactor {
+			var y float64
+			for k := range truePeakTaps {
+				y += m.phases[p][k] * m.history[ch][(m.histPos-k+truePeakTaps)%truePeakTaps]
+			}
+
+			m.peakLin = math.Max(m.peakLin, math.Abs(y))
+		}
+	}
+}
+
+// integrated returns the gated integrated loudness in LUFS. Silence, or
+// material shorter than one block, reports the absolute gate.
+func (m *loudnessMeter) integrated() float64 {
+	absGate := blockPower(absoluteGateLUFS)
+
+	var sum float64
+	var count int
+	for _, b := range m.blocks {
+		if b > absGate {
+			sum += b
+			count++
+		}
+	}
+
+	if count == 0 {
+		return absoluteGateLUFS
+	}
+
+	relGate := sum / float64(count) * math.Pow(10, relativeGateLU/10)
+
+	sum, count = 0, 0
+	for _, b := range m.blocks {
+		if b > absGate && b > relGate {
+			sum += b
+			count++
+		}
+	}
+
+	if count == 0 {
+		return absoluteGateLUFS
+	}
+
+	return blockLoudness(sum / float64(count))
+}
+
+// truePeak returns the true peak in dBTP.
+func (m *loudnessMeter) truePeak() float64 {
+	if m.peakLin <= 0 {
+		return math.Inf(-1)
+	}
+
+	return 20 * math.Log10(m.peakLin)
+}
+
+// blockLoudness converts a mean square to LUFS.
+func blockLoudness(meanSquare float64) float64 {
+	return -0.691 + 10*math.Log10(meanSquare)
+}
+
+// blockPower converts LUFS back to a mean square.
+func blockPower(lufs float64) float64 {
+	return math.Pow(10, (lufs+0.691)/10)
+}
+
+// AnalyzeLoudness decodes an audio file and returns its integrated loudness
+// in LUFS and its true peak in dBTP, or ErrNoLoudness for silence.
+func AnalyzeLoudness(filepath string) (lufs, peakDB float64, err error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	streamer, format, err := openDecoder(file, filepath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer streamer.Close()
+
+	m := newLoudnessMeter(format)
+	buf := make([][2]float64, 8192)
+
+	for {
+		n, ok := streamer.Stream(buf)
+		m.write(buf[:n])
+
+		if !ok {
+			break
+		}
+	}
+
+	if streamErr := streamer.Err(); streamErr != nil {
+		return 0, 0, fmt.Errorf("failed to decode %s: %w", filepath, streamErr)
+	}
+
+	lufs, peakDB = m.integrated(), m.truePeak()
+	if lufs <= absoluteGateLUFS || math.IsInf(peakDB, 0) {
+		return 0, 0, ErrNoLoudness
+	}
+
+	return lufs, peakDB, nil
+}
+
+// NormalizationGain returns the gain in dB that brings material of the given
+// loudness to ReferenceLoudness, reduced if needed so that its true peak
+// stays at or below TruePeakCeiling. Boosts are capped at
+// MaxNormalizationBoost.
+func NormalizationGain(lufs, peakDB float64) float64 {
+	gain := min(ReferenceLoudness-lufs, MaxNormalizationBoost)
+
+	if limit := TruePeakCeiling - peakDB; gain > limit {
+		gain = limit
+	}
+
+	return gain
+}
+
+// AlbumLoudness combines the integrated loudness of several tracks into a
+// single album loudness by averaging their power.
+func AlbumLoudness(lufs []float64) float64 {
+	if len(lufs) == 0 {
+		return absoluteGateLUFS
+	}
+
+	var sum float64
+	for _, l := range lufs {
+		sum += blockPower(l)
+	}
+
+	return blockLoudness(sum / float64(len(lufs)))
+}
@@ -0,0 +1,93 @@
+package player
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+var loudnessFormat = beep.Format{SampleRate: 48000, NumChannels: 2, Precision: 2}
+
+// sine returns a stereo sine wave of the given frequency, amplitude and phase.
+func sine(freq, amp, phase float64, seconds float64) [][2]float64 {
+	n := int(seconds * float64(loudnessFormat.SampleRate))
+	samples := make([][2]float64, n)
+	for i := range samples {
+		v := amp * math.Sin(2*math.Pi*freq*float64(i)/float64(loudnessFormat.SampleRate)+phase)
+		samples[i] = [2]float64{v, v}
+	}
+	return samples
+}
+
+// --- Integrated loudness ---
+
+func TestLoudnessOfReferenceSine(t *testing.T) {
+	// EBU Tech 3341: a stereo 1 kHz sine at -23 dBFS measures -23 LUFS.
+	m := newLoudnessMeter(loudnessFormat)
+	m.write(sine(1000, math.Pow(10, -23.0/20), 0, 5))
+
+	if got := m.integrated(); math.Abs(got+23) > 0.1 {
+		t.Errorf("integrated: got %.2f LUFS, want -23", got)
+	}
+}
+
+func TestLoudnessGatesSilence(t *testing.T) {
+	m := newLoudnessMeter(loudnessFormat)
+	m.write(sine(1000, math.Pow(10, -23.0/20), 0, 5))
+	m.write(make([][2]float64, 5*48000))
+
+	// The few blocks straddling the boundary pass the gate and pull the
+	// result down slightly.
+	if got := m.integrated(); math.Abs(got+23) > 0.3 {
+		t.Errorf("integrated with silence: got %.2f LUFS, want -23 (silence gated)", got)
+	}
+
+	silent := newLoudnessMeter(loudnessFormat)
+	silent.write(make([][2]float64, 48000))
+	if got := silent.integrated(); got != absoluteGateLUFS {
+		t.Errorf("integrated of silence: got %.2f, want %.2f", got, absoluteGateLUFS)
+	}
+}
+
+// --- True peak ---
+
+func TestTruePeakFindsInterSamplePeak(t *testing.T) {
+	// At fs/4 with a 45° phase every sample lands at ±0.354, while the
+	// waveform itself peaks at 0.5 (-6.02 dBTP).
+	m := newLoudnessMeter(loudnessFormat)
+	m.write(sine(12000, 0.5, math.Pi/4, 1))
+
+	if got := m.truePeak(); math.Abs(got+6.02) > 0.5 {
+		t.Errorf("truePeak: got %.2f dBTP, want ~-6.02", got)
+	}
+}
+
+// --- Gain ---
+
+func TestNormalizationGain(t *testing.T) {
+	if got := NormalizationGain(-23, -10); got != 5 {
+		t.Errorf("NormalizationGain(-23, -10): got %.2f, want 5", got)
+	}
+	// Raising by 5 dB would put the peak at +1 dBTP; limited to -1 dBTP.
+	if got := NormalizationGain(-23, -4); got != 3 {
+		t.Errorf("NormalizationGain(-23, -4): got %.2f, want 3 (peak limited)", got)
+	}
+	if got := NormalizationGain(-8, -0.5); got != -10 {
+		t.Errorf("NormalizationGain(-8, -0.5): got %.2f, want -10", got)
+	}
+	// Raising a very quiet track is capped, peak headroom or not.
+	if got := NormalizationGain(-40, -30); got != MaxNormalizationBoost {
+		t.Errorf("NormalizationGain(-40, -30): got %.2f, want %.2f (boost capped)", got, MaxNormalizationBoost)
+	}
+}
+
+func TestAlbumLoudness(t *testing.T) {
+	if got := AlbumLoudness([]float64{-14, -14}); math.Abs(got+14) > 1e-9 {
+		t.Errorf("AlbumLoudness(equal): got %.2f, want -14", got)
+	}
+	// Power average leans towards the louder track.
+	if got := AlbumLoudness([]float64{-10, -20}); got < -13 || got > -12.5 {
+		t.Errorf("AlbumLoudness(-10, -20): got %.2f, want ~-12.6", got)
+	}
+}
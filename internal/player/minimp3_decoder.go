@@ -20,10 +20,16 @@ type minimp3Decoder struct {
 	data         []byte
 	format       beep.Format
 	position     int // Current position in samples
-	TotalSamples int // Will be updated when EOF is discovered
+	TotalSamples int // From the Xing/VBRI header if present, otherwise corrected at EOF
 	buffer       []int16
 	bufferIndex  int
 
+	// Frame index for exact seeking, and the VBR header if the stream has
+	// one. lengthFromHeader is set when TotalSamples is exact.
+	index            *mp3FrameIndex
+	vbr              mp3VBRHeader
+	lengthFromHeader bool
+
 	// Callback to notify player of actual duration changes
 	durationUpdateCallback func(actualSamples int)
 
@@ -70,6 +76,7 @@ func DecodeMiniMP3(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
 	channels := dec.Channels
 
 	// Reset decoder
+	dec.Close()
 	dec, err = minimp3.NewDecoder(bytes.NewReader(data))
 	if err != nil {
 		return nil, beep.Format{}, fmt.Errorf("failed to recreate decoder: %w", err)
@@ -81,22 +88,15 @@ func DecodeMiniMP3(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
 		Precision:   2, // 16-bit
 	}
 
-	// Start with conservative estimate - will be updated when we reach actual EOF
-	// or from ffprobe if available
-	totalSamples := sampleRate * 60 * 5 // 5 minutes default - prevents seeking beyond actual audio
-
-	logger.Debug("minimp3: Created decoder for %d Hz, %d channels, initial estimate: %d samples",
-		sampleRate, channels, totalSamples)
-
 	// Pre-allocate buffers to reduce GC pressure
 	const maxDecodeSize = 4608 * 2 // Max MP3 frame size * 2 for stereo
 
-	return &minimp3Decoder{
+	d := &minimp3Decoder{
 		decoder:                dec,
 		data:                   data,
 		format:                 format,
 		position:               0,
-		TotalSamples:           totalSamples,
+		TotalSamples:           sampleRate * 60 * 5, // 5 minutes default - prevents seeking beyond actual audio
 		buffer:                 make([]int16, 0),
 		bufferIndex:            0,
 		durationUpdateCallback: nil,
@@ -105,7 +105,40 @@ func DecodeMiniMP3(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
 		decodeBuffer: make([]byte, maxDecodeSize),
 		pcmBuffer:    make([]int16, maxDecodeSize),
 		samplePool:   make([][2]float64, 8192), // Pool of samples
-	}, format, nil
+	}
+
+	d.readStreamHeaders()
+
+	logger.Debug("minimp3: Created decoder for %d Hz, %d channels, length: %d samples (exact: %v)",
+		sampleRate, channels, d.TotalSamples, d.lengthFromHeader)
+
+	return d, format, nil
+}
+
+// readStreamHeaders sets up the frame index and takes the stream length
+// from the Xing/Info or VBRI header. Without one, the length is estimated
+// from the bitrate of the first frame and corrected at EOF.
+func (d *minimp3Decoder) readStreamHeaders() {
+	index, ok := newMP3FrameIndex(d.data)
+	if !ok || !index.extend(0) {
+		return
+	}
+
+	d.index = index
+	first := index.first
+
+	end := min(index.offsets[0]+first.size, len(d.data))
+	if vbr, ok := parseVBRHeader(d.data[index.offsets[0]:end], first); ok {
+		// minimp3 decodes the header frame itself as one frame of silence.
+		d.vbr = vbr
+		d.TotalSamples = (vbr.frames + 1) * first.samples
+		d.lengthFromHeader = true
+
+		return
+	}
+
+	audioBytes := len(d.data) - index.offsets[0]
+	d.TotalSamples = int(int64(audioBytes) * 8 * int64(first.sampleRate) / int64(first.bitrate))
 }
 
 // Stream streams audio samples using batch conversion for better performance.
@@ -156,6 +189,11 @@ func (d *minimp3Decoder) refillBuffer(samples [][2]float64, startIndex int) bool
 
 	d.convertBytesToSamples(buf, bytesRead)
 
+	// Keep the frame index a little ahead of playback.
+	if d.index != nil {
+		d.index.extend(d.position/d.index.first.samples + 64)
+	}
+
 	return true
 }
 
@@ -281,7 +319,17 @@ func (d *minimp3Decoder) Position() int {
 	return d.position
 }
 
-// Seek seeks to a position in samples - simplified approach.
+// mp3SeekPreroll is the number of frames decoded ahead of the target frame
+// after a seek, so that the bit reservoir and the MDCT overlap are primed;
+// mp3MaxPreroll bounds how far back that may go.
+const (
+	mp3SeekPreroll = 2
+	mp3MaxPreroll  = 16
+)
+
+// Seek seeks to a position in samples. With a frame index the decoder is
+// restarted a few frames ahead of the frame holding the target and then
+// skips to the exact sample.
 func (d *minimp3Decoder) Seek(p int) error {
 	// Clamp to valid range
 	if p < 0 {
@@ -292,77 +340,140 @@ func (d *minimp3Decoder) Seek(p int) error {
 		p = d.TotalSamples - 1
 	}
 
-	// For seeking near the beginning, just reset and decode forward
-	if p < d.TotalSamples/50 { // First 2% of file (about 6 seconds for a 5-minute song)
+	if d.index == nil {
 		return d.seekFromBeginning(p)
 	}
 
-	// For later positions, try byte-based approximation then decode forward
-	return d.seekApproximate(p)
+	spf := d.index.first.samples
+	frame := p / spf
+
+	if !d.index.extend(frame) {
+		if !d.index.complete {
+			return d.seekApproximate(p)
+		}
+
+		// The stream is shorter than its header claimed.
+		frame = len(d.index.offsets) - 1
+		p = min(p, (frame+1)*spf-1)
+	}
+
+	return d.seekToFrame(frame, p)
 }
 
-// seekFromBeginning resets decoder and reads forward to target position.
-func (d *minimp3Decoder) seekFromBeginning(targetPos int) error {
-	// Reset decoder
-	dec, err := minimp3.NewDecoder(bytes.NewReader(d.data))
+// seekToFrame restarts decoding ahead of the given frame and discards
+// samples up to target. minimp3 outputs nothing for frames whose bit
+// reservoir is incomplete, so those are predicted from the side
+// information and left out of the count.
+func (d *minimp3Decoder) seekToFrame(frame, target int) error {
+	spf := d.index.first.samples
+
+	start := max(frame-mp3SeekPreroll, 0)
+
+	decodable := d.index.decodableFrames(start, frame)
+	for !decodable[len(decodable)-1] && start > 0 && frame-start < mp3MaxPreroll {
+		start--
+		decodable = d.index.decodableFrames(start, frame)
+	}
+
+	skip := target - frame*spf
+	for _, ok := range decodable[:len(decodable)-1] {
+		if ok {
+			skip += spf
+		}
+	}
+
+	if err := d.restartAt(d.index.offsets[start]); err != nil {
+		return err
+	}
+
+	d.discard(skip)
+	d.position = target
+
+	return nil
+}
+
+// restartAt replaces the decoder with one reading from the given offset.
+func (d *minimp3Decoder) restartAt(offset int) error {
+	dec, err := minimp3.NewDecoder(bytes.NewReader(d.data[offset:]))
 	if err != nil {
 		return fmt.Errorf("failed to recreate decoder: %w", err)
 	}
 
+	if d.decoder != nil {
+		d.decoder.Close()
+	}
+
 	d.decoder = dec
 	d.position = 0
 	d.buffer = make([]int16, 0)
 	d.bufferIndex = 0
 
-	if targetPos <= 0 {
-		return nil
-	}
+	return nil
+}
 
-	// Skip forward to target position with larger buffer for efficiency
-	skipBuffer := make([]byte, 32768) // Increased from 8KB to 32KB for faster skipping
-	samplesToSkip := targetPos
+// discard decodes and drops the given number of samples.
+func (d *minimp3Decoder) discard(samples int) {
+	remaining := samples * 2 * d.format.NumChannels
 
-	for samplesToSkip > 0 {
-		bytesRead, readErr := d.decoder.Read(skipBuffer)
-		if readErr == io.EOF || bytesRead == 0 {
-			break
+	for remaining > 0 {
+		buf := d.readBuffer[:min(remaining, len(d.readBuffer))]
+
+		bytesRead, err := d.decoder.Read(buf)
+		if err == io.EOF || bytesRead == 0 {
+			return
 		}
 
-		samplesRead := min(bytesRead/(2*d.format.NumChannels), samplesToSkip)
+		remaining -= bytesRead
+	}
+}
 
-		samplesToSkip -= samplesRead
-		d.position += samplesRead
+// seekFromBeginning resets decoder and reads forward to target position.
+func (d *minimp3Decoder) seekFromBeginning(targetPos int) error {
+	if err := d.restartAt(0); err != nil {
+		return err
 	}
 
+	d.discard(targetPos)
+	d.position = targetPos
+
 	return nil
 }
 
-// seekApproximate uses byte-based estimation for faster seeking to later positions.
+// seekApproximate estimates the byte offset of the target, from the
+// Xing/VBRI table of contents if there is one, and resumes decoding at the
+// next frame there. Used only when the frame index cannot reach the target.
 func (d *minimp3Decoder) seekApproximate(targetPos int) error {
-	// Use simple byte-based estimation
 	targetRatio := float64(targetPos) / float64(d.TotalSamples)
-	estimatedBytePos := max(
-		// Clamp to safe range
-		min(
 
-			int(targetRatio*float64(len(d.data))), len(d.data)-1024), 0)
+	estimatedBytePos := int(targetRatio * float64(len(d.data)))
+	if d.index != nil {
+		if offset, ok := d.vbr.seekOffset(targetRatio); ok {
+			estimatedBytePos = d.index.offsets[0] + offset
+		}
 
-	// Create decoder from estimated position
-	dec, err := minimp3.NewDecoder(bytes.NewReader(d.data[estimatedBytePos:]))
-	if err != nil {
+		if off, _, ok := d.index.sync(min(estimatedBytePos, len(d.data))); ok {
+			estimatedBytePos = off
+		}
+	}
+
+	// Clamp to safe range
+	estimatedBytePos = max(min(estimatedBytePos, len(d.data)-1024), 0)
+
+	if err := d.restartAt(estimatedBytePos); err != nil {
 		// Fallback to beginning if estimation fails
 		return d.seekFromBeginning(targetPos)
 	}
 
-	d.decoder = dec
 	d.position = int(targetRatio * float64(d.TotalSamples))
-	d.buffer = make([]int16, 0)
-	d.bufferIndex = 0
 
 	return nil
 }
 
 // Close closes the decoder.
 func (d *minimp3Decoder) Close() error {
+	if d.decoder != nil {
+		d.decoder.Close()
+	}
+
 	return nil
 }
@@ -0,0 +1,402 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// maxBitReservoir is the size of minimp3's bit reservoir in bytes.
+const maxBitReservoir = 511
+
+// mp3FrameHeader is a parsed MPEG audio frame header.
+type mp3FrameHeader struct {
+	mpeg1      bool
+	layer      int
+	crc        bool
+	bitrate    int // bits per second
+	sampleRate int
+	mono       bool
+	size       int // frame length in bytes, including the header
+	samples    int // samples per channel
+}
+
+var mp3Bitrates = [2][3][15]int{
+	{ // MPEG-1, layers I-III
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{ // MPEG-2 and 2.5, layers I-III
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+// parseMP3FrameHeader parses the four header bytes at the start of b.
+// Free-format streams are not supported.
+func parseMP3FrameHeader(b []byte) (mp3FrameHeader, bool) {
+	var h mp3FrameHeader
+
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return h, false
+	}
+
+	version := b[1] >> 3 & 0x03 // 0: MPEG-2.5, 2: MPEG-2, 3: MPEG-1
+	layerBits := b[1] >> 1 & 0x03
+	bitrateIdx := b[2] >> 4
+	rateIdx := b[2] >> 2 & 0x03
+
+	if version == 1 || layerBits == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return h, false
+	}
+
+	h.mpeg1 = version == 3
+	h.layer = 4 - int(layerBits)
+	h.crc = b[1]&0x01 == 0
+	h.mono = b[3]>>6 == 3
+
+	lsf := 1
+	if h.mpeg1 {
+		lsf = 0
+	}
+
+	h.bitrate = mp3Bitrates[lsf][h.layer-1][bitrateIdx] * 1000
+
+	h.sampleRate = mp3SampleRates[rateIdx]
+	switch version {
+	case 2:
+		h.sampleRate /= 2
+	case 0:
+		h.sampleRate /= 4
+	}
+
+	padding := int(b[2] >> 1 & 0x01)
+
+	switch {
+	case h.layer == 1:
+		h.samples = 384
+		h.size = (12*h.bitrate/h.sampleRate + padding) * 4
+	case h.layer == 3 && !h.mpeg1:
+		h.samples = 576
+		h.size = 72*h.bitrate/h.sampleRate + padding
+	default:
+		h.samples = 1152
+		h.size = 144*h.bitrate/h.sampleRate + padding
+	}
+
+	return h, true
+}
+
+// sideInfoSize returns the length of the layer III side information.
+func (h mp3FrameHeader) sideInfoSize() int {
+	switch {
+	case h.mpeg1 && h.mono:
+		return 17
+	case h.mpeg1:
+		return 32
+	case h.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// compatible reports whether two headers can belong to the same stream.
+func (h mp3FrameHeader) compatible(o mp3FrameHeader) bool {
+	return h.mpeg1 == o.mpeg1 && h.layer == o.layer && h.sampleRate == o.sampleRate && h.mono == o.mono
+}
+
+// mp3VBRHeader holds what a Xing/Info or VBRI header says about the stream.
+type mp3VBRHeader struct {
+	frames int // audio frames, excluding the header frame itself
+	bytes  int // stream length in bytes, 0 if unknown
+
+	// seekPoints maps fractions of the stream to byte offsets from the
+	// header frame: point i is at fraction i/len(seekPoints).
+	seekPoints []int
+}
+
+// parseVBRHeader looks for a Xing/Info or VBRI header in the first frame.
+func parseVBRHeader(frame []byte, h mp3FrameHeader) (mp3VBRHeader, bool) {
+	var v mp3VBRHeader
+
+	if off := 4 + h.sideInfoSize(); len(frame) >= off+8 {
+		tag := frame[off : off+4]
+		if bytes.Equal(tag, []byte("Xing")) || bytes.Equal(tag, []byte("Info")) {
+			return parseXing(frame[off+4:])
+		}
+	}
+
+	if len(frame) >= 36+26 && bytes.Equal(frame[36:40], []byte("VBRI")) {
+		return parseVBRI(frame[40:])
+	}
+
+	return v, false
+}
+
+func parseXing(b []byte) (mp3VBRHeader, bool) {
+	var v mp3VBRHeader
+
+	flags := binary.BigEndian.Uint32(b)
+	b = b[4:]
+
+	if flags&0x1 != 0 {
+		if len(b) < 4 {
+			return v, false
+		}
+		v.frames = int(binary.BigEndian.Uint32(b))
+		b = b[4:]
+	}
+
+	if flags&0x2 != 0 {
+		if len(b) < 4 {
+			return v, false
+		}
+		v.bytes = int(binary.BigEndian.Uint32(b))
+		b = b[4:]
+	}
+
+	// The TOC gives, for each percent of the duration, the byte position
+	// as a fraction of 256 of the total size.
+	if flags&0x4 != 0 && len(b) >= 100 && v.bytes > 0 {
+		v.seekPoints = make([]int, 100)
+		for i := range v.seekPoints {
+			v.seekPoints[i] = int(b[i]) * v.bytes / 256
+		}
+	}
+
+	return v, v.frames > 0
+}
+
+func parseVBRI(b []byte) (mp3VBRHeader, bool) {
+	var v mp3VBRHeader
+
+	v.bytes = int(binary.BigEndian.Uint32(b[6:]))
+	v.frames = int(binary.BigEndian.Uint32(b[10:]))
+	entries := int(binary.BigEndian.Uint16(b[14:]))
+	scale := int(binary.BigEndian.Uint16(b[16:]))
+	entrySize := int(binary.BigEndian.Uint16(b[18:]))
+	b = b[22:]
+
+	// Each entry is the byte length of an equal share of the frames.
+	if entries > 0 && entrySize >= 1 && entrySize <= 4 && len(b) >= entries*entrySize {
+		v.seekPoints = make([]int, entries)
+		pos := 0
+		for i := range entries {
+			v.seekPoints[i] = pos
+
+			var size int
+			for _, c := range b[i*entrySize : (i+1)*entrySize] {
+				size = size<<8 | int(c)
+			}
+			pos += size * scale
+		}
+	}
+
+	return v, v.frames > 0
+}
+
+// seekOffset estimates the byte offset, relative to the header frame, of
+// the given fraction of the stream.
+func (v mp3VBRHeader) seekOffset(fraction float64) (int, bool) {
+	if len(v.seekPoints) == 0 {
+		return 0, false
+	}
+
+	i := min(max(int(fraction*float64(len(v.seekPoints))), 0), len(v.seekPoints)-1)
+
+	return v.seekPoints[i], true
+}
+
+// id3v2Size returns the length of an ID3v2 tag at the start of data.
+func id3v2Size(data []byte) int {
+	if len(data) < 10 || !bytes.Equal(data[:3], []byte("ID3")) {
+		return 0
+	}
+
+	size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+
+	return min(size, len(data))
+}
+
+// mp3FrameIndex records the byte offset of every frame found so far. It is
+// extended on demand by walking frame headers, which is much cheaper than
+// decoding.
+type mp3FrameIndex struct {
+	data     []byte
+	first    mp3FrameHeader
+	offsets  []int
+	next     int  // offset where the walk continues
+	complete bool // the walk reached the end of the stream
+}
+
+// newMP3FrameIndex locates the first frame after any ID3v2 tag.
+func newMP3FrameIndex(data []byte) (*mp3FrameIndex, bool) {
+	idx := &mp3FrameIndex{data: data}
+
+	off, h, ok := idx.sync(id3v2Size(data))
+	if !ok {
+		return nil, false
+	}
+
+	idx.first = h
+	idx.next = off
+
+	return idx, true
+}
+
+// sync finds the next offset at or after from where a frame header is
+// followed by another compatible header (or the end of the data).
+func (idx *mp3FrameIndex) sync(from int) (int, mp3FrameHeader, bool) {
+	for i := from; i+4 <= len(idx.data); i++ {
+		h, ok := parseMP3FrameHeader(idx.data[i:])
+		if !ok {
+			continue
+		}
+
+		if idx.first.layer != 0 && !idx.first.compatible(h) {
+			continue
+		}
+
+		end := i + h.size
+		if end == len(idx.data) {
+			return i, h, true
+		}
+
+		if n, ok := parseMP3FrameHeader(idx.data[min(end, len(idx.data)):]); ok && h.compatible(n) {
+			return i, h, true
+		}
+	}
+
+	return 0, mp3FrameHeader{}, false
+}
+
+// extend walks frame headers until frame n is indexed or the stream ends.
+// It reports whether frame n exists.
+func (idx *mp3FrameIndex) extend(n int) bool {
+	for len(idx.offsets) <= n && !idx.complete {
+		h, ok := parseMP3FrameHeader(idx.data[min(idx.next, len(idx.data)):])
+		if !ok || !idx.first.compatible(h) {
+			// Trailing tags, or garbage inside the stream: resynchronize.
+			off, rh, found := idx.sync(idx.next)
+			if !found {
+				idx.complete = true
+				break
+			}
+			idx.next, h = off, rh
+		}
+
+		if idx.next+h.size > len(idx.data) {
+			idx.complete = true
+			break
+		}
+
+		idx.offsets = append(idx.offsets, idx.next)
+		idx.next += h.size
+	}
+
+	return n < len(idx.offsets)
+}
+
+// mp3SideInfo is the part of a layer III frame's side information that
+// determines how minimp3's bit reservoir evolves.
+type mp3SideInfo struct {
+	mainDataBegin int // bytes of main data taken from previous frames
+	mainBytes     int // main data bytes carried in this frame
+	part23Bits    int // main data bits used by this frame
+}
+
+// parseSideInfo reads the side information of the layer III frame at off.
+func (idx *mp3FrameIndex) parseSideInfo(off int) (mp3SideInfo, bool) {
+	var si mp3SideInfo
+
+	h, ok := parseMP3FrameHeader(idx.data[off:])
+	if !ok || h.layer != 3 {
+		return si, false
+	}
+
+	start := off + 4
+	if h.crc {
+		start += 2
+	}
+
+	size := h.sideInfoSize()
+	if start+size > len(idx.data) {
+		return si, false
+	}
+
+	si.mainBytes = h.size - (start - off) - size
+
+	channels := 2
+	if h.mono {
+		channels = 1
+	}
+
+	br := &bitReader{data: idx.data[start : start+size]}
+
+	granules, rest := 1, uint(51)
+	if h.mpeg1 {
+		v, _ := br.read(9)
+		si.mainDataBegin = int(v)
+		_, _ = br.read(uint(7 + 2*channels)) // private bits and scfsi
+		granules, rest = 2, 47
+	} else {
+		v, _ := br.read(8)
+		si.mainDataBegin = int(v)
+		_, _ = br.read(uint(channels)) // private bits
+	}
+
+	for range granules * channels {
+		v, err := br.read(12)
+		if err != nil {
+			return si, false
+		}
+		si.part23Bits += int(v)
+		_, _ = br.read(rest)
+	}
+
+	return si, true
+}
+
+// decodableFrames predicts, for frames first..last of the index, which ones
+// minimp3 decodes to audio when started at first with an empty bit
+// reservoir. Frames whose main data begins in bytes the reservoir does not
+// hold produce no samples.
+func (idx *mp3FrameIndex) decodableFrames(first, last int) []bool {
+	ok := make([]bool, last-first+1)
+
+	if idx.first.layer != 3 {
+		for i := range ok {
+			ok[i] = true
+		}
+		return ok
+	}
+
+	reservoir := 0
+	for i := range ok {
+		si, valid := idx.parseSideInfo(idx.offsets[first+i])
+		if !valid {
+			reservoir = 0
+			continue
+		}
+
+		ok[i] = reservoir >= si.mainDataBegin
+
+		total := min(reservoir, si.mainDataBegin) + si.mainBytes
+		used := 0
+		if ok[i] {
+			used = (si.part23Bits + 7) / 8
+		}
+
+		reservoir = min(total-used, maxBitReservoir)
+	}
+
+	return ok
+}
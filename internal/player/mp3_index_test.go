@@ -0,0 +1,179 @@
+package player
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// 128 kbps MPEG-1 layer III, 44.1 kHz, joint stereo, no CRC.
+var testMP3Header = []byte{0xff, 0xfb, 0x90, 0x40}
+
+// testMP3Frame builds a 417-byte frame whose side information carries the
+// given main_data_begin and total part2_3_length.
+func testMP3Frame(mainDataBegin, part23Bits int) []byte {
+	w := &bitWriter{buf: append([]byte{}, testMP3Header...)}
+	w.write(uint64(mainDataBegin), 9)
+	w.write(0, 11) // private bits and scfsi
+
+	for i := range 4 {
+		bits := part23Bits / 4
+		if i == 0 {
+			bits += part23Bits % 4
+		}
+		w.write(uint64(bits), 12)
+		w.write(0, 47)
+	}
+
+	return append(w.buf, make([]byte, 417-len(w.buf))...)
+}
+
+func TestParseMP3FrameHeader(t *testing.T) {
+	h, ok := parseMP3FrameHeader(testMP3Header)
+	if !ok {
+		t.Fatal("parseMP3FrameHeader: header rejected")
+	}
+	if h.size != 417 || h.samples != 1152 || h.sampleRate != 44100 || !h.mpeg1 || h.layer != 3 {
+		t.Errorf("header: got %+v", h)
+	}
+
+	padded, _ := parseMP3FrameHeader([]byte{0xff, 0xfb, 0x92, 0x40})
+	if padded.size != 418 {
+		t.Errorf("padded size: got %d, want 418", padded.size)
+	}
+
+	// MPEG-2 layer III, 64 kbps, 22.05 kHz
+	lsf, _ := parseMP3FrameHeader([]byte{0xff, 0xf3, 0x80, 0xc0})
+	if lsf.samples != 576 || lsf.sampleRate != 22050 || lsf.size != 208 {
+		t.Errorf("MPEG-2 header: got %+v", lsf)
+	}
+
+	if _, ok := parseMP3FrameHeader([]byte{0xff, 0xfb, 0xf0, 0x40}); ok {
+		t.Error("parseMP3FrameHeader: accepted bad bitrate index")
+	}
+}
+
+func TestParseXingHeader(t *testing.T) {
+	frame := testMP3Frame(0, 0)
+	off := 4 + 32
+	copy(frame[off:], "Xing")
+	binary.BigEndian.PutUint32(frame[off+4:], 0x7)
+	binary.BigEndian.PutUint32(frame[off+8:], 9000)
+	binary.BigEndian.PutUint32(frame[off+12:], 3_000_000)
+	for i := range 100 {
+		frame[off+16+i] = byte(i * 256 / 100)
+	}
+
+	h, _ := parseMP3FrameHeader(frame)
+	vbr, ok := parseVBRHeader(frame, h)
+	if !ok {
+		t.Fatal("parseVBRHeader: Xing header not found")
+	}
+	if vbr.frames != 9000 {
+		t.Errorf("frames: got %d, want 9000", vbr.frames)
+	}
+
+	offset, ok := vbr.seekOffset(0.5)
+	if want := 128 * 3_000_000 / 256; !ok || offset != want {
+		t.Errorf("seekOffset(0.5): got (%d, %v), want (%d, true)", offset, ok, want)
+	}
+}
+
+func TestParseVBRIHeader(t *testing.T) {
+	frame := testMP3Frame(0, 0)
+	copy(frame[36:], "VBRI")
+	b := frame[40:]
+	binary.BigEndian.PutUint32(b[6:], 50_000) // bytes
+	binary.BigEndian.PutUint32(b[10:], 1200)  // frames
+	binary.BigEndian.PutUint16(b[14:], 4)     // entries
+	binary.BigEndian.PutUint16(b[16:], 2)     // scale
+	binary.BigEndian.PutUint16(b[18:], 2)     // entry size
+	binary.BigEndian.PutUint16(b[20:], 300)   // frames per entry
+	for i := range 4 {
+		binary.BigEndian.PutUint16(b[22+2*i:], 6000)
+	}
+
+	h, _ := parseMP3FrameHeader(frame)
+	vbr, ok := parseVBRHeader(frame, h)
+	if !ok {
+		t.Fatal("parseVBRHeader: VBRI header not found")
+	}
+	if vbr.frames != 1200 {
+		t.Errorf("frames: got %d, want 1200", vbr.frames)
+	}
+
+	if offset, _ := vbr.seekOffset(0.75); offset != 3*12000 {
+		t.Errorf("seekOffset(0.75): got %d, want %d", offset, 3*12000)
+	}
+}
+
+func TestMP3FrameIndexSkipsTagsAndResyncs(t *testing.T) {
+	tag := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20}
+	data := append(tag, make([]byte, 20)...)
+
+	var want []int
+	for i := range 6 {
+		if i == 3 {
+			data = append(data, 0x00, 0x12, 0x34) // garbage between frames
+		}
+		want = append(want, len(data))
+		data = append(data, testMP3Frame(0, 0)...)
+	}
+	data = append(data, []byte("TAG")...)
+
+	idx, ok := newMP3FrameIndex(data)
+	if !ok {
+		t.Fatal("newMP3FrameIndex: no frame found")
+	}
+
+	if !idx.extend(2) || len(idx.offsets) != 3 {
+		t.Fatalf("extend(2): indexed %d frames, want 3", len(idx.offsets))
+	}
+
+	if idx.extend(10) {
+		t.Error("extend(10): reported a frame past the end")
+	}
+	if !idx.complete {
+		t.Error("extend(10): index not marked complete")
+	}
+
+	if len(idx.offsets) != len(want) {
+		t.Fatalf("offsets: got %v, want %v", idx.offsets, want)
+	}
+	for i := range want {
+		if idx.offsets[i] != want[i] {
+			t.Errorf("offset %d: got %d, want %d", i, idx.offsets[i], want[i])
+		}
+	}
+}
+
+func TestDecodableFramesFollowsReservoir(t *testing.T) {
+	// Each frame carries 417-4-32 = 381 bytes of main data.
+	frames := []struct{ mdb, used int }{
+		{0, 381},   // fills nothing into the reservoir
+		{100, 200}, // needs 100 bytes the reservoir lacks
+		{100, 300}, // reservoir holds 381 bytes: decodes, leaves 181
+		{200, 100}, // needs 200 of 181: fails
+		{150, 100}, // reservoir capped at 381: decodes
+	}
+
+	var data []byte
+	for _, f := range frames {
+		data = append(data, testMP3Frame(f.mdb, f.used*8)...)
+	}
+
+	idx, _ := newMP3FrameIndex(data)
+	idx.extend(len(frames) - 1)
+
+	got := idx.decodableFrames(0, len(frames)-1)
+	want := []bool{true, false, true, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("frame %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Starting later begins with an empty reservoir again.
+	if got := idx.decodableFrames(2, 2); got[0] {
+		t.Error("frame 2 from an empty reservoir: got decodable")
+	}
+}
@@ -0,0 +1,343 @@
+package player
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+)
+
+// Output kinds accepted by NewOutput.
+const (
+	OutputSpeaker = "speaker" // the system sound card
+	OutputNull    = "null"    // discards audio in real time
+	OutputWAV     = "wav"     // writes the rendered audio to a WAV file
+)
+
+// Output is where the player sends its audio. Like the beep speaker, it
+// mixes the streamers given to Play; Lock and Unlock guard changes to
+// streamers that are playing.
+type Output interface {
+	// Init prepares the output for a sample rate, replacing any previous
+	// initialization. bufferSize is a latency hint in samples.
+	Init(sampleRate beep.SampleRate, bufferSize int) error
+	Play(s ...beep.Streamer)
+	Clear()
+	Lock()
+	Unlock()
+	Close()
+}
+
+// NewOutput creates the output selected by the audio_output setting. path
+// is the file written by the WAV output.
+func NewOutput(kind, path string) (Output, error) {
+	switch kind {
+	case "", OutputSpeaker:
+		return &speakerOutput{}, nil
+	case OutputNull:
+		return NewNullOutput(), nil
+	case OutputWAV:
+		if path == "" {
+			return nil, fmt.Errorf("the wav audio output needs a file path")
+		}
+		return NewWAVOutput(path), nil
+	default:
+		return nil, fmt.Errorf("unknown audio output %q", kind)
+	}
+}
+
+// speakerOutput plays through the sound card using the global beep speaker.
+type speakerOutput struct {
+	initialized bool
+}
+
+func (o *speakerOutput) Init(sampleRate beep.SampleRate, bufferSize int) error {
+	if o.initialized {
+		speaker.Close()
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := speaker.Init(sampleRate, bufferSize); err != nil {
+		o.initialized = false
+		return err
+	}
+
+	o.initialized = true
+
+	return nil
+}
+
+func (o *speakerOutput) Play(s ...beep.Streamer) { speaker.Play(s...) }
+func (o *speakerOutput) Clear()                  { speaker.Clear() }
+func (o *speakerOutput) Lock()                   { speaker.Lock() }
+func (o *speakerOutput) Unlock()                 { speaker.Unlock() }
+
+func (o *speakerOutput) Close() {
+	if o.initialized {
+		speaker.Close()
+		o.initialized = false
+	}
+}
+
+// clockTick is how often a clocked output pulls audio.
+const clockTick = 20 * time.Millisecond
+
+// clockedOutput mixes its streamers in real time without a sound card and
+// hands each rendered chunk to a sink. Without a clock it renders only when
+// render is called, which lets tests drive it sample by sample.
+type clockedOutput struct {
+	mu    sync.Mutex
+	mixer beep.Mixer
+	rate  beep.SampleRate
+	buf   [][2]float64
+	sink  sampleSink
+	clock bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// sampleSink receives rendered audio from a clockedOutput.
+type sampleSink interface {
+	open(sampleRate beep.SampleRate) error
+	write(samples [][2]float64) error
+	close() error
+}
+
+// NewNullOutput returns an output that consumes audio at the speed a sound
+// card would and discards it.
+func NewNullOutput() Output {
+	return &clockedOutput{sink: nullSink{}, clock: true}
+}
+
+// NewWAVOutput returns an output that consumes audio in real time and
+// writes it to a 16-bit stereo WAV file at path.
+func NewWAVOutput(path string) Output {
+	return &clockedOutput{sink: &wavSink{path: path}, clock: true}
+}
+
+func (o *clockedOutput) Init(sampleRate beep.SampleRate, _ int) error {
+	o.stopClock()
+
+	o.mu.Lock()
+	o.rate = sampleRate
+	o.buf = make([][2]float64, sampleRate.N(clockTick))
+	o.mu.Unlock()
+
+	if err := o.sink.open(sampleRate); err != nil {
+		return err
+	}
+
+	if o.clock {
+		o.stop = make(chan struct{})
+		o.done = make(chan struct{})
+		go o.run(o.stop, o.done)
+	}
+
+	return nil
+}
+
+// run renders audio as fast as it would play, catching up after delays.
+func (o *clockedOutput) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(clockTick)
+	defer ticker.Stop()
+
+	start := time.Now()
+	rendered := 0
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			due := o.rate.N(time.Since(start)) - rendered
+			for due > 0 {
+				n := o.render(due)
+				rendered += n
+				due -= n
+			}
+		}
+	}
+}
+
+// render mixes up to n samples, at most one chunk, passes them to the sink
+// and returns how many were rendered.
+func (o *clockedOutput) render(n int) int {
+	o.mu.Lock()
+	n = min(n, len(o.buf))
+	samples := o.buf[:n]
+	o.mixer.Stream(samples)
+	o.mu.Unlock()
+
+	if err := o.sink.write(samples); err != nil {
+		logger.Error("Audio output: %v", err)
+	}
+
+	return n
+}
+
+func (o *clockedOutput) stopClock() {
+	if o.stop == nil {
+		return
+	}
+
+	close(o.stop)
+	<-o.done
+	o.stop, o.done = nil, nil
+}
+
+func (o *clockedOutput) Play(s ...beep.Streamer) {
+	o.mu.Lock()
+	o.mixer.Add(s...)
+	o.mu.Unlock()
+}
+
+func (o *clockedOutput) Clear() {
+	o.mu.Lock()
+	o.mixer.Clear()
+	o.mu.Unlock()
+}
+
+func (o *clockedOutput) Lock()   { o.mu.Lock() }
+func (o *clockedOutput) Unlock() { o.mu.Unlock() }
+
+func (o *clockedOutput) Close() {
+	o.stopClock()
+
+	if err := o.sink.close(); err != nil {
+		logger.Error("Audio output: %v", err)
+	}
+}
+
+// nullSink discards audio.
+type nullSink struct{}
+
+func (nullSink) open(beep.SampleRate) error { return nil }
+func (nullSink) write([][2]float64) error   { return nil }
+func (nullSink) close() error               { return nil }
+
+// wavHeaderSize is the length of a canonical PCM WAV header.
+const wavHeaderSize = 44
+
+// wavSink writes 16-bit stereo PCM to a WAV file. The header sizes are
+// filled in when the file is closed.
+type wavSink struct {
+	path   string
+	rate   beep.SampleRate
+	file   *os.File
+	w      *bufio.Writer
+	frames int64
+	frame  [4]byte
+}
+
+func (s *wavSink) open(sampleRate beep.SampleRate) error {
+	if s.file != nil {
+		if sampleRate == s.rate {
+			return nil
+		}
+
+		// A WAV file has a single sample rate.
+		logger.Warn("WAV output: sample rate changed from %d to %d Hz, restarting %s", s.rate, sampleRate, s.path)
+
+		if err := s.close(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to create WAV output: %w", err)
+	}
+
+	s.file = file
+	s.w = bufio.NewWriterSize(file, 64*1024)
+	s.rate = sampleRate
+	s.frames = 0
+
+	return s.writeHeader()
+}
+
+func (s *wavSink) writeHeader() error {
+	const channels, bytesPerSample = 2, 2
+
+	dataSize := uint32(s.frames * channels * bytesPerSample)
+
+	var h [wavHeaderSize]byte
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+dataSize)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16) // fmt chunk size
+	binary.LittleEndian.PutUint16(h[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(h[22:], channels)
+	binary.LittleEndian.PutUint32(h[24:], uint32(s.rate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(s.rate)*channels*bytesPerSample)
+	binary.LittleEndian.PutUint16(h[32:], channels*bytesPerSample)
+	binary.LittleEndian.PutUint16(h[34:], 8*bytesPerSample)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], dataSize)
+
+	if _, err := s.file.WriteAt(h[:], 0); err != nil {
+		return fmt.Errorf("failed to write WAV header: %w", err)
+	}
+
+	if s.frames == 0 {
+		_, err := s.file.Seek(wavHeaderSize, 0)
+		return err
+	}
+
+	return nil
+}
+
+func (s *wavSink) write(samples [][2]float64) error {
+	if s.w == nil {
+		return nil
+	}
+
+	for _, sample := range samples {
+		binary.LittleEndian.PutUint16(s.frame[0:], uint16(toPCM16(sample[0])))
+		binary.LittleEndian.PutUint16(s.frame[2:], uint16(toPCM16(sample[1])))
+
+		if _, err := s.w.Write(s.frame[:]); err != nil {
+			return fmt.Errorf("failed to write WAV output: %w", err)
+		}
+	}
+
+	s.frames += int64(len(samples))
+
+	return nil
+}
+
+func (s *wavSink) close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.w.Flush()
+	if err == nil {
+		err = s.writeHeader()
+	}
+
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	s.file, s.w = nil, nil
+
+	return err
+}
+
+// toPCM16 converts a sample to 16-bit PCM, clipping it to [-1, 1].
+func toPCM16(v float64) int16 {
+	v = math.Max(-1, math.Min(1, v))
+	return int16(math.Round(v * 32767))
+}
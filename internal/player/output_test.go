@@ -0,0 +1,180 @@
+package player
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/wav"
+)
+
+// recordSink keeps everything rendered by a clockedOutput.
+type recordSink struct {
+	rate    beep.SampleRate
+	samples [][2]float64
+}
+
+func (s *recordSink) open(rate beep.SampleRate) error { s.rate = rate; return nil }
+func (s *recordSink) close() error                    { return nil }
+
+func (s *recordSink) write(samples [][2]float64) error {
+	s.samples = append(s.samples, samples...)
+	return nil
+}
+
+// newManualOutput returns an output that renders only when told to.
+func newManualOutput() (*clockedOutput, *recordSink) {
+	sink := &recordSink{}
+	return &clockedOutput{sink: sink}, sink
+}
+
+// renderSamples renders n samples from a manual output.
+func renderSamples(o *clockedOutput, n int) {
+	for n > 0 {
+		n -= o.render(n)
+	}
+}
+
+// writeTestWAV writes a one second stereo WAV file with a different
+// sawtooth on each channel.
+func writeTestWAV(t *testing.T, rate beep.SampleRate) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	sink := &wavSink{path: path}
+	if err := sink.open(rate); err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make([][2]float64, int(rate))
+	for i := range samples {
+		samples[i] = [2]float64{float64(i%200)/200 - 0.5, float64(i%73)/146 - 0.25}
+	}
+
+	if err := sink.write(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// decodeTestWAV returns every sample of a WAV file as the player decodes it.
+func decodeTestWAV(t *testing.T, path string) ([][2]float64, beep.Format) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, format, err := wav.Decode(f)
+	if err != nil {
+		t.Fatalf("wav.Decode: %v", err)
+	}
+
+	samples := make([][2]float64, s.Len())
+	n, _ := s.Stream(samples)
+
+	return samples[:n], format
+}
+
+func TestWAVSinkWritesPCM(t *testing.T) {
+	path := writeTestWAV(t, 22050)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data[0:4]) != "RIFF" || string(data[8:16]) != "WAVEfmt " || string(data[36:40]) != "data" {
+		t.Fatalf("header: got %q", data[:wavHeaderSize])
+	}
+	if rate := binary.LittleEndian.Uint32(data[24:]); rate != 22050 {
+		t.Errorf("sample rate: got %d, want 22050", rate)
+	}
+	if size := binary.LittleEndian.Uint32(data[40:]); size != 22050*4 || len(data) != wavHeaderSize+22050*4 {
+		t.Fatalf("data size: header says %d, file has %d", size, len(data)-wavHeaderSize)
+	}
+
+	pcm := data[wavHeaderSize:]
+	for i := range 22050 {
+		left := int16(binary.LittleEndian.Uint16(pcm[4*i:]))
+		right := int16(binary.LittleEndian.Uint16(pcm[4*i+2:]))
+
+		want := [2]float64{float64(i%200)/200 - 0.5, float64(i%73)/146 - 0.25}
+		if left != toPCM16(want[0]) || right != toPCM16(want[1]) {
+			t.Fatalf("sample %d: got [%d %d], want %v", i, left, right, want)
+		}
+	}
+}
+
+func TestClockedOutputMixesStreamers(t *testing.T) {
+	o, sink := newManualOutput()
+	if err := o.Init(44100, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Play(&constStreamer{value: 0.25, remaining: 100}, &constStreamer{value: 0.5, remaining: 50})
+	renderSamples(o, 150)
+
+	for i, got := range sink.samples {
+		want := 0.25
+		switch {
+		case i < 50:
+			want = 0.75
+		case i >= 100:
+			want = 0
+		}
+
+		if got[0] != want || got[1] != want {
+			t.Fatalf("sample %d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestPlayerRendersSourceThroughVolume(t *testing.T) {
+	path := writeTestWAV(t, 44100)
+	source, _ := decodeTestWAV(t, path)
+
+	for _, volume := range []float64{1, 0.5} {
+		o, sink := newManualOutput()
+
+		p, err := NewWithOutput(o, 44100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.SetVolume(volume); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.LoadFile(path); err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		if err := p.Play(); err != nil {
+			t.Fatal(err)
+		}
+
+		renderSamples(o, len(source))
+		p.Close()
+
+		db, _ := LinearToDecibel(volume)
+		gain := math.Pow(2, db) // effects.Volume with base 2
+
+		// The flat equalizer is transparent up to rounding, and the limiter
+		// only delays the audio by its lookahead.
+		delay := len(p.limiter.delay)
+		for i, got := range sink.samples[delay:] {
+			want := [2]float64{source[i][0] * gain, source[i][1] * gain}
+			if math.Abs(got[0]-want[0]) > 1e-9 || math.Abs(got[1]-want[1]) > 1e-9 {
+				t.Fatalf("volume %v, sample %d: got %v, want %v", volume, i, got, want)
+			}
+		}
+	}
+}
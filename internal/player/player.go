@@ -12,7 +12,6 @@ import (
 
 	"github.com/faiface/beep"
 	"github.com/faiface/beep/effects"
-	"github.com/faiface/beep/speaker"
 	"github.com/faiface/beep/wav"
 
 	"github.com/haryoiro/yutemal/internal/logger"
@@ -20,41 +19,76 @@ import (
 
 // Player represents the audio player.
 type Player struct {
-	mu                 sync.RWMutex
-	streamer           beep.StreamSeekCloser
-	bufferedStreamer   *BufferedStreamer
-	equalizer          *Equalizer
-	ctrl               *beep.Ctrl
-	volume             *effects.Volume
-	format             beep.Format
-	isPlaying          bool
-	currentFile        string
-	duration           time.Duration
-	ctx                context.Context
-	cancel             context.CancelFunc
-	speakerInitialized bool
-	currentSampleRate  beep.SampleRate
-	lastSeekTime       time.Time
-	seekCooldown       time.Duration
-	iseeking           bool
-	savedVolume        float64
-	savedVolumeSet     bool
-}
-
-// New creates a new audio player.
+	mu                sync.RWMutex
+	streamer          beep.StreamSeekCloser
+	bufferedStreamer  *BufferedStreamer
+	gapless           *GaplessStreamer
+	preloadTarget     string
+	pendingFadeIn     time.Duration
+	trackGain         float64
+	stretch           *TimeStretch
+	speed             float64
+	resampler         *Resampler    // nil when the source matches the output rate
+	channels          *ChannelMixer // mono, balance, crossfeed and karaoke in front of the equalizer
+	equalizer         *Equalizer
+	spectrum          *SpectrumAnalyzer // taps the output of the equalizer
+	limiter           *Limiter          // preamp and limiter after the volume
+	ctrl              *beep.Ctrl
+	volume            *effects.Volume
+	format            beep.Format
+	isPlaying         bool
+	currentFile       string
+	download          *PartialDownload // set while playing a file that is still downloading
+	duration          time.Duration
+	ctx               context.Context
+	cancel            context.CancelFunc
+	output            Output
+	outputInitialized bool
+	outputRate        beep.SampleRate
+	lastSeekTime      time.Time
+	seekCooldown      time.Duration
+	iseeking          bool
+	savedVolume       float64
+	savedVolumeSet    bool
+}
+
+// New creates a new audio player that plays through the sound card at the
+// default output rate.
 func New() (*Player, error) {
+	return NewWithOutput(&speakerOutput{}, DefaultOutputSampleRate)
+}
+
+// NewWithOutput creates a new audio player that sends its audio to output.
+// The output is initialized once at sampleRate and sources at other rates
+// are resampled; a zero rate adopts the rate of the first loaded file.
+func NewWithOutput(output Output, sampleRate int) (*Player, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// The equalizer exists before the first file so that a preset can be
+	// applied right away; setupVolume connects it to the source.
+	eqRate := sampleRate
+	if eqRate <= 0 {
+		eqRate = DefaultOutputSampleRate
+	}
+
 	player := &Player{
 		ctx:            ctx,
 		cancel:         cancel,
+		output:         output,
+		outputRate:     beep.SampleRate(max(sampleRate, 0)),
+		equalizer:      NewEqualizer(nil, float64(eqRate)),
 		seekCooldown:   500 * time.Millisecond,
 		iseeking:       false,
 		savedVolume:    0.7,
 		savedVolumeSet: false,
+		trackGain:      1,
+		speed:          1,
 	}
+	player.channels = NewChannelMixer(nil, float64(eqRate))
+	player.spectrum = NewSpectrumAnalyzer(player.equalizer, float64(eqRate))
+	player.limiter = NewLimiter(nil, float64(eqRate))
 
-	logger.Debug("Audio player created (speaker will be initialized on first file load)")
+	logger.Debug("Audio player created (output will be initialized on first file load)")
 
 	return player, nil
 }
@@ -84,7 +118,7 @@ func (p *Player) loadFileInternal(filepath string) error {
 	p.setupStreamer(streamer, format)
 	p.setupVolume()
 
-	err = p.setupSpeaker(format)
+	err = p.setupOutput(format)
 	if err != nil {
 		return err
 	}
@@ -95,14 +129,68 @@ func (p *Player) loadFileInternal(filepath string) error {
 	return nil
 }
 
+// LoadPartial loads a file that is still being downloaded. Playback waits
+// whenever it catches up with the download, and so does a seek past the
+// downloaded region. duration is the expected length of the track, which
+// the partial file cannot tell reliably.
+func (p *Player) LoadPartial(download *PartialDownload, duration time.Duration) error {
+	path := download.containerPath()
+	if !IsStreamable(path) {
+		return fmt.Errorf("progressive playback is not supported for %s", path)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cleanupStreamer()
+	p.initializeDefaults()
+
+	streamer, format, err := DecodeFFmpegPartial(download, duration)
+	if err != nil {
+		return err
+	}
+
+	p.setupStreamer(streamer, format)
+	p.setupVolume()
+
+	if err := p.setupOutput(format); err != nil {
+		return err
+	}
+
+	p.duration = format.SampleRate.D(streamer.Len())
+	p.download = download
+	p.currentFile = path
+
+	logger.Debug("Streaming partial download: %s, expected duration: %v, sample rate: %d Hz",
+		download.Path(), p.duration, format.SampleRate)
+
+	return nil
+}
+
+// IsStreamable reports whether a file in the given container can be played
+// while it is still downloading.
+func IsStreamable(path string) bool {
+	ext := strings.ToLower(path)
+	for _, suffix := range []string{".webm", ".opus", ".ogg", ".m4a", ".mp4"} {
+		if strings.HasSuffix(ext, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (p *Player) cleanupStreamer() {
 	// Step 1: Nil the duration callback to prevent deadlock.
 	// fillLoop -> source.Stream() -> handleEOF -> durationUpdateCallback
 	// tries to acquire p.mu, which LoadFile() already holds.
-	if p.streamer != nil {
-		if dec, ok := p.streamer.(*minimp3Decoder); ok {
-			dec.durationUpdateCallback = nil
-		}
+	// A decoder waiting on a partial download is woken instead, so that
+	// fillLoop can exit.
+	switch dec := p.streamer.(type) {
+	case *minimp3Decoder:
+		dec.durationUpdateCallback = nil
+	case *ffmpegDecoder:
+		dec.interrupt()
 	}
 
 	// Step 2: Drain the buffer — sets closed=true, empties buffer,
@@ -112,12 +200,21 @@ func (p *Player) cleanupStreamer() {
 		p.bufferedStreamer.Drain()
 	}
 
-	// Step 3: Clear all streamers from the speaker.
-	// speaker.Clear() internally acquires speaker mu, so do NOT wrap
-	// it in speaker.Lock()/Unlock() — that would deadlock on the
+	// Tracks held by the gapless streamer (a preloaded successor, or one
+	// taking part in a crossfade) are drained the same way.
+	var detached []*preloadedTrack
+	if p.gapless != nil {
+		detached = p.gapless.detach()
+	}
+
+	p.preloadTarget = ""
+
+	// Step 3: Clear all streamers from the output.
+	// Output.Clear() internally acquires the output's mutex, so do NOT
+	// wrap it in p.output.Lock()/Unlock() — that would deadlock on the
 	// non-reentrant mutex.
-	if p.speakerInitialized {
-		speaker.Clear()
+	if p.outputInitialized {
+		p.output.Clear()
 	}
 
 	// Step 4: Wait for fillLoop to exit and close resources.
@@ -130,6 +227,10 @@ func (p *Player) cleanupStreamer() {
 		p.streamer.Close()
 		p.streamer = nil
 	}
+
+	for _, t := range detached {
+		t.close()
+	}
 }
 
 func (p *Player) initializeDefaults() {
@@ -138,37 +239,62 @@ func (p *Player) initializeDefaults() {
 	p.ctrl = nil
 	p.volume = nil
 	p.bufferedStreamer = nil
+	p.gapless = nil
+	p.stretch = nil
+	p.resampler = nil
+	p.download = nil
 }
 
 func (p *Player) decodeAudioFile(file *os.File, filepath string) (beep.StreamSeekCloser, beep.Format, error) {
+	streamer, format, err := openDecoder(file, filepath)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	if minimp3Dec, ok := streamer.(*minimp3Decoder); ok {
+		minimp3Dec.durationUpdateCallback = p.UpdateActualDuration
+	}
+
+	return streamer, format, nil
+}
+
+// openDecoder picks a decoder by file extension. The file is closed on error.
+func openDecoder(file *os.File, filepath string) (beep.StreamSeekCloser, beep.Format, error) {
 	ext := strings.ToLower(filepath)
 
 	switch {
 	case strings.HasSuffix(ext, ".mp3"):
-		return p.decodeMp3(file)
+		return decodeMp3(file)
 	case strings.HasSuffix(ext, ".wav"):
-		return p.decodeWav(file)
+		return decodeWav(file)
+	case strings.HasSuffix(ext, ".flac"):
+		return DecodeFLAC(file)
+	case strings.HasSuffix(ext, ".ogg"), strings.HasSuffix(ext, ".oga"):
+		// Ogg is used for both Vorbis and Opus; only Vorbis is decoded natively.
+		if isOggOpus(file) {
+			return DecodeFFmpeg(file)
+		}
+		return DecodeVorbis(file)
+	case strings.HasSuffix(ext, ".webm"), strings.HasSuffix(ext, ".opus"),
+		strings.HasSuffix(ext, ".m4a"), strings.HasSuffix(ext, ".mp4"), strings.HasSuffix(ext, ".aac"):
+		return DecodeFFmpeg(file)
 	default:
 		file.Close()
 		return nil, beep.Format{}, fmt.Errorf("unsupported file format: %s", filepath)
 	}
 }
 
-func (p *Player) decodeMp3(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
+func decodeMp3(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
 	streamer, format, err := DecodeMiniMP3(file)
 	if err != nil {
 		file.Close()
 		return nil, beep.Format{}, fmt.Errorf("failed to decode MP3: %w", err)
 	}
 
-	if minimp3Dec, ok := streamer.(*minimp3Decoder); ok {
-		minimp3Dec.durationUpdateCallback = p.UpdateActualDuration
-	}
-
 	return streamer, format, nil
 }
 
-func (p *Player) decodeWav(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
+func decodeWav(file *os.File) (beep.StreamSeekCloser, beep.Format, error) {
 	streamer, format, err := wav.Decode(file)
 	if err != nil {
 		file.Close()
@@ -189,23 +315,49 @@ func (p *Player) setupVolume() {
 	volumeToApply := p.getVolumeToApply()
 	dbVolume, isSilent := p.calculateVolumeSettings(volumeToApply)
 
-	// Create or update equalizer
-	if p.equalizer == nil {
-		p.equalizer = NewEqualizer(p.bufferedStreamer, float64(p.format.SampleRate))
-	} else {
-		p.equalizer.streamer = p.bufferedStreamer
-		p.equalizer.UpdateSampleRate(float64(p.format.SampleRate))
+	p.gapless = NewGaplessStreamer(p.bufferedStreamer)
+	p.gapless.setGain(p.trackGain)
+	if p.pendingFadeIn > 0 {
+		p.gapless.fadeIn(p.format.SampleRate.N(p.pendingFadeIn))
+		p.pendingFadeIn = 0
+	}
+
+	p.stretch = NewTimeStretch(p.gapless, p.format.SampleRate)
+	p.stretch.setSpeed(p.speed)
+
+	if p.outputRate == 0 {
+		p.outputRate = p.format.SampleRate
+	}
+
+	// Everything from here on runs at the output rate
+	var source beep.Streamer = p.stretch
+	if p.format.SampleRate != p.outputRate {
+		p.resampler = NewResampler(p.stretch, p.format.SampleRate, p.outputRate)
+		source = p.resampler
 	}
 
+	p.channels.streamer = source
+	p.channels.setSampleRate(float64(p.outputRate))
+
+	p.equalizer.streamer = p.channels
+	p.equalizer.UpdateSampleRate(float64(p.outputRate))
+	p.spectrum.setSampleRate(float64(p.outputRate))
+
 	volume := &effects.Volume{
-		Streamer: p.equalizer,
+		Streamer: p.spectrum,
 		Base:     2,
 		Volume:   dbVolume,
 		Silent:   isSilent,
 	}
 
+	// Keep the result within full scale, counting clips per track
+	p.limiter.streamer = volume
+	p.limiter.setSampleRate(float64(p.outputRate))
+	p.limiter.reset()
+	p.limiter.ResetClipped()
+
 	ctrl := &beep.Ctrl{
-		Streamer: volume,
+		Streamer: p.limiter,
 		Paused:   true,
 	}
 
@@ -230,39 +382,59 @@ func (p *Player) calculateVolumeSettings(volumeToApply float64) (float64, bool)
 	return LinearToDecibel(volumeToApply)
 }
 
-func (p *Player) setupSpeaker(format beep.Format) error {
-	if !p.speakerInitialized || p.currentSampleRate != format.SampleRate {
-		if p.speakerInitialized {
-			speaker.Close()
-			time.Sleep(100 * time.Millisecond)
-		}
-
-		err := speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/2))
+// setupOutput initializes the output on first use and starts sending the
+// current track to it. The output keeps its rate for the life of the
+// player; re-initializing it between tracks would be audible.
+func (p *Player) setupOutput(format beep.Format) error {
+	if !p.outputInitialized {
+		err := p.output.Init(p.outputRate, p.outputRate.N(time.Second/2))
 		if err != nil {
-			return fmt.Errorf("failed to initialize speaker for sample rate %d: %w", format.SampleRate, err)
+			return fmt.Errorf("failed to initialize audio output for sample rate %d: %w", p.outputRate, err)
 		}
 
-		p.speakerInitialized = true
-		p.currentSampleRate = format.SampleRate
+		p.outputInitialized = true
+	}
+
+	if format.SampleRate != p.outputRate {
+		logger.Debug("Resampling from %d Hz to the output rate of %d Hz", format.SampleRate, p.outputRate)
 	}
 
-	speaker.Play(p.ctrl)
+	p.output.Play(p.ctrl)
 
 	return nil
 }
 
 func (p *Player) calculateDuration(filepath string, streamer beep.StreamSeekCloser) {
+	p.duration = p.probeDuration(filepath, streamer, p.format)
+}
+
+// probeDuration returns the duration of a decoded file. FLAC, Vorbis and
+// MP3 with a Xing/VBRI header report an exact length from their stream
+// headers; other files prefer ffprobe and fall back to the decoder's
+// length estimate.
+func (p *Player) probeDuration(filepath string, streamer beep.StreamSeekCloser, format beep.Format) time.Duration {
+	switch dec := streamer.(type) {
+	case *flacDecoder, *vorbisDecoder:
+		if streamer.Len() > 0 {
+			return format.SampleRate.D(streamer.Len())
+		}
+	case *minimp3Decoder:
+		if dec.lengthFromHeader {
+			return format.SampleRate.D(dec.TotalSamples)
+		}
+	}
+
 	actualDuration := p.getActualDuration(filepath)
 	if actualDuration > 0 {
-		p.duration = actualDuration
 		if minimp3Dec, ok := streamer.(*minimp3Decoder); ok {
-			actualSamples := p.format.SampleRate.N(actualDuration)
+			actualSamples := format.SampleRate.N(actualDuration)
 			minimp3Dec.TotalSamples = actualSamples
 		}
-	} else {
-		length := p.streamer.Len()
-		p.duration = p.format.SampleRate.D(length)
+
+		return actualDuration
 	}
+
+	return format.SampleRate.D(streamer.Len())
 }
 
 func (p *Player) logFileInfo(filepath string, format beep.Format) {
@@ -287,10 +459,10 @@ func (p *Player) Play() error {
 		return fmt.Errorf("no file loaded")
 	}
 
-	speaker.Lock()
+	p.output.Lock()
 	p.ctrl.Paused = false
 	p.isPlaying = true
-	speaker.Unlock()
+	p.output.Unlock()
 
 	return nil
 }
@@ -304,10 +476,10 @@ func (p *Player) Pause() error {
 		return fmt.Errorf("no file loaded")
 	}
 
-	speaker.Lock()
+	p.output.Lock()
 	p.ctrl.Paused = true
 	p.isPlaying = false
-	speaker.Unlock()
+	p.output.Unlock()
 
 	return nil
 }
@@ -330,11 +502,11 @@ func (p *Player) Stop() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.ctrl == nil || !p.speakerInitialized {
+	if p.ctrl == nil || !p.outputInitialized {
 		return nil
 	}
 
-	speaker.Clear()
+	p.output.Clear()
 
 	if p.streamer != nil {
 		if err := p.streamer.Seek(0); err != nil {
@@ -356,7 +528,7 @@ func (p *Player) SetVolume(volume float64) error {
 	p.savedVolume = volume
 	p.savedVolumeSet = true
 
-	if p.volume == nil || !p.speakerInitialized {
+	if p.volume == nil || !p.outputInitialized {
 		return nil
 	}
 
@@ -381,9 +553,9 @@ func (p *Player) SetVolume(volume float64) error {
 		}
 	}
 
-	speaker.Lock()
+	p.output.Lock()
 	p.volume.Volume = dbVolume
-	speaker.Unlock()
+	p.output.Unlock()
 
 	return nil
 }
@@ -436,10 +608,10 @@ func (p *Player) Seek(pos time.Duration) error {
 
 	wasPlaying := p.isPlaying
 	if wasPlaying && p.ctrl != nil {
-		speaker.Lock()
+		p.output.Lock()
 		p.ctrl.Paused = true
-		speaker.Unlock()
-		speaker.Clear()
+		p.output.Unlock()
+		p.output.Clear()
 	}
 
 	// Seek the buffered streamer if available
@@ -464,18 +636,30 @@ func (p *Player) Seek(pos time.Duration) error {
 		}
 	}
 
+	// Drop audio the time stretcher read ahead from the old position
+	if p.stretch != nil {
+		p.stretch.reset()
+	}
+
+	if p.resampler != nil {
+		p.resampler.reset()
+	}
+
 	// Reset EQ filter state to avoid transient pops from stale delay lines
 	if p.equalizer != nil {
 		p.equalizer.ResetState()
 	}
+	p.channels.reset()
+	p.spectrum.reset()
+	p.limiter.reset()
 
 	if wasPlaying && p.ctrl != nil {
 		// Give a tiny bit of time for buffer to fill before resuming
 		time.Sleep(100 * time.Millisecond)
-		speaker.Play(p.ctrl)
-		speaker.Lock()
+		p.output.Play(p.ctrl)
+		p.output.Lock()
 		p.ctrl.Paused = false
-		speaker.Unlock()
+		p.output.Unlock()
 	}
 
 	p.lastSeekTime = time.Now()
@@ -500,12 +684,23 @@ func (p *Player) SeekBackward(duration time.Duration) error {
 	return p.Seek(newPos)
 }
 
+// GetPosition returns the playback position in source time, so that it is
+// unaffected by the playback speed.
 func (p *Player) GetPosition() time.Duration {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
 	if p.bufferedStreamer != nil {
 		streamPos := p.bufferedStreamer.Position()
+		if p.stretch != nil {
+			streamPos = max(streamPos-p.stretch.latency(), 0)
+		}
+		if p.resampler != nil {
+			// The resampler holds stretched samples, each covering speed
+			// source samples.
+			streamPos = max(streamPos-int(float64(p.resampler.latency())*p.speed), 0)
+		}
+
 		return p.format.SampleRate.D(streamPos)
 	} else if p.streamer != nil {
 		streamPos := p.streamer.Position()
@@ -524,6 +719,12 @@ func (p *Player) HasEnded() bool {
 		return false
 	}
 
+	// A preloaded track will take over at the end; that is a hand-off,
+	// not an end of playback.
+	if p.gapless != nil && p.gapless.hasNext() {
+		return false
+	}
+
 	var currentPos, totalLen int
 	if p.bufferedStreamer != nil {
 		currentPos = p.bufferedStreamer.Position()
@@ -539,7 +740,7 @@ func (p *Player) HasEnded() bool {
 	return hasEnded
 }
 
-// GetDuration returns the total duration.
+// GetDuration returns the total duration in source time.
 func (p *Player) GetDuration() time.Duration {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -547,6 +748,27 @@ func (p *Player) GetDuration() time.Duration {
 	return p.duration
 }
 
+// SetSpeed sets the playback speed, clamped to [MinSpeed, MaxSpeed]. Pitch
+// is preserved.
+func (p *Player) SetSpeed(speed float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.speed = math.Max(MinSpeed, math.Min(MaxSpeed, speed))
+
+	if p.stretch != nil {
+		p.stretch.setSpeed(p.speed)
+	}
+}
+
+// GetSpeed returns the playback speed.
+func (p *Player) GetSpeed() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.speed
+}
+
 // IsPlaying returns whether the player is currently playing.
 func (p *Player) IsPlaying() bool {
 	p.mu.RLock()
@@ -563,6 +785,28 @@ func (p *Player) GetCurrentFile() string {
 	return p.currentFile
 }
 
+// Buffered returns the fraction of the current track that has been
+// downloaded, which is 1 unless it is playing from a partial download.
+func (p *Player) Buffered() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.download == nil {
+		return 1
+	}
+
+	return p.download.Buffered()
+}
+
+// IsStreaming reports whether the current track was loaded from a partial
+// download. It keeps playing from it after the download completes.
+func (p *Player) IsStreaming() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.download != nil
+}
+
 // GetRawPosition returns the current sample position.
 func (p *Player) GetRawPosition() int {
 	p.mu.RLock()
@@ -591,7 +835,7 @@ func (p *Player) GetRawLength() int {
 	return 0
 }
 
-// GetSampleRate returns the current sample rate.
+// GetSampleRate returns the sample rate of the current track.
 func (p *Player) GetSampleRate() int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -642,15 +886,225 @@ func (p *Player) Close() error {
 		p.bufferedStreamer = nil
 	}
 
-	if p.speakerInitialized {
-		speaker.Close()
+	if p.gapless != nil {
+		for _, t := range p.gapless.detach() {
+			t.close()
+		}
+	}
+
+	if p.outputInitialized {
+		p.output.Close()
 
-		p.speakerInitialized = false
+		p.outputInitialized = false
 	}
 
 	return nil
 }
 
+// PreloadOptions controls how a preloaded track takes over playback.
+type PreloadOptions struct {
+	Crossfade time.Duration // overlap with the end of the current track; 0 is gapless
+	GainDB    float64       // loudness normalization gain for the track
+}
+
+// Preload decodes the given file in the background so that playback can
+// continue into it without a gap, or with a crossfade, when the current
+// track ends. A later call with a different file, or loading a new file,
+// supersedes the request.
+func (p *Player) Preload(filepath string, opts PreloadOptions) {
+	p.mu.Lock()
+	if p.preloadTarget == filepath {
+		p.mu.Unlock()
+		return
+	}
+
+	p.preloadTarget = filepath
+
+	if p.gapless != nil {
+		if prev := p.gapless.setNext(nil); prev != nil {
+			prev.close()
+		}
+	}
+	p.mu.Unlock()
+
+	if filepath == "" {
+		return
+	}
+
+	go p.decodePreload(filepath, opts)
+}
+
+// PreloadTarget returns the file most recently requested via Preload.
+func (p *Player) PreloadTarget() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.preloadTarget
+}
+
+// decodePreload decodes a file into a second BufferedStreamer and installs
+// it as the gapless successor of the current track. A file at another
+// sample rate is resampled to the rate of the current track, which the
+// rest of the chain keeps running at.
+func (p *Player) decodePreload(filepath string, opts PreloadOptions) {
+	p.mu.RLock()
+	chainRate := p.format.SampleRate
+	p.mu.RUnlock()
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		logger.Debug("Preload: failed to open %s: %v", filepath, err)
+		return
+	}
+
+	streamer, format, err := p.decodeAudioFile(file, filepath)
+	if err != nil {
+		logger.Debug("Preload: failed to decode %s: %v", filepath, err)
+		return
+	}
+
+	// The duration callback belongs to the current track until hand-off.
+	if minimp3Dec, ok := streamer.(*minimp3Decoder); ok {
+		minimp3Dec.durationUpdateCallback = nil
+	}
+
+	duration := p.probeDuration(filepath, streamer, format)
+
+	if format.SampleRate != chainRate {
+		logger.Debug("Preload: resampling %s from %d Hz to %d Hz", filepath, format.SampleRate, chainRate)
+
+		streamer = newResampledSource(streamer, format.SampleRate, chainRate)
+		format.SampleRate = chainRate
+	}
+
+	next := &preloadedTrack{
+		file:      filepath,
+		streamer:  streamer,
+		format:    format,
+		duration:  duration,
+		crossfade: format.SampleRate.N(opts.Crossfade),
+		gain:      decibelsToGain(opts.GainDB),
+	}
+
+	// Fill the buffer before installing the track, so that the hand-off
+	// never waits for the disk while the gapless streamer is locked.
+	next.buffered = NewBufferedStreamer(streamer, format, 4.0)
+	next.buffered.WaitFilled()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.preloadTarget != filepath || p.gapless == nil {
+		next.close()
+		return
+	}
+
+	// A new file was loaded at another rate while this one was decoding
+	if format.SampleRate != p.format.SampleRate {
+		next.close()
+		return
+	}
+
+	p.gapless.setNext(next)
+
+	logger.Debug("Preloaded next track for gapless playback: %s (%v)", filepath, next.duration)
+}
+
+// TakeHandoff reports whether playback has continued into the preloaded
+// track since the last call. If so, the preloaded track becomes the current
+// one and its file path is returned.
+func (p *Player) TakeHandoff() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.gapless == nil {
+		return "", false
+	}
+
+	next := p.gapless.takeHandedOff()
+	if next == nil {
+		return "", false
+	}
+
+	// The outgoing track may still be fading out, so the gapless streamer
+	// decides when its decoder and buffer can be closed.
+	if dec, ok := p.streamer.(*minimp3Decoder); ok {
+		dec.durationUpdateCallback = nil
+	}
+
+	p.gapless.retire(&preloadedTrack{
+		file:     p.currentFile,
+		streamer: p.streamer,
+		buffered: p.bufferedStreamer,
+	})
+
+	p.streamer = next.streamer
+	p.bufferedStreamer = next.buffered
+	p.format = next.format
+	p.duration = next.duration
+	p.currentFile = next.file
+	p.trackGain = next.gain
+	p.preloadTarget = ""
+	p.limiter.ResetClipped()
+
+	if minimp3Dec, ok := next.streamer.(*minimp3Decoder); ok {
+		minimp3Dec.durationUpdateCallback = p.UpdateActualDuration
+	}
+
+	logger.Debug("Gapless hand-off to %s, duration: %v", next.file, next.duration)
+
+	return next.file, true
+}
+
+// SetTrackGain sets the loudness normalization gain in dB, applied on top
+// of the user volume. It takes effect immediately and carries over to
+// files loaded afterwards, so it is best set before LoadFile.
+func (p *Player) SetTrackGain(gainDB float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.trackGain = decibelsToGain(gainDB)
+
+	if p.gapless != nil {
+		p.gapless.setGain(p.trackGain)
+	}
+}
+
+// FadeOut starts fading the current track out over d and returns a channel
+// that is closed once the fade has been rendered, after which a LoadFile
+// no longer cuts off audible audio. The next loaded file fades in over the
+// same duration; CancelFade undoes the fade if none is loaded. It returns
+// nil while paused.
+func (p *Player) FadeOut(d time.Duration) <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.gapless == nil || !p.isPlaying || d <= 0 {
+		return nil
+	}
+
+	p.pendingFadeIn = d
+
+	return p.gapless.fadeOut(p.format.SampleRate.N(d))
+}
+
+// CancelFade brings back a track faded out by FadeOut that no other file
+// has replaced.
+func (p *Player) CancelFade() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pendingFadeIn == 0 {
+		return
+	}
+
+	p.pendingFadeIn = 0
+
+	if p.gapless != nil {
+		p.gapless.clearFade()
+	}
+}
+
 // VolumeUp increases volume by 5%.
 func (p *Player) VolumeUp() error {
 	currentVol := p.GetVolume()
@@ -682,7 +1136,80 @@ func (p *Player) SetEQBandGain(band int, gainDB float64) {
 	}
 }
 
-// SetEQGains sets all EQ band gains at once.
+// SetEQPreset replaces the EQ bands and preamp with those of a validated
+// preset.
+func (p *Player) SetEQPreset(preset EQPreset) {
+	if p.equalizer != nil {
+		p.equalizer.SetPreset(preset)
+	}
+}
+
+// GetEQBands returns the current EQ bands.
+func (p *Player) GetEQBands() []EQBand {
+	if p.equalizer != nil {
+		return p.equalizer.Bands()
+	}
+	return nil
+}
+
+// GetEQPreamp returns the EQ preamp in dB.
+func (p *Player) GetEQPreamp() float64 {
+	if p.equalizer != nil {
+		return p.equalizer.Preamp()
+	}
+	return 0
+}
+
+// Spectrum fills bars with the current output spectrum, see
+// SpectrumAnalyzer.Spectrum. It returns false without computing anything
+// while nothing is playing.
+func (p *Player) Spectrum(bars []float64) bool {
+	if !p.IsPlaying() {
+		return false
+	}
+
+	p.spectrum.Spectrum(bars)
+
+	return true
+}
+
+// SetChannelSettings switches the channel tools.
+func (p *Player) SetChannelSettings(settings ChannelSettings) {
+	p.channels.SetSettings(settings)
+}
+
+// GetChannelSettings returns the channel tool settings.
+func (p *Player) GetChannelSettings() ChannelSettings {
+	return p.channels.Settings()
+}
+
+// SetPreamp sets the gain applied in front of the limiter, in dB.
+func (p *Player) SetPreamp(db float64) {
+	p.limiter.SetPreamp(db)
+}
+
+// GetPreamp returns the preamp in front of the limiter, in dB.
+func (p *Player) GetPreamp() float64 {
+	return p.limiter.Preamp()
+}
+
+// SetLimiterEnabled enables or disables the output limiter.
+func (p *Player) SetLimiterEnabled(enabled bool) {
+	p.limiter.SetEnabled(enabled)
+}
+
+// IsLimiterEnabled returns whether the output limiter is active.
+func (p *Player) IsLimiterEnabled() bool {
+	return p.limiter.IsEnabled()
+}
+
+// ClippedSamples returns how many samples of the current track went over
+// full scale before the limiter, or at the output if it is disabled.
+func (p *Player) ClippedSamples() int {
+	return p.limiter.Clipped()
+}
+
+// SetEQGains switches to the 10-band graphic EQ with the given gains.
 func (p *Player) SetEQGains(gains [NumBands]float64) {
 	if p.equalizer != nil {
 		p.equalizer.SetAllGains(gains)
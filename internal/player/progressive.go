@@ -0,0 +1,169 @@
+package player
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// PartialSuffix is the extension yt-dlp gives a file while it is still
+// being downloaded.
+const PartialSuffix = ".part"
+
+var errReaderClosed = errors.New("progressive reader closed")
+
+// PartialDownload tracks a file that a downloader is still writing, so that
+// playback can start before the download completes. The downloader reports
+// progress; readers block on data that has not arrived yet.
+type PartialDownload struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	path    string
+	written int64
+	total   int64 // expected final size, 0 if unknown
+	done    bool
+	err     error
+}
+
+// NewPartialDownload starts tracking the file at path.
+func NewPartialDownload(path string) *PartialDownload {
+	d := &PartialDownload{path: path}
+	d.cond = sync.NewCond(&d.mu)
+
+	return d
+}
+
+// Path returns the file being written.
+func (d *PartialDownload) Path() string {
+	return d.path
+}
+
+// Progress records that written bytes of an expected total (0 if unknown)
+// are on disk.
+func (d *PartialDownload) Progress(written, total int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.written = max(d.written, written)
+	if total > 0 {
+		d.total = total
+	}
+
+	d.cond.Broadcast()
+}
+
+// Finish marks the download as ended. err is nil when the file is complete.
+func (d *PartialDownload) Finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.done = true
+	d.err = err
+
+	if err == nil {
+		d.total = d.written
+	}
+
+	d.cond.Broadcast()
+}
+
+// Written returns the number of bytes on disk.
+func (d *PartialDownload) Written() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.written
+}
+
+// Done reports whether the download has ended, successfully or not.
+func (d *PartialDownload) Done() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.done
+}
+
+// Buffered returns the fraction of the file downloaded so far, or 1 once
+// the download is complete.
+func (d *PartialDownload) Buffered() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case d.done && d.err == nil:
+		return 1
+	case d.total <= 0:
+		return 0
+	default:
+		return min(float64(d.written)/float64(d.total), 1)
+	}
+}
+
+// containerPath returns the path the file will have once complete, which
+// names its container format.
+func (d *PartialDownload) containerPath() string {
+	return strings.TrimSuffix(d.path, PartialSuffix)
+}
+
+// progressiveReader reads a partial download from the start, waiting for
+// data that has not been written yet. Readers share the download's file,
+// which stays readable when yt-dlp renames it on completion.
+type progressiveReader struct {
+	d      *PartialDownload
+	file   *os.File
+	offset int64
+	closed bool // guarded by d.mu
+}
+
+func (d *PartialDownload) newReader(file *os.File) *progressiveReader {
+	return &progressiveReader{d: d, file: file}
+}
+
+// Read implements io.Reader. It blocks until at least one byte past the
+// current offset is on disk or the download has ended.
+func (r *progressiveReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	d := r.d
+
+	d.mu.Lock()
+	for !r.closed && !d.done && d.written <= r.offset {
+		d.cond.Wait()
+	}
+
+	closed, done, err, written := r.closed, d.done, d.err, d.written
+	d.mu.Unlock()
+
+	switch {
+	case closed:
+		return 0, errReaderClosed
+	case done && err != nil && written <= r.offset:
+		return 0, err
+	case !done:
+		p = p[:min(int64(len(p)), written-r.offset)]
+	}
+
+	n, readErr := r.file.ReadAt(p, r.offset)
+	r.offset += int64(n)
+
+	if readErr == io.EOF && n > 0 {
+		readErr = nil
+	}
+
+	return n, readErr
+}
+
+// Close wakes a blocked Read and makes further reads fail. The shared file
+// is left open.
+func (r *progressiveReader) Close() error {
+	r.d.mu.Lock()
+	r.closed = true
+	r.d.cond.Broadcast()
+	r.d.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package player
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestPartial writes data to a file and opens a reader over it as a
+// download that has not reported any progress yet.
+func openTestPartial(t *testing.T, data []byte) (*PartialDownload, *progressiveReader) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "track.webm"+PartialSuffix)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	d := NewPartialDownload(path)
+
+	return d, d.newReader(file)
+}
+
+// readAsync starts a Read and returns a channel with its result.
+func readAsync(r *progressiveReader, size int) chan error {
+	result := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, size))
+		result <- err
+	}()
+
+	return result
+}
+
+func TestProgressiveReaderWaitsForData(t *testing.T) {
+	d, r := openTestPartial(t, []byte("0123456789"))
+
+	result := readAsync(r, 4)
+	select {
+	case <-result:
+		t.Fatal("Read returned before any data was reported")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.Progress(6, 10)
+	if err := <-result; err != nil {
+		t.Fatalf("Read: unexpected error: %v", err)
+	}
+
+	// Only the reported bytes are returned, even though more are on disk.
+	buf := make([]byte, 8)
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "45" {
+		t.Errorf("Read: got %q, %v, want \"45\"", buf[:n], err)
+	}
+
+	if got := d.Buffered(); got != 0.6 {
+		t.Errorf("Buffered: got %v, want 0.6", got)
+	}
+}
+
+func TestProgressiveReaderReadsToEndAfterFinish(t *testing.T) {
+	d, r := openTestPartial(t, []byte("0123456789"))
+
+	d.Progress(4, 0)
+	d.Finish(nil)
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("ReadAll: got %q, %v", data, err)
+	}
+
+	if got := d.Buffered(); got != 1 {
+		t.Errorf("Buffered: got %v, want 1", got)
+	}
+}
+
+func TestProgressiveReaderFailedDownload(t *testing.T) {
+	d, r := openTestPartial(t, []byte("0123456789"))
+	d.Progress(3, 10)
+
+	failure := errors.New("network down")
+	result := readAsync(r, 16)
+	<-result // the first three bytes
+
+	result = readAsync(r, 16)
+	d.Finish(failure)
+
+	if err := <-result; !errors.Is(err, failure) {
+		t.Errorf("Read: got error %v, want %v", err, failure)
+	}
+}
+
+func TestProgressiveReaderCloseWakesRead(t *testing.T) {
+	_, r := openTestPartial(t, []byte("0123456789"))
+
+	result := readAsync(r, 4)
+	r.Close()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, errReaderClosed) {
+			t.Errorf("Read: got error %v, want %v", err, errReaderClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake the blocked Read")
+	}
+}
@@ -0,0 +1,251 @@
+package player
+
+import (
+	"math"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// Resampler parameters: the number of sinc zero crossings on each side of
+// the kernel, how finely the kernel is tabulated between source samples,
+// the Kaiser window shape, and the passband kept below the lower Nyquist
+// frequency to leave room for the filter's transition band.
+const (
+	resamplerZeroCrossings = 64
+	resamplerPhases        = 256
+	resamplerKaiserBeta    = 8.6
+	resamplerPassband      = 0.95
+	resamplerChunk         = 1024
+)
+
+// DefaultOutputSampleRate is the output rate used when none is configured.
+const DefaultOutputSampleRate = 48000
+
+// Resampler converts a stream to another sample rate by band-limited
+// interpolation with a Kaiser-windowed sinc kernel. When downsampling the
+// kernel is widened so that it also filters out everything above the new
+// Nyquist frequency, which would otherwise alias.
+type Resampler struct {
+	mu       sync.Mutex
+	streamer beep.Streamer
+	ratio    float64 // source samples per output sample
+
+	width  int       // kernel half width in source samples
+	kernel []float64 // one side of the kernel, resamplerPhases per sample
+
+	in  [][2]float64 // source history; in[width] is the first source sample
+	pos float64      // position of the next output sample in in
+	eof bool
+	buf [][2]float64
+}
+
+// NewResampler converts streamer from the from rate to the to rate.
+func NewResampler(streamer beep.Streamer, from, to beep.SampleRate) *Resampler {
+	ratio := float64(from) / float64(to)
+	cutoff := resamplerPassband * math.Min(1, 1/ratio)
+	width := int(math.Ceil(resamplerZeroCrossings / cutoff))
+
+	kernel := make([]float64, width*resamplerPhases+2)
+	for i := range kernel {
+		x := float64(i) / resamplerPhases
+		kernel[i] = cutoff * sinc(cutoff*x) * kaiser(x/float64(width), resamplerKaiserBeta)
+	}
+
+	r := &Resampler{
+		streamer: streamer,
+		ratio:    ratio,
+		width:    width,
+		kernel:   kernel,
+		buf:      make([][2]float64, resamplerChunk),
+	}
+	r.resetLocked()
+
+	return r
+}
+
+// Stream implements beep.Streamer.
+func (r *Resampler) Stream(samples [][2]float64) (n int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n < len(samples) {
+		// The kernel reaches width samples past the output position.
+		need := int(r.pos) + r.width + 1
+		for len(r.in) < need && !r.eof {
+			r.fill()
+		}
+
+		if r.eof && r.pos >= float64(len(r.in)) {
+			break
+		}
+
+		samples[n] = r.interpolate(r.pos)
+		n++
+		r.pos += r.ratio
+	}
+
+	r.compact()
+
+	return n, n > 0
+}
+
+// Err implements beep.Streamer.
+func (r *Resampler) Err() error {
+	return r.streamer.Err()
+}
+
+// fill appends the next chunk of the source to the history.
+func (r *Resampler) fill() {
+	n, ok := r.streamer.Stream(r.buf)
+	r.in = append(r.in, r.buf[:n]...)
+
+	if !ok {
+		r.eof = true
+	}
+}
+
+// interpolate evaluates the band-limited signal at position t in the
+// history. Samples past the end of the source count as silence.
+func (r *Resampler) interpolate(t float64) [2]float64 {
+	base := int(t)
+	first := max(base-r.width+1, 0)
+	last := min(base+r.width, len(r.in)-1)
+
+	var out [2]float64
+	for j := first; j <= last; j++ {
+		w := r.weight(math.Abs(t - float64(j)))
+		out[0] += r.in[j][0] * w
+		out[1] += r.in[j][1] * w
+	}
+
+	return out
+}
+
+// weight looks up the kernel at distance x, interpolating between phases.
+func (r *Resampler) weight(x float64) float64 {
+	p := x * resamplerPhases
+	i := int(p)
+	if i+1 >= len(r.kernel) {
+		return 0
+	}
+
+	frac := p - float64(i)
+
+	return r.kernel[i] + frac*(r.kernel[i+1]-r.kernel[i])
+}
+
+// compact drops history the kernel can no longer reach.
+func (r *Resampler) compact() {
+	drop := int(r.pos) - r.width
+	if drop < len(r.in)/2 || drop <= 0 {
+		return
+	}
+
+	r.in = append(r.in[:0], r.in[drop:]...)
+	r.pos -= float64(drop)
+}
+
+// reset discards all buffered state, e.g. after a seek.
+func (r *Resampler) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resetLocked()
+}
+
+// resetLocked starts over with silence before the first source sample.
+func (r *Resampler) resetLocked() {
+	r.in = r.in[:0]
+	for range r.width {
+		r.in = append(r.in, [2]float64{})
+	}
+
+	r.pos = float64(r.width)
+	r.eof = false
+}
+
+// latency returns how many samples have been read from the source but not
+// yet resampled.
+func (r *Resampler) latency() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return max(int(float64(len(r.in))-r.pos), 0)
+}
+
+// resampledSource is a seekable source converted to another sample rate,
+// with its length and positions counted at the new rate. It lets a track
+// join the gapless chain of a track with a different rate.
+type resampledSource struct {
+	*Resampler
+	source   beep.StreamSeekCloser
+	from, to beep.SampleRate
+}
+
+func newResampledSource(source beep.StreamSeekCloser, from, to beep.SampleRate) *resampledSource {
+	return &resampledSource{
+		Resampler: NewResampler(source, from, to),
+		source:    source,
+		from:      from,
+		to:        to,
+	}
+}
+
+// Len implements beep.StreamSeeker.
+func (r *resampledSource) Len() int {
+	return r.to.N(r.from.D(r.source.Len()))
+}
+
+// Position implements beep.StreamSeeker.
+func (r *resampledSource) Position() int {
+	return r.to.N(r.from.D(max(r.source.Position()-r.latency(), 0)))
+}
+
+// Seek implements beep.StreamSeeker.
+func (r *resampledSource) Seek(p int) error {
+	if err := r.source.Seek(r.from.N(r.to.D(p))); err != nil {
+		return err
+	}
+
+	r.reset()
+
+	return nil
+}
+
+// Close implements beep.StreamSeekCloser.
+func (r *resampledSource) Close() error {
+	return r.source.Close()
+}
+
+// sinc is the normalized sinc function.
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser is the Kaiser window at x in [-1, 1].
+func kaiser(x, beta float64) float64 {
+	if x < -1 || x > 1 {
+		return 0
+	}
+
+	return besselI0(beta*math.Sqrt(1-x*x)) / besselI0(beta)
+}
+
+// besselI0 is the modified Bessel function of the first kind of order zero.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,155 @@
+package player
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// resampleAll resamples a finite source until it ends.
+func resampleAll(r *Resampler) [][2]float64 {
+	var out [][2]float64
+
+	buf := make([][2]float64, 500)
+	for {
+		n, ok := r.Stream(buf)
+		out = append(out, buf[:n]...)
+		if !ok {
+			return out
+		}
+	}
+}
+
+func TestResamplerLength(t *testing.T) {
+	src := &sineStreamer{freq: 440, rate: 44100, limit: 44100}
+	out := resampleAll(NewResampler(src, 44100, 48000))
+
+	if len(out) < 47999 || len(out) > 48001 {
+		t.Errorf("len: got %d, want 48000", len(out))
+	}
+}
+
+func TestResamplerPreservesTone(t *testing.T) {
+	src := &sineStreamer{freq: 1000, rate: 44100, limit: 44100}
+	out := resampleAll(NewResampler(src, 44100, 48000))
+
+	// Away from the edges, where the kernel runs into silence, the output
+	// is the same sine sampled at the new rate.
+	worst := 0.0
+	for i := 1000; i < 47000; i++ {
+		want := 0.5 * math.Sin(2*math.Pi*1000*float64(i)/48000)
+		worst = math.Max(worst, math.Abs(out[i][0]-want))
+	}
+
+	if worst > 1e-3 {
+		t.Errorf("max error: got %v, want <= 1e-3", worst)
+	}
+}
+
+func TestResamplerFiltersAboveNyquist(t *testing.T) {
+	// 23 kHz fits in 48 kHz but not in 44.1 kHz, where it would alias to
+	// 21.1 kHz.
+	src := &sineStreamer{freq: 23000, rate: 48000, limit: 48000}
+	out := resampleAll(NewResampler(src, 48000, 44100))
+
+	sum := 0.0
+	for _, s := range out[1000:43000] {
+		sum += s[0] * s[0]
+	}
+	rms := math.Sqrt(sum / 42000)
+
+	if rms > 0.01 {
+		t.Errorf("rms: got %v, want <= 0.01", rms)
+	}
+}
+
+func TestResamplerResetRestartsHistory(t *testing.T) {
+	src := &sineStreamer{freq: 440, rate: 44100}
+	r := NewResampler(src, 44100, 48000)
+
+	r.Stream(make([][2]float64, 1000))
+	if got := r.latency(); got <= 0 {
+		t.Errorf("latency before reset: got %d, want > 0", got)
+	}
+
+	r.reset()
+	if got := r.latency(); got != 0 {
+		t.Errorf("latency after reset: got %d, want 0", got)
+	}
+}
+
+func TestPlayerKeepsOutputRate(t *testing.T) {
+	o, sink := newManualOutput()
+
+	p, err := NewWithOutput(o, 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.LoadFile(writeTestWAV(t, 22050)); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if err := p.Play(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sink.rate != 44100 {
+		t.Errorf("output rate: got %d, want 44100", sink.rate)
+	}
+	if got := p.GetSampleRate(); got != 22050 {
+		t.Errorf("track rate: got %d, want 22050", got)
+	}
+
+	// One second of source fills one second of output.
+	renderSamples(o, 44100)
+	if pos := p.GetPosition(); pos < 900*time.Millisecond || pos > time.Second {
+		t.Errorf("position after 1s of output: got %v", pos)
+	}
+}
+
+// sineSeeker is a seekable sineStreamer.
+type sineSeeker struct {
+	sineStreamer
+}
+
+func (s *sineSeeker) Len() int      { return s.limit }
+func (s *sineSeeker) Position() int { return s.consumed }
+func (s *sineSeeker) Close() error  { return nil }
+func (s *sineSeeker) Seek(p int) error {
+	s.consumed = p
+	return nil
+}
+
+func TestResampledSourceCountsAtNewRate(t *testing.T) {
+	src := &sineSeeker{sineStreamer{freq: 1000, rate: 44100, limit: 44100}}
+	r := newResampledSource(src, 44100, 48000)
+
+	if r.Len() != 48000 {
+		t.Errorf("Len: got %d, want 48000", r.Len())
+	}
+
+	if err := r.Seek(24000); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if src.consumed != 22050 {
+		t.Errorf("source position after Seek: got %d, want 22050", src.consumed)
+	}
+	if r.Position() != 24000 {
+		t.Errorf("Position after Seek: got %d, want 24000", r.Position())
+	}
+
+	out := make([][2]float64, 1000)
+	if n, _ := r.Stream(out); n != len(out) {
+		t.Fatalf("Stream: got %d samples, want %d", n, len(out))
+	}
+
+	// Past the kernel's reach into the silence before the seek target, the
+	// tone carries on from half a second in.
+	for i := 200; i < len(out); i++ {
+		want := 0.5 * math.Sin(2*math.Pi*1000*float64(24000+i)/48000)
+		if math.Abs(out[i][0]-want) > 1e-3 {
+			t.Fatalf("sample %d: got %v, want %v", i, out[i][0], want)
+		}
+	}
+}
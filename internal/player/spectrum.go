@@ -0,0 +1,166 @@
+package player
+
+import (
+	"math"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// Spectrum analyzer parameters: the FFT length, the frequency range shown
+// and the level shown as an empty bar.
+const (
+	spectrumSize    = 2048
+	spectrumMinFreq = 30.0
+	spectrumMaxFreq = 16000.0
+	spectrumFloorDB = -70.0
+)
+
+// SpectrumAnalyzer passes audio through unchanged while keeping the most
+// recent samples in a ring. The audio thread only copies into the ring;
+// the FFT runs when Spectrum is called, from whichever goroutine wants to
+// draw it, and never allocates.
+type SpectrumAnalyzer struct {
+	mu         sync.Mutex
+	streamer   beep.Streamer
+	ring       [spectrumSize]float64 // mono mix
+	pos        int
+	sampleRate float64
+
+	calc     sync.Mutex // guards the buffers below
+	window   [spectrumSize]float64
+	cos, sin [spectrumSize / 2]float64
+	re, im   [spectrumSize]float64
+}
+
+// NewSpectrumAnalyzer taps streamer, which plays at sampleRate.
+func NewSpectrumAnalyzer(streamer beep.Streamer, sampleRate float64) *SpectrumAnalyzer {
+	s := &SpectrumAnalyzer{
+		streamer:   streamer,
+		sampleRate: sampleRate,
+	}
+
+	for i := range s.window {
+		s.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/spectrumSize)
+	}
+	for i := range s.cos {
+		s.cos[i] = math.Cos(2 * math.Pi * float64(i) / spectrumSize)
+		s.sin[i] = -math.Sin(2 * math.Pi * float64(i) / spectrumSize)
+	}
+
+	return s
+}
+
+// Stream implements beep.Streamer.
+func (s *SpectrumAnalyzer) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = s.streamer.Stream(samples)
+
+	s.mu.Lock()
+	for _, sample := range samples[:n] {
+		s.ring[s.pos] = (sample[0] + sample[1]) / 2
+		s.pos = (s.pos + 1) % spectrumSize
+	}
+	s.mu.Unlock()
+
+	return n, ok
+}
+
+// Err implements beep.Streamer.
+func (s *SpectrumAnalyzer) Err() error {
+	return s.streamer.Err()
+}
+
+// setSampleRate sets the rate of the tapped stream.
+func (s *SpectrumAnalyzer) setSampleRate(sampleRate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sampleRate = sampleRate
+}
+
+// reset fills the ring with silence, e.g. after a seek.
+func (s *SpectrumAnalyzer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ring = [spectrumSize]float64{}
+}
+
+// Spectrum fills bars with the levels of log-spaced bands from low to high
+// frequency, each from 0 (at or below spectrumFloorDB) to 1 (full scale).
+func (s *SpectrumAnalyzer) Spectrum(bars []float64) {
+	if len(bars) == 0 {
+		return
+	}
+
+	s.calc.Lock()
+	defer s.calc.Unlock()
+
+	s.mu.Lock()
+	for i := range spectrumSize {
+		s.re[i] = s.ring[(s.pos+i)%spectrumSize] * s.window[i]
+	}
+	sampleRate := s.sampleRate
+	s.mu.Unlock()
+
+	s.im = [spectrumSize]float64{}
+	s.fft()
+
+	maxFreq := math.Min(spectrumMaxFreq, 0.95*sampleRate/2)
+	binWidth := sampleRate / spectrumSize
+	ratio := math.Pow(maxFreq/spectrumMinFreq, 1/float64(len(bars)))
+
+	for i := range bars {
+		lo := spectrumMinFreq * math.Pow(ratio, float64(i))
+		hi := lo * ratio
+
+		// Narrow low bands may fall between bins; use the nearest one.
+		first := int(math.Round(lo / binWidth))
+		last := max(int(math.Round(hi/binWidth))-1, first)
+		last = min(last, spectrumSize/2-1)
+
+		peak := 0.0
+		for k := first; k <= last; k++ {
+			peak = math.Max(peak, math.Hypot(s.re[k], s.im[k]))
+		}
+
+		// A full scale sine peaks at N/4 with the Hann window.
+		db := 20 * math.Log10(peak*4/spectrumSize+1e-12)
+		bars[i] = math.Max(0, math.Min(1, 1-db/spectrumFloorDB))
+	}
+}
+
+// fft transforms re and im in place (iterative radix-2).
+func (s *SpectrumAnalyzer) fft() {
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < spectrumSize; i++ {
+		bit := spectrumSize >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+
+		if i < j {
+			s.re[i], s.re[j] = s.re[j], s.re[i]
+			s.im[i], s.im[j] = s.im[j], s.im[i]
+		}
+	}
+
+	for size := 2; size <= spectrumSize; size <<= 1 {
+		half := size / 2
+		step := spectrumSize / size
+
+		for start := 0; start < spectrumSize; start += size {
+			for k := range half {
+				wr, wi := s.cos[k*step], s.sin[k*step]
+				a, b := start+k, start+k+half
+
+				tr := s.re[b]*wr - s.im[b]*wi
+				ti := s.re[b]*wi + s.im[b]*wr
+
+				s.re[b], s.im[b] = s.re[a]-tr, s.im[a]-ti
+				s.re[a], s.im[a] = s.re[a]+tr, s.im[a]+ti
+			}
+		}
+	}
+}
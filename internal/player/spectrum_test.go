@@ -0,0 +1,65 @@
+package player
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpectrumFindsTone(t *testing.T) {
+	s := NewSpectrumAnalyzer(&sineStreamer{freq: 1000, rate: 48000}, 48000)
+	s.Stream(make([][2]float64, spectrumSize))
+
+	bars := make([]float64, 20)
+	s.Spectrum(bars)
+
+	loudest := 0
+	for i, v := range bars {
+		if v > bars[loudest] {
+			loudest = i
+		}
+	}
+
+	// The band holding 1 kHz
+	ratio := math.Pow(spectrumMaxFreq/spectrumMinFreq, 1.0/20)
+	want := int(math.Log(1000/spectrumMinFreq) / math.Log(ratio))
+	if loudest != want {
+		t.Errorf("loudest band: got %d, want %d (%v)", loudest, want, bars)
+	}
+
+	// A -6 dBFS sine shows close to its level.
+	if got, wantLevel := bars[want], 1-6.0/-spectrumFloorDB; math.Abs(got-wantLevel) > 0.05 {
+		t.Errorf("level: got %.3f, want %.3f", got, wantLevel)
+	}
+
+	if bars[0] > 0.3 || bars[len(bars)-1] > 0.3 {
+		t.Errorf("far bands: got %.3f and %.3f, want quiet", bars[0], bars[len(bars)-1])
+	}
+}
+
+func TestSpectrumSilence(t *testing.T) {
+	s := NewSpectrumAnalyzer(&sineStreamer{freq: 1000, rate: 48000}, 48000)
+	s.Stream(make([][2]float64, spectrumSize))
+	s.reset()
+
+	bars := make([]float64, 16)
+	s.Spectrum(bars)
+
+	for i, v := range bars {
+		if v != 0 {
+			t.Errorf("bar %d after reset: got %v, want 0", i, v)
+		}
+	}
+}
+
+func TestSpectrumDoesNotAllocate(t *testing.T) {
+	s := NewSpectrumAnalyzer(&sineStreamer{freq: 440, rate: 44100}, 44100)
+	buf := make([][2]float64, 512)
+	bars := make([]float64, 32)
+
+	if allocs := testing.AllocsPerRun(100, func() { s.Stream(buf) }); allocs != 0 {
+		t.Errorf("Stream allocations: got %v, want 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { s.Spectrum(bars) }); allocs != 0 {
+		t.Errorf("Spectrum allocations: got %v, want 0", allocs)
+	}
+}
@@ -0,0 +1,279 @@
+package player
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// Playback speed limits.
+const (
+	MinSpeed = 0.5
+	MaxSpeed = 2.0
+)
+
+// WSOLA parameters: the length of one overlap-add frame and how far the
+// analysis position may move to find the best-matching segment.
+const (
+	stretchFrameDuration     = 30 * time.Millisecond
+	stretchToleranceDuration = 7 * time.Millisecond
+)
+
+// TimeStretch changes playback speed without changing pitch using WSOLA
+// (waveform-similarity overlap-add). Output frames are taken from the
+// source at a hop scaled by the speed, each shifted within a small
+// tolerance so that it lines up with the natural continuation of the
+// previous frame, and cross-faded with a Hann window. At 1x with no
+// pending state the source is passed through untouched.
+type TimeStretch struct {
+	mu       sync.Mutex
+	streamer beep.Streamer
+	speed    float64
+
+	hop       int       // output hop, half a frame
+	tolerance int       // search range around the ideal position
+	window    []float64 // rising half of the Hann window
+
+	active  bool
+	eof     bool
+	in      [][2]float64 // source samples not yet consumed
+	ideal   float64      // ideal start of the next frame in in
+	natural int          // continuation of the previous frame in in
+	tail    [][2]float64 // windowed second half of the previous frame
+	out     [][2]float64 // rendered samples not yet returned
+	outPos  int
+	buf     [][2]float64
+}
+
+// NewTimeStretch wraps a streamer with a speed control.
+func NewTimeStretch(streamer beep.Streamer, sampleRate beep.SampleRate) *TimeStretch {
+	hop := max(sampleRate.N(stretchFrameDuration)/2, 16)
+
+	window := make([]float64, hop)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(math.Pi*float64(i)/float64(hop))
+	}
+
+	return &TimeStretch{
+		streamer:  streamer,
+		speed:     1,
+		hop:       hop,
+		tolerance: max(sampleRate.N(stretchToleranceDuration), 1),
+		window:    window,
+		tail:      make([][2]float64, hop),
+		buf:       make([][2]float64, 2*hop),
+	}
+}
+
+// Stream implements beep.Streamer.
+func (s *TimeStretch) Stream(samples [][2]float64) (n int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for n < len(samples) {
+		if s.outPos < len(s.out) {
+			c := copy(samples[n:], s.out[s.outPos:])
+			n += c
+			s.outPos += c
+
+			continue
+		}
+
+		if !s.active {
+			if s.speed == 1 {
+				m, ok := s.streamer.Stream(samples[n:])
+				n += m
+
+				return n, ok || n > 0
+			}
+
+			if !s.start() {
+				break
+			}
+		}
+
+		if s.speed == 1 || !s.produce() {
+			s.drain()
+		}
+	}
+
+	return n, n > 0
+}
+
+// Err implements beep.Streamer.
+func (s *TimeStretch) Err() error {
+	return s.streamer.Err()
+}
+
+// start primes the stretcher with the first half frame of the source, as
+// if a previous frame had ended exactly there.
+func (s *TimeStretch) start() bool {
+	s.in = s.in[:0]
+	for len(s.in) < s.hop && !s.eof {
+		s.fill()
+	}
+
+	if len(s.in) == 0 {
+		s.eof = false
+		return false
+	}
+
+	for i := range s.tail {
+		s.tail[i] = [2]float64{}
+		if i < len(s.in) {
+			w := 1 - s.window[i]
+			s.tail[i] = [2]float64{w * s.in[i][0], w * s.in[i][1]}
+		}
+	}
+
+	s.ideal = 0
+	s.natural = 0
+	s.active = true
+
+	return true
+}
+
+// fill appends the next chunk of the source to the input buffer.
+func (s *TimeStretch) fill() {
+	n, ok := s.streamer.Stream(s.buf)
+	s.in = append(s.in, s.buf[:n]...)
+
+	if !ok {
+		s.eof = true
+	}
+}
+
+// produce renders one output hop. It returns false once the source cannot
+// provide a full frame.
+func (s *TimeStretch) produce() bool {
+	need := int(s.ideal) + s.tolerance + 2*s.hop
+	for len(s.in) < need && !s.eof {
+		s.fill()
+	}
+
+	if len(s.in) < need {
+		return false
+	}
+
+	c := s.bestOffset()
+
+	s.out = s.out[:0]
+	s.outPos = 0
+
+	for i, w := range s.window {
+		x := s.in[c+i]
+		s.out = append(s.out, [2]float64{s.tail[i][0] + w*x[0], s.tail[i][1] + w*x[1]})
+
+		y := s.in[c+s.hop+i]
+		s.tail[i] = [2]float64{(1 - w) * y[0], (1 - w) * y[1]}
+	}
+
+	s.natural = c + s.hop
+	s.ideal += float64(s.hop) * s.speed
+	s.compact()
+
+	return true
+}
+
+// bestOffset finds the frame start within the tolerance of the ideal
+// position whose first half best matches the natural continuation of the
+// previous frame, by normalized cross-correlation of the mono mix.
+func (s *TimeStretch) bestOffset() int {
+	lo := max(int(s.ideal)-s.tolerance, 0)
+	hi := int(s.ideal) + s.tolerance
+
+	if s.natural >= lo && s.natural <= hi && s.speed == 1 {
+		return s.natural
+	}
+
+	target := s.in[s.natural : s.natural+s.hop]
+
+	best, bestScore := lo, math.Inf(-1)
+	for c := lo; c <= hi; c++ {
+		var corr, energy float64
+		for i := 0; i < s.hop; i += 2 {
+			x := s.in[c+i][0] + s.in[c+i][1]
+			corr += x * (target[i][0] + target[i][1])
+			energy += x * x
+		}
+
+		score := corr / math.Sqrt(energy+1e-9)
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+
+	return best
+}
+
+// compact drops input that no future frame can reach.
+func (s *TimeStretch) compact() {
+	drop := min(int(s.ideal)-s.tolerance, s.natural)
+	if drop < 2*s.hop {
+		return
+	}
+
+	s.in = s.in[:copy(s.in, s.in[drop:])]
+	s.ideal -= float64(drop)
+	s.natural -= drop
+}
+
+// drain renders the rest of the input unstretched and returns to
+// pass-through, either because the speed is back at 1x or because the
+// source has ended.
+func (s *TimeStretch) drain() {
+	s.out = s.out[:0]
+	s.outPos = 0
+
+	for i, w := range s.window {
+		var x [2]float64
+		if s.natural+i < len(s.in) {
+			x = s.in[s.natural+i]
+		}
+		s.out = append(s.out, [2]float64{s.tail[i][0] + w*x[0], s.tail[i][1] + w*x[1]})
+	}
+
+	if rest := s.natural + s.hop; rest < len(s.in) {
+		s.out = append(s.out, s.in[rest:]...)
+	}
+
+	s.in = s.in[:0]
+	s.active = false
+	s.eof = false
+}
+
+// setSpeed changes the playback speed, clamped to [MinSpeed, MaxSpeed].
+func (s *TimeStretch) setSpeed(speed float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.speed = math.Max(MinSpeed, math.Min(MaxSpeed, speed))
+}
+
+// reset discards all buffered state, e.g. after a seek.
+func (s *TimeStretch) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.in = s.in[:0]
+	s.out = s.out[:0]
+	s.outPos = 0
+	s.active = false
+	s.eof = false
+}
+
+// latency returns how many source samples have been read from the source
+// but not yet played, so that positions can be reported in source time.
+func (s *TimeStretch) latency() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := float64(len(s.out)-s.outPos) * s.speed
+	if s.active {
+		pending += float64(len(s.in)) - s.ideal
+	}
+
+	return max(int(pending), 0)
+}
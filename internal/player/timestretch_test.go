@@ -0,0 +1,130 @@
+package player
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+// sineStreamer yields a stereo sine wave and counts the samples consumed.
+type sineStreamer struct {
+	freq     float64
+	rate     float64
+	consumed int
+	limit    int // 0 for endless
+}
+
+func (s *sineStreamer) Stream(samples [][2]float64) (int, bool) {
+	n := len(samples)
+	if s.limit > 0 {
+		n = min(n, s.limit-s.consumed)
+		if n == 0 {
+			return 0, false
+		}
+	}
+
+	for i := range n {
+		v := 0.5 * math.Sin(2*math.Pi*s.freq*float64(s.consumed+i)/s.rate)
+		samples[i] = [2]float64{v, v}
+	}
+	s.consumed += n
+
+	return n, true
+}
+
+func (s *sineStreamer) Err() error { return nil }
+
+// zeroCrossings counts sign changes of the left channel.
+func zeroCrossings(samples [][2]float64) int {
+	count := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1][0] < 0) != (samples[i][0] < 0) {
+			count++
+		}
+	}
+	return count
+}
+
+const stretchTestRate = beep.SampleRate(44100)
+
+func TestTimeStretchPassThroughAtNormalSpeed(t *testing.T) {
+	src := &sineStreamer{freq: 440, rate: float64(stretchTestRate)}
+	s := NewTimeStretch(src, stretchTestRate)
+
+	samples := make([][2]float64, 1000)
+	s.Stream(samples)
+
+	for i, got := range samples {
+		want := 0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(stretchTestRate))
+		if got[0] != want {
+			t.Fatalf("sample %d: got %v, want %v", i, got[0], want)
+		}
+	}
+	if src.consumed != 1000 {
+		t.Errorf("consumed: got %d, want 1000", src.consumed)
+	}
+	if l := s.latency(); l != 0 {
+		t.Errorf("latency: got %d, want 0", l)
+	}
+}
+
+func TestTimeStretchSpeedKeepsPitch(t *testing.T) {
+	for _, speed := range []float64{0.5, 1.5, 2.0} {
+		src := &sineStreamer{freq: 440, rate: float64(stretchTestRate)}
+		s := NewTimeStretch(src, stretchTestRate)
+		s.setSpeed(speed)
+
+		// Render one second of output in speaker-sized chunks.
+		out := make([][2]float64, int(stretchTestRate))
+		for pos := 0; pos < len(out); pos += 512 {
+			s.Stream(out[pos:min(pos+512, len(out))])
+		}
+
+		// Source consumed, net of read-ahead, tracks the speed.
+		played := float64(src.consumed-s.latency()) / float64(len(out))
+		if math.Abs(played-speed) > 0.02 {
+			t.Errorf("speed %.1f: source advanced %.3fx", speed, played)
+		}
+
+		// A 440 Hz tone crosses zero 880 times per second regardless of speed.
+		if zc := zeroCrossings(out); math.Abs(float64(zc)-880) > 10 {
+			t.Errorf("speed %.1f: got %d zero crossings, want about 880", speed, zc)
+		}
+	}
+}
+
+func TestTimeStretchClampsSpeed(t *testing.T) {
+	s := NewTimeStretch(&sineStreamer{freq: 440, rate: 44100}, stretchTestRate)
+
+	s.setSpeed(5)
+	if s.speed != MaxSpeed {
+		t.Errorf("speed: got %v, want %v", s.speed, MaxSpeed)
+	}
+
+	s.setSpeed(0.1)
+	if s.speed != MinSpeed {
+		t.Errorf("speed: got %v, want %v", s.speed, MinSpeed)
+	}
+}
+
+func TestTimeStretchDrainsAtEnd(t *testing.T) {
+	src := &sineStreamer{freq: 440, rate: float64(stretchTestRate), limit: 10000}
+	s := NewTimeStretch(src, stretchTestRate)
+	s.setSpeed(2)
+
+	total := 0
+	buf := make([][2]float64, 512)
+	for {
+		n, ok := s.Stream(buf)
+		total += n
+		if !ok {
+			break
+		}
+	}
+
+	// About half the source length, plus at most one frame of tail.
+	if total < 4500 || total > 5000+4*s.hop {
+		t.Errorf("output length: got %d, want about 5000", total)
+	}
+}
@@ -26,6 +26,11 @@ func DecibelToLinear(db float64) float64 {
 	return math.Pow(10, db/40.0)
 }
 
+// decibelsToGain converts a gain in dB to a linear amplitude factor.
+func decibelsToGain(db float64) float64 {
+	return math.Pow(10, db/20.0)
+}
+
 // ClampVolume clamps a volume value to the valid range [0.0, 1.0].
 func ClampVolume(v float64) float64 {
 	if v < 0 {
@@ -0,0 +1,126 @@
+package player
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// monoVorbis is one second of mono 44.1 kHz Vorbis, from the test data of
+// github.com/jfreymuth/oggvorbis (MIT).
+const monoVorbis = "testdata/mono.ogg"
+
+func openVorbis(t *testing.T, path string) *vorbisDecoder {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	streamer, format, err := DecodeVorbis(file)
+	if err != nil {
+		t.Fatalf("DecodeVorbis: %v", err)
+	}
+	t.Cleanup(func() { streamer.Close() })
+
+	if format.SampleRate != 44100 {
+		t.Errorf("sample rate: got %d, want 44100", format.SampleRate)
+	}
+
+	return streamer.(*vorbisDecoder)
+}
+
+// referenceVorbis decodes a file with oggvorbis directly, one value per
+// sample and channel.
+func referenceVorbis(t *testing.T, path string) []float32 {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer file.Close()
+
+	data, _, err := oggvorbis.ReadAll(file)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	return data
+}
+
+func TestVorbisDuplicatesMono(t *testing.T) {
+	want := referenceVorbis(t, monoVorbis)
+	d := openVorbis(t, monoVorbis)
+
+	if d.Len() != 44100 || len(want) != d.Len() {
+		t.Fatalf("Len: got %d, want 44100 and the %d samples oggvorbis decodes", d.Len(), len(want))
+	}
+
+	var got [][2]float64
+
+	buf := make([][2]float64, 1000)
+	for {
+		n, ok := d.Stream(buf)
+		got = append(got, buf[:n]...)
+
+		if !ok {
+			break
+		}
+	}
+
+	if err := d.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d samples, want %d", len(got), len(want))
+	}
+
+	for i, s := range got {
+		if s[0] != float64(want[i]) || s[1] != s[0] {
+			t.Fatalf("sample %d: got %v, want %v on both channels", i, s, want[i])
+		}
+	}
+}
+
+func TestVorbisSeekIsSampleAccurate(t *testing.T) {
+	want := referenceVorbis(t, monoVorbis)
+	total := len(want)
+
+	d := openVorbis(t, monoVorbis)
+
+	for _, target := range []int{total / 2, 0, 4095, 4096, total - 1, 1234} {
+		if err := d.Seek(target); err != nil {
+			t.Fatalf("Seek(%d): %v", target, err)
+		}
+		if d.Position() != target {
+			t.Errorf("Position after Seek(%d): got %d", target, d.Position())
+		}
+
+		samples := make([][2]float64, min(500, total-target))
+		n, _ := d.Stream(samples)
+		if n != len(samples) {
+			t.Fatalf("Stream after Seek(%d): got %d samples, want %d", target, n, len(samples))
+		}
+
+		for i := range samples {
+			if samples[i][0] != float64(want[target+i]) {
+				t.Fatalf("Seek(%d): sample %d: got %v, want %v", target, target+i, samples[i][0], want[target+i])
+			}
+		}
+
+		if d.Position() != target+n {
+			t.Errorf("Position after streaming from %d: got %d, want %d", target, d.Position(), target+n)
+		}
+	}
+
+	if err := d.Seek(total); err != nil {
+		t.Fatalf("Seek to end: %v", err)
+	}
+	if n, ok := d.Stream(make([][2]float64, 10)); n != 0 || ok {
+		t.Errorf("Stream at end: got (%d, %v), want (0, false)", n, ok)
+	}
+}
@@ -2,15 +2,31 @@ package queue
 
 import (
 	"math/rand"
+	"slices"
+	"strings"
 
 	"github.com/haryoiro/yutemal/internal/structures"
 )
 
 // Queue manages an ordered list of tracks with a current position pointer.
 // All methods are pure state manipulation with no I/O side effects.
+//
+// Tracks always keeps the order the tracks were added in. While shuffled,
+// the queue plays through a separate play order instead, so that turning
+// shuffle off restores the original order and Previous retraces what was
+// actually played.
 type Queue struct {
 	Tracks  []structures.Track
-	Current int
+	Current int // index into Tracks
+	Repeat  structures.RepeatMode
+
+	// SmartShuffle keeps tracks by the same artist apart when shuffling.
+	SmartShuffle bool
+
+	shuffled bool
+	order    []int // play order as indices into Tracks, while shuffled
+	pos      int   // position of Current in order
+	rng      *rand.Rand
 }
 
 // New creates an empty queue.
@@ -41,36 +57,152 @@ func (q *Queue) ValidCurrent() bool {
 	return q.Current >= 0 && q.Current < len(q.Tracks)
 }
 
-// Next advances to the next track. Returns true if advanced.
+// IsShuffled returns whether the queue plays in shuffled order.
+func (q *Queue) IsShuffled() bool {
+	return q.shuffled
+}
+
+// PlayOrder returns the indices of Tracks in the order they play.
+func (q *Queue) PlayOrder() []int {
+	if q.shuffled {
+		return slices.Clone(q.order)
+	}
+
+	order := make([]int, len(q.Tracks))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// position returns where the current track is in the play order.
+func (q *Queue) position() int {
+	if q.shuffled {
+		return q.pos
+	}
+	return q.Current
+}
+
+// positionOf returns where a track is in the play order.
+func (q *Queue) positionOf(index int) int {
+	if q.shuffled {
+		return slices.Index(q.order, index)
+	}
+	return index
+}
+
+// at returns the track index at a position in the play order.
+func (q *Queue) at(p int) int {
+	if q.shuffled {
+		return q.order[p]
+	}
+	return p
+}
+
+// moveTo makes the track at a position in the play order current.
+func (q *Queue) moveTo(p int) {
+	if p < 0 || p >= len(q.Tracks) {
+		q.pos, q.Current = 0, 0
+		return
+	}
+	q.pos = p
+	q.Current = q.at(p)
+}
+
+// AtEnd returns whether the current track is the last in the play order,
+// regardless of repeat.
+func (q *Queue) AtEnd() bool {
+	return q.position()+1 >= len(q.Tracks)
+}
+
+// HasNext returns whether Next would move.
+func (q *Queue) HasNext() bool {
+	return !q.AtEnd() || (q.Repeat != structures.RepeatOff && len(q.Tracks) > 0)
+}
+
+// HasPrevious returns whether Previous would move.
+func (q *Queue) HasPrevious() bool {
+	return q.position() > 0 || (q.Repeat != structures.RepeatOff && len(q.Tracks) > 0)
+}
+
+// Next advances to the next track, wrapping around to the first one when
+// repeat is on. Returns true if advanced.
 func (q *Queue) Next() bool {
-	if q.Current+1 >= len(q.Tracks) {
+	if !q.HasNext() {
 		return false
 	}
-	q.Current++
+	q.moveTo((q.position() + 1) % len(q.Tracks))
 	return true
 }
 
-// Previous goes back to the previous track. Returns true if moved.
+// Previous goes back to the previous track, wrapping around to the last one
+// when repeat is on. Returns true if moved.
 func (q *Queue) Previous() bool {
-	if q.Current <= 0 {
+	if !q.HasPrevious() {
 		return false
 	}
-	q.Current--
+	q.moveTo((q.position() - 1 + len(q.Tracks)) % len(q.Tracks))
 	return true
 }
 
+// Advance moves on after the current track has played to its end. In
+// repeat-one mode it stays on the track, otherwise it is Next.
+// Returns true if there is a track to play.
+func (q *Queue) Advance() bool {
+	if q.Repeat == structures.RepeatOne {
+		return q.ValidCurrent()
+	}
+	return q.Next()
+}
+
+// Upcoming returns the index of the track Advance would play.
+func (q *Queue) Upcoming() (int, bool) {
+	switch {
+	case !q.ValidCurrent():
+		return 0, false
+	case q.Repeat == structures.RepeatOne:
+		return q.Current, true
+	case !q.AtEnd():
+		return q.at(q.position() + 1), true
+	case q.Repeat == structures.RepeatAll:
+		return q.at(0), true
+	}
+	return 0, false
+}
+
 // JumpTo sets the current index. Returns false if out of bounds.
+// While shuffled, the track is moved up to play right after the current
+// one, so that the tracks played so far stay in the history.
 func (q *Queue) JumpTo(index int) bool {
 	if index < 0 || index >= len(q.Tracks) {
 		return false
 	}
+	if q.shuffled && index != q.Current {
+		p := q.positionOf(index)
+		q.order = slices.Delete(q.order, p, p+1)
+		if p < q.pos {
+			q.pos--
+		}
+		q.order = slices.Insert(q.order, q.pos+1, index)
+		q.pos++
+	}
 	q.Current = index
 	return true
 }
 
-// AddTracks appends tracks to the end.
+// AddTracks appends tracks to the end. While shuffled, the new tracks are
+// shuffled in after the ones already queued.
 func (q *Queue) AddTracks(tracks []structures.Track) {
+	start := len(q.Tracks)
 	q.Tracks = append(q.Tracks, tracks...)
+
+	if !q.shuffled {
+		return
+	}
+	for i := start; i < len(q.Tracks); i++ {
+		q.order = append(q.order, i)
+	}
+	q.shuffleFrom(max(start, q.pos+1))
 }
 
 // InsertAfterCurrent inserts a track after the current position.
@@ -79,11 +211,23 @@ func (q *Queue) InsertAfterCurrent(track structures.Track) {
 	if len(q.Tracks) == 0 {
 		q.Tracks = append(q.Tracks, track)
 		q.Current = 0
+		if q.shuffled {
+			q.order, q.pos = []int{0}, 0
+		}
 		return
 	}
 	insertPos := min(q.Current+1, len(q.Tracks))
 	q.Tracks = append(q.Tracks[:insertPos],
 		append([]structures.Track{track}, q.Tracks[insertPos:]...)...)
+
+	if q.shuffled {
+		for i, index := range q.order {
+			if index >= insertPos {
+				q.order[i]++
+			}
+		}
+		q.order = slices.Insert(q.order, q.pos+1, insertPos)
+	}
 }
 
 // DeleteAt removes a track at the given index.
@@ -93,40 +237,192 @@ func (q *Queue) DeleteAt(index int) (deletedCurrent bool) {
 		return false
 	}
 	deletedCurrent = index == q.Current
+	p, cur := q.positionOf(index), q.position()
 	q.Tracks = append(q.Tracks[:index], q.Tracks[index+1:]...)
 
-	if index < q.Current {
-		q.Current--
-	} else if deletedCurrent {
-		if q.Current >= len(q.Tracks) && q.Current > 0 {
-			q.Current--
+	if q.shuffled {
+		q.order = slices.Delete(q.order, p, p+1)
+		for i, other := range q.order {
+			if other > index {
+				q.order[i]--
+			}
+		}
+	}
+
+	// Once the current track is gone the one after it takes over. At the
+	// end of the queue that is the first track when repeat is on, and the
+	// new last track otherwise.
+	if p < cur {
+		cur--
+	} else if deletedCurrent && cur >= len(q.Tracks) {
+		if q.Repeat != structures.RepeatOff {
+			cur = 0
+		} else if cur > 0 {
+			cur--
+		}
+	}
+	q.moveTo(cur)
+	return deletedCurrent
+}
+
+// DeleteIndices removes the tracks at the given indices, ignoring duplicates
+// and indices out of bounds. Returns whether the current track was among
+// them, in which case the first remaining track after it becomes current.
+func (q *Queue) DeleteIndices(indices []int) (deletedCurrent bool) {
+	sorted := slices.Clone(indices)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	// From the back, so that the indices still to go stay valid
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if q.DeleteAt(sorted[i]) {
+			deletedCurrent = true
 		}
 	}
 	return deletedCurrent
 }
 
+// Move moves the track at from to index to, shifting the tracks in
+// between. Returns false if either index is out of bounds.
+func (q *Queue) Move(from, to int) bool {
+	return q.MoveRange(from, from+1, to)
+}
+
+// MoveRange moves the tracks from start up to but not including end so
+// that the first of them ends up at index to. The current track stays
+// current, and while shuffled the play order is kept. Returns false if the
+// range or the target is out of bounds.
+func (q *Queue) MoveRange(start, end, to int) bool {
+	n := end - start
+	if start < 0 || n <= 0 || end > len(q.Tracks) || to < 0 || to > len(q.Tracks)-n {
+		return false
+	}
+	if to == start {
+		return true
+	}
+
+	// from[i] is the old index of the track that ends up at i
+	from := make([]int, len(q.Tracks))
+	for i := range from {
+		from[i] = i
+	}
+	block := slices.Clone(from[start:end])
+	from = slices.Delete(from, start, end)
+	from = slices.Insert(from, to, block...)
+
+	tracks := make([]structures.Track, len(q.Tracks))
+	newIndex := make([]int, len(q.Tracks))
+	for i, old := range from {
+		tracks[i] = q.Tracks[old]
+		newIndex[old] = i
+	}
+	q.Tracks = tracks
+
+	if q.ValidCurrent() {
+		q.Current = newIndex[q.Current]
+	}
+	for i, old := range q.order {
+		q.order[i] = newIndex[old]
+	}
+	return true
+}
+
 // DeleteCurrent removes the current track.
 func (q *Queue) DeleteCurrent() {
 	if !q.ValidCurrent() {
 		return
 	}
-	q.Tracks = append(q.Tracks[:q.Current], q.Tracks[q.Current+1:]...)
-	if q.Current >= len(q.Tracks) && q.Current > 0 {
-		q.Current--
-	}
+	q.DeleteAt(q.Current)
 }
 
-// Shuffle shuffles all tracks after the current position.
+// Shuffle turns shuffle on, or reshuffles the tracks still to come if it
+// is on already. The current track and the ones played before it keep
+// their place in the play order, and Tracks keeps its order.
 // Uses the provided random source for deterministic testing.
 func (q *Queue) Shuffle(rng *rand.Rand) {
-	if len(q.Tracks) <= q.Current+1 {
+	q.rng = rng
+	if !q.shuffled {
+		q.order = q.PlayOrder()
+		q.pos = max(min(q.Current, len(q.Tracks)-1), 0)
+		q.shuffled = true
+	}
+	q.shuffleFrom(q.pos + 1)
+}
+
+// Unshuffle turns shuffle off. Playback continues in the original order
+// from the current track.
+func (q *Queue) Unshuffle() {
+	q.shuffled = false
+	q.order = nil
+	q.pos = 0
+}
+
+// shuffleFrom shuffles the play order from position from onwards.
+func (q *Queue) shuffleFrom(from int) {
+	if from >= len(q.order) {
 		return
 	}
-	remaining := q.Tracks[q.Current+1:]
-	for i := len(remaining) - 1; i > 0; i-- {
-		j := rng.Intn(i + 1)
-		remaining[i], remaining[j] = remaining[j], remaining[i]
+	rest := q.order[from:]
+	q.rng.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+	if q.SmartShuffle {
+		q.spreadArtists(from)
+	}
+}
+
+// spreadArtists reorders the play order from position from onwards so that
+// tracks by the same artist do not follow each other, wherever the mix of
+// artists allows it. Otherwise the shuffled order is kept, except that an
+// artist with more than half of the remaining tracks has to come first to
+// stay apart.
+func (q *Queue) spreadArtists(from int) {
+	rest := slices.Clone(q.order[from:])
+	left := make(map[string]int)
+	for _, index := range rest {
+		left[artistKey(q.Tracks[index])]++
+	}
+
+	prev := ""
+	if from > 0 {
+		prev = artistKey(q.Tracks[q.order[from-1]])
+	}
+
+	for p := from; p < len(q.order); p++ {
+		pick := -1
+		for j, index := range rest {
+			artist := artistKey(q.Tracks[index])
+			if artist != "" && artist != prev && 2*left[artist] > len(rest) {
+				pick = j
+				break
+			}
+		}
+		if pick < 0 {
+			for j, index := range rest {
+				if artist := artistKey(q.Tracks[index]); artist == "" || artist != prev {
+					pick = j
+					break
+				}
+			}
+		}
+		if pick < 0 {
+			pick = 0
+		}
+
+		q.order[p] = rest[pick]
+		rest = slices.Delete(rest, pick, pick+1)
+		prev = artistKey(q.Tracks[q.order[p]])
+		left[prev]--
+	}
+}
+
+// artistKey returns the main artist of a track for smart shuffle, or ""
+// if it is unknown.
+func artistKey(track structures.Track) string {
+	if len(track.Artists) == 0 {
+		return ""
 	}
+	return strings.ToLower(strings.TrimSpace(track.Artists[0]))
 }
 
 // ReplaceAfterCurrent removes all tracks after current and appends new ones.
@@ -134,13 +430,79 @@ func (q *Queue) Shuffle(rng *rand.Rand) {
 func (q *Queue) ReplaceAfterCurrent(tracks []structures.Track) bool {
 	if q.Current+1 < len(q.Tracks) {
 		q.Tracks = q.Tracks[:q.Current+1]
+		if q.shuffled {
+			q.order = slices.DeleteFunc(q.order, func(index int) bool { return index > q.Current })
+			q.pos = slices.Index(q.order, q.Current)
+		}
 	}
-	q.Tracks = append(q.Tracks, tracks...)
+	q.AddTracks(tracks)
 	return q.Next()
 }
 
-// Clear removes all tracks and resets the index.
+// Clear removes all tracks and resets the index. Shuffle stays on.
 func (q *Queue) Clear() {
 	q.Tracks = nil
 	q.Current = 0
+	q.order = nil
+	q.pos = 0
+}
+
+// Snapshot is the saved state of a queue, for restoring it after a restart.
+type Snapshot struct {
+	Tracks   []structures.Track `json:"tracks"`
+	Current  int                `json:"current"`
+	Shuffled bool               `json:"shuffled,omitempty"`
+	Order    []int              `json:"order,omitempty"` // play order, while shuffled
+}
+
+// Snapshot returns a copy of the queue's tracks, current track and play
+// order.
+func (q *Queue) Snapshot() Snapshot {
+	s := Snapshot{
+		Tracks:   slices.Clone(q.Tracks),
+		Current:  q.Current,
+		Shuffled: q.shuffled,
+	}
+	if q.shuffled {
+		s.Order = slices.Clone(q.order)
+	}
+	return s
+}
+
+// Restore replaces the queue's tracks, current track and play order with
+// a snapshot. A shuffled snapshot whose play order does not match its
+// tracks is restored unshuffled. The random source is used for tracks
+// added later while shuffled. Returns false, leaving the queue untouched,
+// if the snapshot has no valid current track.
+func (q *Queue) Restore(s Snapshot, rng *rand.Rand) bool {
+	if s.Current < 0 || s.Current >= len(s.Tracks) {
+		return false
+	}
+
+	q.Tracks = slices.Clone(s.Tracks)
+	q.Current = s.Current
+	q.Unshuffle()
+
+	if s.Shuffled && isPermutation(s.Order, len(s.Tracks)) {
+		q.shuffled = true
+		q.order = slices.Clone(s.Order)
+		q.pos = slices.Index(q.order, q.Current)
+		q.rng = rng
+	}
+	return true
+}
+
+// isPermutation reports whether order holds each index below n once.
+func isPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, index := range order {
+		if index < 0 || index >= n || seen[index] {
+			return false
+		}
+		seen[index] = true
+	}
+	return true
 }
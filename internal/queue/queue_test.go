@@ -2,6 +2,7 @@ package queue
 
 import (
 	"math/rand"
+	"slices"
 	"testing"
 
 	"github.com/haryoiro/yutemal/internal/structures"
@@ -385,6 +386,194 @@ func TestShuffleEmpty(t *testing.T) {
 	q.Shuffle(rng) // should not panic
 }
 
+func TestShuffleKeepsOriginalOrder(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Current = 1
+	q.Shuffle(rand.New(rand.NewSource(42)))
+
+	if !q.IsShuffled() {
+		t.Error("IsShuffled: got false")
+	}
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		if q.Tracks[i].TrackID != id {
+			t.Errorf("Tracks[%d]: got %s, want %s", i, q.Tracks[i].TrackID, id)
+		}
+	}
+
+	order := q.PlayOrder()
+	if order[0] != 0 || order[1] != 1 {
+		t.Errorf("PlayOrder history: got %v, want [0 1 ...]", order[:2])
+	}
+	seen := make(map[int]bool)
+	for _, index := range order {
+		seen[index] = true
+	}
+	if len(order) != 5 || len(seen) != 5 {
+		t.Errorf("PlayOrder: got %v, want a permutation of 5 tracks", order)
+	}
+}
+
+func TestUnshuffleReturnsToOriginalPosition(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Shuffle(rand.New(rand.NewSource(1)))
+	q.Next()
+	q.Next()
+
+	cur, _ := q.CurrentTrack()
+	q.Unshuffle()
+
+	if q.IsShuffled() {
+		t.Error("IsShuffled after Unshuffle: got true")
+	}
+	if q.Tracks[q.Current].TrackID != cur.TrackID {
+		t.Errorf("current after Unshuffle: got %s, want %s", q.Tracks[q.Current].TrackID, cur.TrackID)
+	}
+	want := q.Current + 1
+	if q.Next() && q.Current != want {
+		t.Errorf("Next after Unshuffle: got %d, want %d", q.Current, want)
+	}
+}
+
+func TestPreviousRetracesPlayOrder(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Shuffle(rand.New(rand.NewSource(3)))
+
+	var played []int
+	for {
+		played = append(played, q.Current)
+		if !q.Next() {
+			break
+		}
+	}
+
+	for i := len(played) - 2; i >= 0; i-- {
+		if !q.Previous() {
+			t.Fatal("Previous: got false")
+		}
+		if q.Current != played[i] {
+			t.Errorf("Previous: got %d, want %d", q.Current, played[i])
+		}
+	}
+}
+
+func TestJumpToWhileShuffled(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Shuffle(rand.New(rand.NewSource(5)))
+	q.Next()
+	before := q.Current
+
+	target := q.PlayOrder()[4]
+	if !q.JumpTo(target) {
+		t.Fatal("JumpTo: got false")
+	}
+	if q.Current != target {
+		t.Errorf("Current: got %d, want %d", q.Current, target)
+	}
+
+	q.Previous()
+	if q.Current != before {
+		t.Errorf("Previous after JumpTo: got %d, want %d", q.Current, before)
+	}
+}
+
+func TestAddTracksWhileShuffled(t *testing.T) {
+	q := New()
+	q.Shuffle(rand.New(rand.NewSource(9)))
+	q.AddTracks(tracks("a", "b", "c", "d"))
+
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "a" {
+		t.Errorf("CurrentTrack: got %s, want a", cur.TrackID)
+	}
+
+	q.Clear()
+	if !q.IsShuffled() {
+		t.Error("Clear should keep shuffle on")
+	}
+
+	q.AddTracks(tracks("x", "y"))
+	q.AddTracks(tracks("z"))
+	if order := q.PlayOrder(); len(order) != 3 || order[0] != 0 {
+		t.Errorf("PlayOrder: got %v, want 3 tracks starting with 0", order)
+	}
+}
+
+func TestInsertAfterCurrentWhileShuffled(t *testing.T) {
+	q := setup("a", "b", "c", "d")
+	q.Shuffle(rand.New(rand.NewSource(11)))
+	q.Next()
+	cur := q.Tracks[q.Current].TrackID
+
+	q.InsertAfterCurrent(track("x"))
+	if q.Tracks[q.Current].TrackID != cur {
+		t.Errorf("current after insert: got %s, want %s", q.Tracks[q.Current].TrackID, cur)
+	}
+
+	q.Next()
+	if q.Tracks[q.Current].TrackID != "x" {
+		t.Errorf("Next after insert: got %s, want x", q.Tracks[q.Current].TrackID)
+	}
+}
+
+func TestDeleteAtWhileShuffled(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Shuffle(rand.New(rand.NewSource(13)))
+	q.Next()
+
+	order := q.PlayOrder()
+	next := q.Tracks[order[2]].TrackID
+
+	if !q.DeleteAt(q.Current) {
+		t.Error("DeleteAt current: got false")
+	}
+	if q.Tracks[q.Current].TrackID != next {
+		t.Errorf("after deleting current: got %s, want %s", q.Tracks[q.Current].TrackID, next)
+	}
+
+	// Every index in the play order is still valid and used once
+	seen := make(map[int]bool)
+	for _, index := range q.PlayOrder() {
+		if index < 0 || index >= q.Len() || seen[index] {
+			t.Fatalf("PlayOrder after delete: got %v", q.PlayOrder())
+		}
+		seen[index] = true
+	}
+}
+
+func TestSmartShuffleSeparatesArtists(t *testing.T) {
+	artists := []string{"A", "A", "A", "B", "B", "B", "C", "C", "D", "E"}
+
+	for seed := range int64(50) {
+		q := New()
+		for i, artist := range artists {
+			q.AddTracks([]structures.Track{{TrackID: string(rune('a' + i)), Artists: []string{artist}}})
+		}
+		q.SmartShuffle = true
+		q.Current = 0
+		q.Shuffle(rand.New(rand.NewSource(seed)))
+
+		order := q.PlayOrder()
+		for i := 1; i < len(order); i++ {
+			if q.Tracks[order[i]].Artists[0] == q.Tracks[order[i-1]].Artists[0] {
+				t.Errorf("seed %d: %s twice in a row in %v", seed, q.Tracks[order[i]].Artists[0], order)
+				break
+			}
+		}
+	}
+}
+
+func TestSmartShuffleUnavoidableRepeats(t *testing.T) {
+	q := New()
+	for i, artist := range []string{"A", "A", "A", "A", "B"} {
+		q.AddTracks([]structures.Track{{TrackID: string(rune('a' + i)), Artists: []string{artist}}})
+	}
+	q.SmartShuffle = true
+	q.Shuffle(rand.New(rand.NewSource(1)))
+
+	if order := q.PlayOrder(); len(order) != 5 {
+		t.Errorf("PlayOrder: got %v, want all 5 tracks", order)
+	}
+}
+
 // --- ReplaceAfterCurrent ---
 
 func TestReplaceAfterCurrent(t *testing.T) {
@@ -451,6 +640,437 @@ func TestClear(t *testing.T) {
 	}
 }
 
+// --- Repeat ---
+
+func TestNextRepeatAll(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Repeat = structures.RepeatAll
+	q.Current = 2
+
+	if !q.HasNext() {
+		t.Error("HasNext at last: got false")
+	}
+	if !q.Next() {
+		t.Error("Next from last: got false")
+	}
+	if q.Current != 0 {
+		t.Errorf("Current: got %d, want 0", q.Current)
+	}
+}
+
+func TestPreviousRepeatAll(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Repeat = structures.RepeatAll
+
+	if !q.Previous() {
+		t.Error("Previous from first: got false")
+	}
+	if q.Current != 2 {
+		t.Errorf("Current: got %d, want 2", q.Current)
+	}
+}
+
+func TestRepeatOff(t *testing.T) {
+	q := setup("a", "b")
+	q.Current = 1
+
+	if q.HasNext() {
+		t.Error("HasNext at last: got true")
+	}
+	if q.Advance() {
+		t.Error("Advance from last: got true")
+	}
+	if _, ok := q.Upcoming(); ok {
+		t.Error("Upcoming at last: got ok")
+	}
+}
+
+func TestAdvanceRepeatOne(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Repeat = structures.RepeatOne
+	q.Current = 1
+
+	if !q.Advance() {
+		t.Error("Advance: got false")
+	}
+	if q.Current != 1 {
+		t.Errorf("Current after Advance: got %d, want 1", q.Current)
+	}
+
+	// Skipping still moves on, wrapping at the end
+	q.Next()
+	q.Next()
+	if q.Current != 0 {
+		t.Errorf("Current after two Next: got %d, want 0", q.Current)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	tests := []struct {
+		repeat  structures.RepeatMode
+		current int
+		want    int
+		wantOK  bool
+	}{
+		{structures.RepeatOff, 0, 1, true},
+		{structures.RepeatOff, 2, 0, false},
+		{structures.RepeatAll, 0, 1, true},
+		{structures.RepeatAll, 2, 0, true},
+		{structures.RepeatOne, 1, 1, true},
+		{structures.RepeatOne, 2, 2, true},
+	}
+
+	for _, tt := range tests {
+		q := setup("a", "b", "c")
+		q.Repeat = tt.repeat
+		q.Current = tt.current
+
+		got, ok := q.Upcoming()
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Upcoming(%s at %d): got %d, %v, want %d, %v", tt.repeat, tt.current, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestUpcomingOnEmpty(t *testing.T) {
+	q := New()
+	q.Repeat = structures.RepeatAll
+
+	if _, ok := q.Upcoming(); ok {
+		t.Error("Upcoming on empty: got ok")
+	}
+	if q.Advance() || q.Next() || q.Previous() {
+		t.Error("moving through an empty queue: got true")
+	}
+}
+
+func TestRepeatAllAfterShuffle(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Repeat = structures.RepeatAll
+	q.Current = 1
+	q.Shuffle(rand.New(rand.NewSource(42)))
+
+	// Every track plays once before the current one comes round again
+	seen := map[string]bool{"b": true}
+	for range q.Len() - 1 {
+		if !q.Advance() {
+			t.Fatal("Advance: got false")
+		}
+		cur, _ := q.CurrentTrack()
+		if seen[cur.TrackID] {
+			t.Errorf("track %s played twice", cur.TrackID)
+		}
+		seen[cur.TrackID] = true
+	}
+
+	q.Advance()
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "b" {
+		t.Errorf("after a full round: got %s, want b", cur.TrackID)
+	}
+}
+
+func TestRepeatOneAfterShuffle(t *testing.T) {
+	q := setup("a", "b", "c", "d")
+	q.Repeat = structures.RepeatOne
+	q.Current = 1
+	q.Shuffle(rand.New(rand.NewSource(7)))
+
+	q.Advance()
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "b" {
+		t.Errorf("Advance after shuffle: got %s, want b", cur.TrackID)
+	}
+}
+
+func TestDeleteAtCurrentLastRepeatAll(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Repeat = structures.RepeatAll
+	q.Current = 2
+
+	if !q.DeleteAt(2) {
+		t.Error("DeleteAt current: got false")
+	}
+	if q.Current != 0 {
+		t.Errorf("Current: got %d, want 0 (wrapped)", q.Current)
+	}
+}
+
+func TestDeleteCurrentLastRepeatAll(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Repeat = structures.RepeatAll
+	q.Current = 2
+	q.DeleteCurrent()
+
+	if q.Current != 0 {
+		t.Errorf("Current: got %d, want 0 (wrapped)", q.Current)
+	}
+}
+
+func TestDeleteCurrentRepeatOne(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Repeat = structures.RepeatOne
+	q.Current = 1
+	q.DeleteAt(1)
+
+	// The next track takes over and is the one repeated
+	q.Advance()
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "c" {
+		t.Errorf("after deleting the repeated track: got %s, want c", cur.TrackID)
+	}
+
+	q.DeleteCurrent()
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "a" {
+		t.Errorf("after deleting the last track: got %s, want a", cur.TrackID)
+	}
+}
+
+func TestDeleteOnlyTrackRepeatAll(t *testing.T) {
+	q := setup("a")
+	q.Repeat = structures.RepeatAll
+	q.DeleteAt(0)
+
+	if q.ValidCurrent() {
+		t.Error("ValidCurrent: got true on empty queue")
+	}
+	if q.Advance() {
+		t.Error("Advance on empty queue: got true")
+	}
+}
+
+// --- Move / MoveRange / DeleteIndices ---
+
+func ids(q *Queue) []string {
+	out := make([]string, q.Len())
+	for i, tr := range q.Tracks {
+		out[i] = tr.TrackID
+	}
+	return out
+}
+
+func assertOrder(t *testing.T, q *Queue, want ...string) {
+	t.Helper()
+	got := ids(q)
+	if !slices.Equal(got, want) {
+		t.Errorf("Tracks: got %v, want %v", got, want)
+	}
+}
+
+func TestMoveDown(t *testing.T) {
+	q := setup("a", "b", "c", "d")
+	q.Current = 2 // at "c"
+
+	if !q.Move(0, 2) {
+		t.Fatal("Move: got false")
+	}
+	assertOrder(t, q, "b", "c", "a", "d")
+	if q.Current != 1 {
+		t.Errorf("Current should follow c: got %d, want 1", q.Current)
+	}
+}
+
+func TestMoveUp(t *testing.T) {
+	q := setup("a", "b", "c", "d")
+	q.Current = 3 // at "d"
+
+	if !q.Move(3, 1) {
+		t.Fatal("Move: got false")
+	}
+	assertOrder(t, q, "a", "d", "b", "c")
+	if q.Current != 1 {
+		t.Errorf("Current should follow d: got %d, want 1", q.Current)
+	}
+}
+
+func TestMoveOutOfBounds(t *testing.T) {
+	q := setup("a", "b")
+
+	for _, tt := range [][2]int{{-1, 0}, {0, 2}, {2, 0}, {0, -1}} {
+		if q.Move(tt[0], tt[1]) {
+			t.Errorf("Move(%d, %d): got true", tt[0], tt[1])
+		}
+	}
+	assertOrder(t, q, "a", "b")
+}
+
+func TestMoveRangeToTop(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Current = 0
+
+	if !q.MoveRange(2, 4, 0) {
+		t.Fatal("MoveRange: got false")
+	}
+	assertOrder(t, q, "c", "d", "a", "b", "e")
+	if q.Current != 2 {
+		t.Errorf("Current should follow a: got %d, want 2", q.Current)
+	}
+}
+
+func TestMoveRangeToEnd(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Current = 1
+
+	if !q.MoveRange(0, 2, 3) {
+		t.Fatal("MoveRange: got false")
+	}
+	assertOrder(t, q, "c", "d", "e", "a", "b")
+	if q.Current != 4 {
+		t.Errorf("Current should follow b: got %d, want 4", q.Current)
+	}
+}
+
+func TestMoveRangeInvalid(t *testing.T) {
+	q := setup("a", "b", "c")
+
+	if q.MoveRange(1, 1, 0) {
+		t.Error("empty range: got true")
+	}
+	if q.MoveRange(1, 3, 2) {
+		t.Error("target past the end: got true")
+	}
+	if q.MoveRange(2, 4, 0) {
+		t.Error("range past the end: got true")
+	}
+}
+
+func TestMoveWhileShuffled(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Shuffle(rand.New(rand.NewSource(21)))
+	q.Next()
+
+	playing := func() []string {
+		var out []string
+		for _, index := range q.PlayOrder() {
+			out = append(out, q.Tracks[index].TrackID)
+		}
+		return out
+	}
+
+	before := playing()
+	cur, _ := q.CurrentTrack()
+
+	q.MoveRange(3, 5, 0)
+
+	if after := playing(); !slices.Equal(after, before) {
+		t.Errorf("play order: got %v, want %v", after, before)
+	}
+	if now, _ := q.CurrentTrack(); now.TrackID != cur.TrackID {
+		t.Errorf("CurrentTrack: got %s, want %s", now.TrackID, cur.TrackID)
+	}
+}
+
+func TestDeleteIndices(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Current = 3 // at "d"
+
+	if q.DeleteIndices([]int{4, 0, 2, 0, 9}) {
+		t.Error("deletedCurrent: got true")
+	}
+	assertOrder(t, q, "b", "d")
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "d" {
+		t.Errorf("CurrentTrack: got %s, want d", cur.TrackID)
+	}
+}
+
+func TestDeleteIndicesWithCurrent(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Current = 1 // at "b"
+
+	if !q.DeleteIndices([]int{1, 2}) {
+		t.Error("deletedCurrent: got false")
+	}
+	assertOrder(t, q, "a", "d", "e")
+	if cur, _ := q.CurrentTrack(); cur.TrackID != "d" {
+		t.Errorf("CurrentTrack: got %s, want d", cur.TrackID)
+	}
+}
+
+func TestDeleteIndicesAll(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Current = 1
+
+	q.DeleteIndices([]int{0, 1, 2})
+	if !q.IsEmpty() || q.ValidCurrent() {
+		t.Errorf("should be empty, got %v", ids(q))
+	}
+}
+
+// --- Snapshot / Restore ---
+
+func TestSnapshotRestore(t *testing.T) {
+	q := setup("a", "b", "c")
+	q.Current = 2
+
+	r := New()
+	if !r.Restore(q.Snapshot(), nil) {
+		t.Fatal("Restore: got false")
+	}
+	assertOrder(t, r, "a", "b", "c")
+	if r.Current != 2 {
+		t.Errorf("Current: got %d, want 2", r.Current)
+	}
+	if r.IsShuffled() {
+		t.Error("IsShuffled: got true")
+	}
+}
+
+func TestSnapshotRestoreShuffled(t *testing.T) {
+	q := setup("a", "b", "c", "d", "e")
+	q.Shuffle(rand.New(rand.NewSource(7)))
+	q.Next()
+
+	r := New()
+	r.Restore(q.Snapshot(), rand.New(rand.NewSource(8)))
+
+	if !r.IsShuffled() {
+		t.Fatal("IsShuffled: got false")
+	}
+	if !slices.Equal(r.PlayOrder(), q.PlayOrder()) {
+		t.Errorf("PlayOrder: got %v, want %v", r.PlayOrder(), q.PlayOrder())
+	}
+	if r.Current != q.Current {
+		t.Errorf("Current: got %d, want %d", r.Current, q.Current)
+	}
+
+	// Playback continues where the saved queue would have
+	q.Next()
+	r.Next()
+	if r.Current != q.Current {
+		t.Errorf("Current after Next: got %d, want %d", r.Current, q.Current)
+	}
+
+	// Tracks can still be added
+	r.AddTracks(tracks("f"))
+	if len(r.PlayOrder()) != 6 {
+		t.Errorf("PlayOrder length: got %d, want 6", len(r.PlayOrder()))
+	}
+}
+
+func TestRestoreBadOrder(t *testing.T) {
+	r := New()
+	s := Snapshot{Tracks: tracks("a", "b", "c"), Current: 1, Shuffled: true, Order: []int{0, 0, 2}}
+
+	if !r.Restore(s, nil) {
+		t.Fatal("Restore: got false")
+	}
+	if r.IsShuffled() {
+		t.Error("IsShuffled: got true for an invalid play order")
+	}
+	if r.Current != 1 {
+		t.Errorf("Current: got %d, want 1", r.Current)
+	}
+}
+
+func TestRestoreInvalidCurrent(t *testing.T) {
+	r := setup("x")
+
+	if r.Restore(Snapshot{Tracks: tracks("a"), Current: 3}, nil) {
+		t.Error("Restore: got true")
+	}
+	if r.Restore(Snapshot{}, nil) {
+		t.Error("Restore of an empty snapshot: got true")
+	}
+	assertOrder(t, r, "x")
+}
+
 // --- Edge cases ---
 
 func TestDeleteAllOneByOne(t *testing.T) {
@@ -0,0 +1,281 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// ranges are the named time ranges of ParseRange.
+var ranges = map[string]time.Duration{
+	"24h":  24 * time.Hour,
+	"7d":   7 * 24 * time.Hour,
+	"30d":  30 * 24 * time.Hour,
+	"year": 365 * 24 * time.Hour,
+	"all":  0,
+}
+
+// ParseRange returns the span of a range name: a named range ending now, or
+// a calendar year in local time.
+func ParseRange(value string, now time.Time) (from, to time.Time, err error) {
+	to = now.Add(time.Minute)
+
+	if span, ok := ranges[value]; ok {
+		if span > 0 {
+			from = now.Add(-span)
+		}
+
+		return from, to, nil
+	}
+
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1970 || year > 9999 {
+		return from, to, fmt.Errorf("invalid range %q: use 24h, 7d, 30d, year, all or a year", value)
+	}
+
+	from = time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+
+	return from, from.AddDate(1, 0, 0), nil
+}
+
+// FormatHours formats a listening time in hours and minutes.
+func FormatHours(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Print prints the report as tables.
+func Print(w io.Writer, s structures.ListeningStats) {
+	period := "All time"
+	if !s.From.IsZero() {
+		period = s.From.Local().Format("2006-01-02") + " to " + s.To.Local().Format("2006-01-02")
+	}
+
+	fmt.Fprintf(w, "Listening statistics: %s\n\n", period)
+
+	if s.Plays == 0 {
+		fmt.Fprintln(w, "Nothing played in this time range.")
+		return
+	}
+
+	fmt.Fprintf(w, "Listening time: %s\nPlays:          %d\nTracks:         %d\nArtists:        %d\n",
+		FormatHours(s.Played), s.Plays, s.Tracks, s.Artists)
+
+	section := func(title string, header ...string) *table {
+		fmt.Fprintf(w, "\n%s\n", title)
+		return &table{rows: [][]string{header}}
+	}
+
+	t := section("Top tracks", "#", "TRACK", "ARTISTS", "PLAYS", "TIME")
+	for i, track := range s.TopTracks {
+		t.add(strconv.Itoa(i+1), track.Track.Title, strings.Join(track.Track.Artists, ", "), strconv.Itoa(track.Plays), FormatHours(track.Played))
+	}
+	t.write(w)
+
+	t = section("Top artists", "#", "ARTIST", "PLAYS", "TIME")
+	for i, a := range s.TopArtists {
+		t.add(strconv.Itoa(i+1), a.Name, strconv.Itoa(a.Plays), FormatHours(a.Played))
+	}
+	t.write(w)
+
+	t = section("Top albums", "#", "ALBUM", "ARTIST", "PLAYS", "TIME")
+	for i, a := range s.TopAlbums {
+		t.add(strconv.Itoa(i+1), a.Name, a.Artist, strconv.Itoa(a.Plays), FormatHours(a.Played))
+	}
+	t.write(w)
+
+	t = section("By hour of day", "HOUR", "TIME", "")
+	for hour, d := range s.ByHour {
+		t.add(fmt.Sprintf("%02d:00", hour), FormatHours(d), bar(d, slices.Max(s.ByHour[:])))
+	}
+	t.write(w)
+
+	t = section("By weekday", "DAY", "TIME", "")
+	for day, d := range s.ByWeekday {
+		t.add(time.Weekday(day).String()[:3], FormatHours(d), bar(d, slices.Max(s.ByWeekday[:])))
+	}
+	t.write(w)
+
+	t = section("Longest streaks", "DAYS", "FROM", "TO")
+	for _, streak := range s.Streaks {
+		t.add(strconv.Itoa(streak.Days), streak.Start.Format("2006-01-02"), streak.End.Format("2006-01-02"))
+	}
+	t.write(w)
+
+	if len(s.NewArtists) > 0 {
+		t = section("New artists", "ARTIST", "FIRST PLAYED", "PLAYS")
+		for _, a := range s.NewArtists {
+			t.add(a.Name, a.First.Local().Format("2006-01-02"), strconv.Itoa(a.Plays))
+		}
+		t.write(w)
+	}
+}
+
+// table lines up columns by display width, which tabwriter does not
+// do for wide characters such as CJK titles.
+type table struct {
+	rows [][]string
+}
+
+func (t *table) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+func (t *table) write(w io.Writer) {
+	var widths []int
+
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+
+			widths[i] = max(widths[i], runewidth.StringWidth(cell))
+		}
+	}
+
+	for _, row := range t.rows {
+		var line strings.Builder
+
+		for i, cell := range row {
+			if i < len(row)-1 {
+				cell = runewidth.FillRight(cell, widths[i]+2)
+			}
+
+			line.WriteString(cell)
+		}
+
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+}
+
+// bar draws d as a bar relative to the largest value.
+func bar(d, largest time.Duration) string {
+	const width = 30
+
+	if largest <= 0 || d <= 0 {
+		return ""
+	}
+
+	return strings.Repeat("█", max(int(int64(d)*width/int64(largest)), 1))
+}
+
+// jsonPlayStat is an artist or album in the JSON report.
+type jsonPlayStat struct {
+	Name          string `json:"name"`
+	Artist        string `json:"artist,omitempty"`
+	Plays         int    `json:"plays"`
+	PlayedSeconds int64  `json:"played_seconds"`
+}
+
+// PrintJSON prints the report as JSON, with times in seconds.
+func PrintJSON(w io.Writer, s structures.ListeningStats) error {
+	type jsonTrack struct {
+		TrackID       string   `json:"track_id"`
+		Title         string   `json:"title"`
+		Artists       []string `json:"artists"`
+		Album         string   `json:"album,omitempty"`
+		Plays         int      `json:"plays"`
+		PlayedSeconds int64    `json:"played_seconds"`
+	}
+
+	type jsonNewArtist struct {
+		Name        string    `json:"name"`
+		FirstPlayed time.Time `json:"first_played"`
+		Plays       int       `json:"plays"`
+	}
+
+	type jsonStreak struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+		Days  int    `json:"days"`
+	}
+
+	report := struct {
+		From           *time.Time      `json:"from,omitempty"`
+		To             time.Time       `json:"to"`
+		PlayedSeconds  int64           `json:"played_seconds"`
+		Plays          int             `json:"plays"`
+		Tracks         int             `json:"tracks"`
+		Artists        int             `json:"artists"`
+		TopTracks      []jsonTrack     `json:"top_tracks"`
+		TopArtists     []jsonPlayStat  `json:"top_artists"`
+		TopAlbums      []jsonPlayStat  `json:"top_albums"`
+		HourSeconds    [24]int64       `json:"hour_seconds"`
+		WeekdaySeconds [7]int64        `json:"weekday_seconds"` // Sunday first
+		Streaks        []jsonStreak    `json:"streaks"`
+		NewArtists     []jsonNewArtist `json:"new_artists"`
+	}{
+		To:            s.To,
+		PlayedSeconds: int64(s.Played.Seconds()),
+		Plays:         s.Plays,
+		Tracks:        s.Tracks,
+		Artists:       s.Artists,
+		TopTracks:     []jsonTrack{},
+		TopArtists:    toJSONPlayStats(s.TopArtists),
+		TopAlbums:     toJSONPlayStats(s.TopAlbums),
+		Streaks:       []jsonStreak{},
+		NewArtists:    []jsonNewArtist{},
+	}
+
+	if !s.From.IsZero() {
+		report.From = &s.From
+	}
+
+	for _, t := range s.TopTracks {
+		report.TopTracks = append(report.TopTracks, jsonTrack{
+			TrackID:       t.Track.TrackID,
+			Title:         t.Track.Title,
+			Artists:       t.Track.Artists,
+			Album:         t.Track.Album,
+			Plays:         t.Plays,
+			PlayedSeconds: int64(t.Played.Seconds()),
+		})
+	}
+
+	for hour, d := range s.ByHour {
+		report.HourSeconds[hour] = int64(d.Seconds())
+	}
+
+	for day, d := range s.ByWeekday {
+		report.WeekdaySeconds[day] = int64(d.Seconds())
+	}
+
+	for _, streak := range s.Streaks {
+		report.Streaks = append(report.Streaks, jsonStreak{
+			Start: streak.Start.Format("2006-01-02"),
+			End:   streak.End.Format("2006-01-02"),
+			Days:  streak.Days,
+		})
+	}
+
+	for _, a := range s.NewArtists {
+		report.NewArtists = append(report.NewArtists, jsonNewArtist{Name: a.Name, FirstPlayed: a.First, Plays: a.Plays})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(report)
+}
+
+func toJSONPlayStats(stats []structures.PlayStat) []jsonPlayStat {
+	out := make([]jsonPlayStat, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, jsonPlayStat{Name: s.Name, Artist: s.Artist, Plays: s.Plays, PlayedSeconds: int64(s.Played.Seconds())})
+	}
+
+	return out
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2025, time.June, 15, 12, 0, 0, 0, time.Local)
+
+	from, to, err := ParseRange("7d", now)
+	if err != nil || !from.Equal(now.AddDate(0, 0, -7)) || !to.After(now) {
+		t.Errorf("7d: got (%v, %v, %v)", from, to, err)
+	}
+
+	from, _, err = ParseRange("all", now)
+	if err != nil || !from.IsZero() {
+		t.Errorf("all: got (%v, %v), want a zero start", from, err)
+	}
+
+	from, to, err = ParseRange("2024", now)
+	if err != nil || !from.Equal(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.Local)) ||
+		!to.Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("2024: got (%v, %v, %v)", from, to, err)
+	}
+
+	for _, value := range []string{"", "week", "1969", "10000"} {
+		if _, _, err := ParseRange(value, now); err == nil {
+			t.Errorf("%q: expected error", value)
+		}
+	}
+}
+
+func TestFormatHours(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                               "0m",
+		59*time.Minute + 40*time.Second: "1h 00m",
+		12 * time.Minute:                "12m",
+		26*time.Hour + 5*time.Minute:    "26h 05m",
+	} {
+		if got := FormatHours(d); got != want {
+			t.Errorf("FormatHours(%v): got %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestTableAlignsWideCharacters(t *testing.T) {
+	tbl := &table{rows: [][]string{{"TRACK", "PLAYS"}}}
+	tbl.add("夜に駆ける", "3")
+	tbl.add("Idol", "12")
+
+	var out bytes.Buffer
+	tbl.write(&out)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"TRACK       PLAYS",
+		"夜に駆ける  3",
+		"Idol        12",
+	}
+
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPrintJSONListsAreNeverNull(t *testing.T) {
+	var out bytes.Buffer
+	if err := PrintJSON(&out, structures.ListeningStats{To: time.Now()}); err != nil {
+		t.Fatalf("PrintJSON: %v", err)
+	}
+
+	var report map[string]any
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if _, ok := report["from"]; ok {
+		t.Error("from: present for a report of all time")
+	}
+
+	for _, key := range []string{"top_tracks", "top_artists", "top_albums", "streaks", "new_artists"} {
+		if _, ok := report[key].([]any); !ok {
+			t.Errorf("%s: got %v, want a list", key, report[key])
+		}
+	}
+}
@@ -14,6 +14,133 @@ const (
 	DownloadFailed
 )
 
+// SleepMode selects when the sleep timer pauses playback.
+type SleepMode int
+
+const (
+	SleepOff           SleepMode = iota
+	SleepAfterDuration           // after a set time
+	SleepAfterTracks             // at the end of the current track, or N more after it
+)
+
+// RepeatMode selects what plays after a track ends.
+type RepeatMode int
+
+const (
+	RepeatOff RepeatMode = iota // stop at the end of the queue
+	RepeatAll                   // start over at the end of the queue
+	RepeatOne                   // play the current track again
+)
+
+// String returns the name the repeat mode is saved under.
+func (r RepeatMode) String() string {
+	switch r {
+	case RepeatAll:
+		return "all"
+	case RepeatOne:
+		return "one"
+	default:
+		return "off"
+	}
+}
+
+// Next returns the mode after r, cycling off, all, one.
+func (r RepeatMode) Next() RepeatMode {
+	return (r + 1) % 3
+}
+
+// ParseRepeatMode returns the repeat mode saved under name, and false if
+// there is none.
+func ParseRepeatMode(name string) (RepeatMode, bool) {
+	for _, r := range []RepeatMode{RepeatOff, RepeatAll, RepeatOne} {
+		if r.String() == name {
+			return r, true
+		}
+	}
+
+	return RepeatOff, false
+}
+
+// Bookmark is a named position in a track.
+type Bookmark struct {
+	CreatedAt time.Time
+	TrackID   string
+	Name      string
+	ID        int64
+	Position  time.Duration
+}
+
+// LocalPlaylist is a playlist kept in the database rather than on YouTube
+// Music.
+type LocalPlaylist struct {
+	UpdatedAt  time.Time
+	ID         string
+	Name       string
+	TrackCount int
+}
+
+// HistoryEntry is one listen of a track.
+type HistoryEntry struct {
+	PlayedAt time.Time // when the listen ended
+	Track    Track
+	ID       int64
+	Played   time.Duration // time actually listened, without skipped parts
+}
+
+// ListeningStats is a report of the listening history over a time range.
+type ListeningStats struct {
+	From       time.Time // zero for all of the history
+	To         time.Time
+	TopTracks  []TrackStat
+	TopArtists []PlayStat
+	TopAlbums  []PlayStat
+	NewArtists []NewArtist // first listened to in the range, newest first
+	Streaks    []Streak    // runs of days with listens, longest first
+	Played     time.Duration
+	Plays      int
+	Tracks     int               // distinct tracks
+	Artists    int               // distinct artists
+	ByHour     [24]time.Duration // listening time by local hour of day
+	ByWeekday  [7]time.Duration  // listening time by local weekday, Sunday first
+}
+
+// TrackStat is how much a track was listened to.
+type TrackStat struct {
+	Track  Track
+	Played time.Duration
+	Plays  int
+}
+
+// PlayStat is how much an artist or an album was listened to.
+type PlayStat struct {
+	Name   string
+	Artist string // the album's artist; empty for artists
+	Played time.Duration
+	Plays  int
+}
+
+// NewArtist is an artist first listened to in the range of a report.
+type NewArtist struct {
+	First time.Time
+	Name  string
+	Plays int
+}
+
+// Streak is a run of consecutive local days with at least one listen.
+type Streak struct {
+	Start time.Time
+	End   time.Time
+	Days  int
+}
+
+// ABLoop is a section of the current track that plays repeatedly once both
+// ends are set.
+type ABLoop struct {
+	A, B time.Duration
+	HasA bool
+	HasB bool
+}
+
 // Track represents a music track.
 // Fields ordered by size (largest first) to minimize padding on ARM64/AMD64.
 type Track struct {
@@ -21,6 +148,7 @@ type Track struct {
 	TrackID      string   `json:"track_id"`                // 16 bytes
 	Title        string   `json:"title"`                   // 16 bytes
 	Thumbnail    string   `json:"thumbnail,omitempty"`     // 16 bytes
+	Album        string   `json:"album,omitempty"`         // 16 bytes
 	AudioQuality string   `json:"audio_quality,omitempty"` // 16 bytes
 	Duration     int      `json:"duration"`                // 8 bytes (in seconds)
 	AudioBitrate int      `json:"audio_bitrate,omitempty"` // 8 bytes (kbps)
@@ -83,16 +211,26 @@ type AddTrackAction struct{ Track Track }
 type InsertTrackAfterCurrentAction struct{ Track Track }
 type DeleteTrackAction struct{}
 type DeleteTrackAtIndexAction struct{ Index int }
+type DeleteTracksAction struct{ Indices []int }
+type MoveTrackAction struct{ From, To int }
+
+// MoveTracksAction moves the tracks from Start up to but not including End
+// so that the first of them ends up at index To.
+type MoveTracksAction struct{ Start, End, To int }
+
 type ReplaceQueueAction struct{ Tracks []Track }
 type TrackStatusUpdateAction struct {
 	TrackID string
 	Status  MusicDownloadStatus
 }
+type TrackStreamableAction struct{ TrackID string }
 type SeekAction struct {
 	Position time.Duration
 }
-type ShuffleQueueAction struct{}
+type ShuffleQueueAction struct{}   // turn shuffle on, or reshuffle what is still to come
+type UnshuffleQueueAction struct{} // back to the original order
 type JumpToIndexAction struct{ Index int }
+type SetSpeedAction struct{ Speed float64 }
 
 // Equalizer actions.
 type EQSetBandGainAction struct {
@@ -100,21 +238,74 @@ type EQSetBandGainAction struct {
 	GainDB float64
 }
 type EQSetPresetAction struct{ Preset string }
+type EQCyclePresetAction struct{}
+type EQResetAction struct{}
+type EQSavePresetAction struct{ Name string }
 type EQToggleAction struct{}
 
+// SetSleepTimerAction starts the sleep timer, or cancels it with SleepOff.
+// Duration applies to SleepAfterDuration, Tracks to SleepAfterTracks.
+type SetSleepTimerAction struct {
+	Mode     SleepMode
+	Duration time.Duration
+	Tracks   int
+}
+
+// Repeat actions. CycleRepeatAction switches off, all, one in turn.
+type CycleRepeatAction struct{}
+type SetRepeatAction struct{ Mode RepeatMode }
+
+// A-B loop and bookmark actions. ABLoopAction marks A, then B, then
+// clears the loop.
+type ABLoopAction struct{}
+type JumpToBookmarkAction struct{ ID int64 }
+type DeleteBookmarkAction struct{ ID int64 }
+
+// AddBookmarkAction bookmarks a position in a track, named after the
+// position if Name is empty. Without a TrackID it bookmarks the current
+// position of the current track.
+type AddBookmarkAction struct {
+	TrackID  string
+	Name     string
+	Position time.Duration
+}
+
+// Channel actions.
+type ToggleMonoAction struct{}
+type ToggleCrossfeedAction struct{}
+type ToggleKaraokeAction struct{}
+type SetBalanceAction struct{ Balance float64 }
+
 // PlayerState represents the current state of the music player.
 // Fields ordered to minimize padding and group hot fields together.
 type PlayerState struct {
 	List         []Track                       // 24 bytes (slice header)
+	EQBands      []EQBandConfig                // 24 bytes (slice header, the active EQ chain)
+	Bookmarks    []Bookmark                    // 24 bytes (slice header, of the current track)
+	Loop         ABLoop                        // 24 bytes
 	MusicStatus  map[string]MusicDownloadStatus // 8 bytes (pointer)
 	ListSelector *ListSelector                 // 8 bytes (pointer)
 	Volume       float64                       // 8 bytes
+	Speed        float64                       // 8 bytes
+	Buffered     float64                       // 8 bytes (fraction of the current track downloaded)
+	EQPreamp     float64                       // 8 bytes
+	Clipped      int                           // 8 bytes (samples of the current track that went over full scale)
+	Balance      float64                       // 8 bytes
+	SleepLeft    time.Duration                 // 8 bytes (until the sleep timer pauses, when known)
+	SleepTracks  int                           // 8 bytes (tracks to play after the current one)
+	SleepMode    SleepMode                     // 8 bytes
+	Repeat       RepeatMode                    // 8 bytes
 	CurrentTime  time.Duration                 // 8 bytes
 	TotalTime    time.Duration                 // 8 bytes
 	Current      int                           // 8 bytes
+	EQPreset     string                        // 16 bytes (key of the active preset, empty for custom gains)
 	EQGains      [10]float64                  // 80 bytes
 	IsPlaying    bool                          // 1 byte
-	EQEnabled    bool                          // 1 byte + 6 padding
+	EQEnabled    bool                          // 1 byte
+	Mono         bool                          // 1 byte
+	Crossfeed    bool                          // 1 byte
+	Karaoke      bool                          // 1 byte
+	Shuffled     bool                          // 1 byte + 1 padding
 }
 
 // ListSelector manages list navigation.
@@ -142,16 +333,35 @@ type Config struct {
 	// Download Configuration
 	DownloadDir            string `toml:"download_dir"`
 	MaxConcurrentDownloads int    `toml:"max_concurrent_downloads"`
-	MaxCacheSize           int64  `toml:"max_cache_size"` // in MB
-	AudioQuality           string `toml:"audio_quality"`  // Audio quality: low/medium/high/best
+	MaxCacheSize           int64  `toml:"max_cache_size"`  // in MB
+	AudioQuality           string `toml:"audio_quality"`   // Audio quality: low/medium/high/best
+	DownloadFormat         string `toml:"download_format"` // "original" keeps Opus/AAC, "mp3" transcodes
 
 	// Player Configuration
-	DefaultVolume float64 `toml:"default_volume"`
-	SeekSeconds   int     `toml:"seek_seconds"`
+	DefaultVolume    float64 `toml:"default_volume"`
+	SeekSeconds      int     `toml:"seek_seconds"`
+	CrossfadeSeconds float64 `toml:"crossfade_seconds"` // Overlap between tracks; 0 plays gaplessly
+	Normalization    string  `toml:"normalization"`     // Loudness normalization: "off", "track" or "album"
+	AudioOutput      string  `toml:"audio_output"`      // "speaker", "null" or "wav"
+	AudioOutputPath  string  `toml:"audio_output_path"` // File written by the wav output
+	OutputSampleRate int     `toml:"output_sample_rate"` // Rate the output runs at; other sources are resampled
+	Preamp           float64 `toml:"preamp"`             // Gain in dB in front of the limiter
+	Limiter          bool    `toml:"limiter"`            // Keep the output within full scale with a look-ahead limiter
+	Mono             bool    `toml:"mono"`               // Downmix to mono, e.g. for one earbud
+	Balance          float64 `toml:"balance"`            // -1 (left) to 1 (right)
+	Crossfeed        bool    `toml:"crossfeed"`          // Bauer crossfeed for headphones
+	Karaoke          bool    `toml:"karaoke"`            // Cancel centre-panned vocals
+	SmartShuffle     bool    `toml:"smart_shuffle"`      // Keep tracks by the same artist apart when shuffling
+	ResumeOnStart    bool    `toml:"resume_on_start"`    // Restore the last session's queue and position, paused
+
+	// Listening history: a listen counts as a play once either threshold is reached
+	PlayThresholdPercent float64 `toml:"play_threshold_percent"` // Share of the track, 0 to 100
+	PlayThresholdSeconds int     `toml:"play_threshold_seconds"` // Time listened, for long tracks
 
 	// Equalizer Configuration
 	EQPreset string       `toml:"eq_preset"` // Preset name: "flat", "bass_boost", "vocal", etc.
 	EQBands  [10]float64  `toml:"eq_bands"`  // Custom band gains in dB (-12 to +12)
+	EQPresets map[string]EQPresetConfig `toml:"eq_presets,omitempty"` // User-defined parametric presets by key
 
 	// Authentication Configuration
 	Browser        string `toml:"browser"`         // Browser to read cookies from: "chrome", "chrome-canary", "chromium"
@@ -161,6 +371,21 @@ type Config struct {
 	DisableAltScreen bool `toml:"disable_alt_screen"` // Disable alternate screen for Kitty graphics compatibility
 }
 
+// EQPresetConfig is a user-defined parametric EQ preset.
+type EQPresetConfig struct {
+	Name   string         `toml:"name"`   // Display name; defaults to the preset key
+	Preamp float64        `toml:"preamp"` // Gain in dB applied before the bands
+	Bands  []EQBandConfig `toml:"bands"`
+}
+
+// EQBandConfig is one filter of a user-defined EQ preset.
+type EQBandConfig struct {
+	Type string  `toml:"type"` // peaking, low_shelf, high_shelf, high_pass or low_pass
+	Freq float64 `toml:"freq"` // Center or corner frequency in Hz
+	Gain float64 `toml:"gain"` // dB (-12 to +12); ignored by the pass filters
+	Q    float64 `toml:"q"`    // Bandwidth; 0 uses a default for the type
+}
+
 // Theme represents the UI theme configuration.
 type Theme struct {
 	Background       string `toml:"background"`         // Note: Not used to avoid partial background coloring
@@ -171,6 +396,7 @@ type Theme struct {
 	ProgressBar      string `toml:"progress_bar"`       // Progress bar background
 	ProgressBarFill  string `toml:"progress_bar_fill"`  // Progress bar fill color
 	ProgressBarStyle string `toml:"progress_bar_style"` // Progress bar style: "line", "block", "gradient"
+	SpectrumStyle    string `toml:"spectrum_style"`     // Spectrum analyzer style: "bars", "braille", "off"
 }
 
 // KeyBindings represents configurable keyboard shortcuts.
@@ -196,6 +422,42 @@ type KeyBindings struct {
 
 	// Equalizer
 	ToggleEQ string `toml:"toggle_eq"`
+	EQEditor string `toml:"eq_editor"`
+
+	// Playback speed (player pane)
+	SpeedUp   string `toml:"speed_up"`
+	SpeedDown string `toml:"speed_down"`
+
+	// Sleep timer (player pane)
+	SleepTimer string `toml:"sleep_timer"`
+
+	// Repeat off/all/one
+	Repeat string `toml:"repeat"`
+
+	// Queue editing (queue pane)
+	MoveTrackUp   string `toml:"move_track_up"`
+	MoveTrackDown string `toml:"move_track_down"`
+	MoveToTop     string `toml:"move_to_top"`
+	MoveToEnd     string `toml:"move_to_end"`
+	VisualSelect  string `toml:"visual_select"`
+
+	// Add tracks to a local playlist (queue, playlist detail; ctrl+p in search)
+	AddToPlaylist string `toml:"add_to_playlist"`
+
+	// A-B loop and bookmarks
+	ABLoop    string `toml:"ab_loop"`
+	Bookmarks string `toml:"bookmarks"`
+
+	// Listening history and statistics
+	History string `toml:"history"`
+	Stats   string `toml:"stats"`
+
+	// Channel tools
+	ToggleMono      string `toml:"toggle_mono"`
+	ToggleCrossfeed string `toml:"toggle_crossfeed"`
+	ToggleKaraoke   string `toml:"toggle_karaoke"`
+	BalanceLeft     string `toml:"balance_left"`
+	BalanceRight    string `toml:"balance_right"`
 }
 
 // Database entry structure.
@@ -204,4 +466,11 @@ type DatabaseEntry struct {
 	AddedAt  time.Time
 	FilePath string
 	FileSize int64
+	Loudness *Loudness // nil until the file has been analyzed
+}
+
+// Loudness holds the measured loudness of a downloaded file.
+type Loudness struct {
+	IntegratedLUFS float64 // EBU R128 integrated loudness
+	TruePeakDBTP   float64 // True peak in dBTP
 }
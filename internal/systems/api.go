@@ -213,6 +213,7 @@ func (as *APISystem) GetPlaylistTracks(playlistID string) ([]structures.Track, e
 			Title:       v.Title,
 			Artists:     v.Artists,
 			Thumbnail:   v.Thumbnail,
+			Album:       v.Album,
 			Duration:    v.Duration,
 			IsAvailable: v.IsAvailable,
 			IsExplicit:  v.IsExplicit,
@@ -262,6 +263,7 @@ func (as *APISystem) Search(query string) (*SearchResults, error) {
 			Title:       v.Title,
 			Artists:     v.Artists,
 			Thumbnail:   v.Thumbnail,
+			Album:       v.Album,
 			Duration:    v.Duration,
 			IsAvailable: v.IsAvailable,
 			IsExplicit:  v.IsExplicit,
@@ -299,6 +301,7 @@ type Playlist struct {
 	Description string
 	Thumbnail   string
 	VideoCount  int
+	Local       bool // kept in the database, see Systems.LocalPlaylists
 }
 
 // SearchResults contains search results.
@@ -484,6 +487,7 @@ func (as *APISystem) GetSections() ([]structures.Section, error) {
 				Title:       track.Title,
 				Artists:     track.Artists,
 				Thumbnail:   track.Thumbnail,
+				Album:       track.Album,
 				Duration:    track.Duration,
 				IsAvailable: track.IsAvailable,
 				IsExplicit:  track.IsExplicit,
@@ -521,6 +525,7 @@ func (as *APISystem) GetSections() ([]structures.Section, error) {
 					Title:       track.Title,
 					Artists:     track.Artists,
 					Thumbnail:   track.Thumbnail,
+					Album:       track.Album,
 					Duration:    track.Duration,
 					IsAvailable: track.IsAvailable,
 					IsExplicit:  track.IsExplicit,
@@ -0,0 +1,197 @@
+package systems
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// minLoopLength is the shortest A-B loop. Seeks in MP3s land on a nearby
+// frame and each seek is followed by a cooldown, so much shorter loops
+// could not repeat reliably.
+const minLoopLength = time.Second
+
+// loopSeekSettle is how long position updates are suppressed after the
+// loop seeks back to A, as for the seek keys.
+const loopSeekSettle = 200 * time.Millisecond
+
+// currentTrackID returns the ID of the current track, or "".
+func (ps *PlayerSystem) currentTrackID() string {
+	track, ok := ps.queue.CurrentTrack()
+	if !ok {
+		return ""
+	}
+
+	return track.TrackID
+}
+
+// markLoop sets A at the current position, then B, then clears the loop.
+func (ps *PlayerSystem) markLoop() {
+	if ps.player == nil || !ps.validatePlayerState() {
+		return
+	}
+
+	pos := ps.player.GetPosition()
+	loop := &ps.state.Loop
+
+	switch {
+	case !loop.HasA:
+		*loop = structures.ABLoop{A: pos, HasA: true}
+		ps.loopTrack = ps.currentTrackID()
+		logger.Debug("Loop start set at %v", pos)
+
+	case !loop.HasB:
+		if pos < loop.A+minLoopLength {
+			logger.Debug("Loop end %v too close to start %v, ignoring", pos, loop.A)
+			return
+		}
+
+		loop.B = pos
+		loop.HasB = true
+		logger.Debug("Looping %v to %v", loop.A, loop.B)
+
+	default:
+		ps.clearLoop()
+	}
+}
+
+// clearLoop removes the A-B loop.
+func (ps *PlayerSystem) clearLoop() {
+	ps.state.Loop = structures.ABLoop{}
+	ps.loopTrack = ""
+}
+
+// checkLoop seeks back to A once playback passes B. Called from the update
+// loop with ps.mu held, which is skipped while another seek settles; a seek
+// still in its cooldown is not repeated either, so an approximate landing
+// point cannot cause a second jump.
+func (ps *PlayerSystem) checkLoop() {
+	loop := ps.state.Loop
+	if !loop.HasA {
+		return
+	}
+
+	// The loop belongs to the track it was set in
+	if ps.loopTrack != ps.currentTrackID() {
+		ps.clearLoop()
+		return
+	}
+
+	if !loop.HasB || !ps.state.IsPlaying || ps.state.CurrentTime < loop.B || ps.player.IsRecentSeek() {
+		return
+	}
+
+	atomic.StoreInt32(&ps.skipUpdate, 1)
+
+	if err := ps.player.Seek(loop.A); err != nil {
+		logger.Error("Failed to seek to loop start: %v", err)
+	} else {
+		ps.state.CurrentTime = ps.player.GetPosition()
+	}
+
+	go func() {
+		time.Sleep(loopSeekSettle)
+		atomic.StoreInt32(&ps.skipUpdate, 0)
+	}()
+}
+
+// refreshBookmarks loads the bookmarks of the current track when it
+// changes.
+func (ps *PlayerSystem) refreshBookmarks() {
+	trackID := ps.currentTrackID()
+	if trackID == ps.bookmarksTrack {
+		return
+	}
+
+	ps.bookmarksTrack = trackID
+	ps.state.Bookmarks = nil
+
+	if trackID != "" {
+		ps.state.Bookmarks = ps.database.GetBookmarks(trackID)
+	}
+}
+
+// addBookmark saves a bookmark, by default at the current position.
+func (ps *PlayerSystem) addBookmark(a structures.AddBookmarkAction) {
+	bookmark := structures.Bookmark{
+		TrackID:  a.TrackID,
+		Name:     a.Name,
+		Position: a.Position,
+	}
+
+	if bookmark.TrackID == "" {
+		if ps.player == nil || !ps.validatePlayerState() {
+			return
+		}
+
+		bookmark.TrackID = ps.currentTrackID()
+		bookmark.Position = ps.player.GetPosition()
+	}
+
+	if bookmark.Name == "" {
+		bookmark.Name = formatPosition(bookmark.Position)
+	}
+
+	if _, err := ps.database.AddBookmark(bookmark); err != nil {
+		logger.Error("Failed to save bookmark: %v", err)
+		return
+	}
+
+	ps.reloadBookmarks()
+}
+
+// jumpToBookmark seeks to a bookmark of the current track.
+func (ps *PlayerSystem) jumpToBookmark(id int64) {
+	if ps.player == nil || !ps.validatePlayerState() {
+		return
+	}
+
+	ps.refreshBookmarks()
+
+	for _, bookmark := range ps.state.Bookmarks {
+		if bookmark.ID != id {
+			continue
+		}
+
+		atomic.StoreInt32(&ps.skipUpdate, 1)
+
+		if err := ps.player.Seek(bookmark.Position); err != nil {
+			logger.Error("Failed to seek to bookmark: %v", err)
+		} else {
+			ps.state.CurrentTime = ps.player.GetPosition()
+			logger.Debug("Jumped to bookmark %s at %v", bookmark.Name, bookmark.Position)
+		}
+
+		go func() {
+			time.Sleep(loopSeekSettle)
+			atomic.StoreInt32(&ps.skipUpdate, 0)
+		}()
+
+		return
+	}
+}
+
+// deleteBookmark removes a bookmark.
+func (ps *PlayerSystem) deleteBookmark(id int64) {
+	if err := ps.database.RemoveBookmark(id); err != nil {
+		logger.Error("Failed to delete bookmark: %v", err)
+		return
+	}
+
+	ps.reloadBookmarks()
+}
+
+// reloadBookmarks reads the bookmarks of the current track again.
+func (ps *PlayerSystem) reloadBookmarks() {
+	ps.bookmarksTrack = ""
+	ps.refreshBookmarks()
+}
+
+// formatPosition formats a track position as m:ss.
+func formatPosition(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,57 @@
+package systems
+
+import (
+	"encoding/json"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/player"
+)
+
+// channelsStateKey is the app state key the channel tools are saved under,
+// as JSON.
+const channelsStateKey = "channels"
+
+// restoreChannels applies the channel tools changed in the last run, or
+// those set in the config if they were never changed with their keys.
+func (ps *PlayerSystem) restoreChannels() {
+	settings := player.ChannelSettings{
+		Mono:      ps.config.Mono,
+		Balance:   ps.config.Balance,
+		Crossfeed: ps.config.Crossfeed,
+		Karaoke:   ps.config.Karaoke,
+	}
+
+	if data, ok := ps.database.GetAppState(channelsStateKey); ok {
+		if err := json.Unmarshal([]byte(data), &settings); err != nil {
+			logger.Warn("Failed to read saved channel settings: %v", err)
+		}
+	}
+
+	ps.player.SetChannelSettings(settings)
+}
+
+// updateChannels changes the channel tools and remembers them for the next
+// start.
+func (ps *PlayerSystem) updateChannels(change func(*player.ChannelSettings)) {
+	if ps.player == nil {
+		return
+	}
+
+	settings := ps.player.GetChannelSettings()
+	change(&settings)
+	ps.player.SetChannelSettings(settings)
+
+	// The player clamps the balance
+	settings = ps.player.GetChannelSettings()
+
+	logger.Debug("Channel tools: mono=%t balance=%.2f crossfeed=%t karaoke=%t",
+		settings.Mono, settings.Balance, settings.Crossfeed, settings.Karaoke)
+
+	data, err := json.Marshal(settings)
+	if err == nil {
+		err = ps.database.SetAppState(channelsStateKey, string(data))
+	}
+	if err != nil {
+		logger.Error("Failed to save channel settings: %v", err)
+	}
+}
@@ -1,11 +1,15 @@
 package systems
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +18,7 @@ import (
 	"github.com/haryoiro/yutemal/internal/constants"
 	"github.com/haryoiro/yutemal/internal/database"
 	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/player"
 	"github.com/haryoiro/yutemal/internal/structures"
 )
 
@@ -26,6 +31,7 @@ type DownloadSystem struct {
 	cookiesFile       string // Path to cookies file for yt-dlp
 	browserCookiesArg string // yt-dlp --cookies-from-browser value (e.g., "chrome:Default")
 	queue             chan structures.Track
+	loudnessQueue     chan string // downloaded tracks to measure
 	workers           int
 	wg                sync.WaitGroup
 	ctx               context.Context
@@ -33,8 +39,24 @@ type DownloadSystem struct {
 	inProgressMu sync.RWMutex
 	inProgress   map[string]bool
 	statusCallback    func(trackID string, status structures.MusicDownloadStatus)
+	streamCallback    func(trackID string)
+	partialMu         sync.Mutex
+	partials          map[string]*player.PartialDownload
 }
 
+// streamStartBytes is how much of a track must be on disk before playback
+// of the partial download can start: enough for the container headers and
+// a few seconds of audio.
+const streamStartBytes = 128 * 1024
+
+// progressPrefix marks the progress lines yt-dlp prints for us.
+const progressPrefix = "[yutemal-progress]"
+
+// progressTemplate makes yt-dlp report the file being written and its
+// progress on one line each update.
+const progressTemplate = "download:" + progressPrefix +
+	" %(progress.tmpfilename)s|%(progress.downloaded_bytes)s|%(progress.total_bytes)s|%(progress.total_bytes_estimate)s"
+
 // NewDownloadSystem creates a new download system.
 func NewDownloadSystem(cfg *structures.Config, db database.DB, cacheDir string) *DownloadSystem {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -45,15 +67,17 @@ func NewDownloadSystem(cfg *structures.Config, db database.DB, cacheDir string)
 	}
 
 	return &DownloadSystem{
-		config:      cfg,
-		database:    db,
-		cacheDir:    cacheDir,
-		downloadDir: downloadDir,
-		queue:       make(chan structures.Track, constants.DefaultQueueSize),
-		workers:     cfg.MaxConcurrentDownloads,
-		ctx:         ctx,
-		cancel:      cancel,
-		inProgress:  make(map[string]bool),
+		config:        cfg,
+		database:      db,
+		cacheDir:      cacheDir,
+		downloadDir:   downloadDir,
+		queue:         make(chan structures.Track, constants.DefaultQueueSize),
+		loudnessQueue: make(chan string, constants.DefaultQueueSize),
+		workers:       cfg.MaxConcurrentDownloads,
+		ctx:           ctx,
+		cancel:        cancel,
+		inProgress:    make(map[string]bool),
+		partials:      make(map[string]*player.PartialDownload),
 	}
 }
 
@@ -64,6 +88,8 @@ func (ds *DownloadSystem) Start() error {
 		go ds.worker(i)
 	}
 
+	go ds.loudnessWorker()
+
 	return nil
 }
 
@@ -82,6 +108,26 @@ func (ds *DownloadSystem) SetStatusCallback(callback func(trackID string, status
 	ds.statusCallback = callback
 }
 
+// SetStreamCallback sets the callback invoked once enough of a track has
+// been downloaded to start playing it.
+func (ds *DownloadSystem) SetStreamCallback(callback func(trackID string)) {
+	ds.streamCallback = callback
+}
+
+// PartialDownload returns the in-progress download of a track if enough of
+// it is on disk to start playback.
+func (ds *DownloadSystem) PartialDownload(trackID string) (*player.PartialDownload, bool) {
+	ds.partialMu.Lock()
+	d, ok := ds.partials[trackID]
+	ds.partialMu.Unlock()
+
+	if !ok || d.Written() < streamStartBytes {
+		return nil, false
+	}
+
+	return d, true
+}
+
 // SetHeaderFile sets the header file for authentication and creates cookies file.
 func (ds *DownloadSystem) SetHeaderFile(headerPath string) error {
 	// Read header file
@@ -196,7 +242,10 @@ func (ds *DownloadSystem) worker(id int) {
 			// Emit downloading status
 			ds.emitStatus(track.TrackID, structures.Downloading)
 
-			if err := ds.downloadTrack(track); err != nil {
+			err := ds.downloadTrack(track)
+			ds.finishPartial(track.TrackID, err)
+
+			if err != nil {
 				logger.Error("Worker %d: Failed to download %s (%s - %s): %v", id, track.TrackID, track.Title, track.Artists, err)
 				// Emit failed status
 				ds.emitStatus(track.TrackID, structures.DownloadFailed)
@@ -204,6 +253,12 @@ func (ds *DownloadSystem) worker(id int) {
 				logger.Debug("Worker %d: Successfully downloaded %s (%s)", id, track.TrackID, track.Title)
 				// Emit downloaded status
 				ds.emitStatus(track.TrackID, structures.Downloaded)
+
+				// Playable already; normalization waits for the measurement
+				select {
+				case ds.loudnessQueue <- track.TrackID:
+				case <-ds.ctx.Done():
+				}
 			}
 
 			// Remove from in-progress
@@ -219,10 +274,8 @@ func (ds *DownloadSystem) worker(id int) {
 
 // downloadTrack downloads a single track using yt-dlp with retry mechanism.
 func (ds *DownloadSystem) downloadTrack(track structures.Track) error {
-	outputPath := filepath.Join(ds.downloadDir, track.TrackID+".mp3")
-
 	// Check if already downloaded
-	if _, err := os.Stat(outputPath); err == nil {
+	if outputPath, ok := findCachedAudio(ds.downloadDir, track.TrackID); ok {
 		logger.Debug("track %s already downloaded", track.TrackID)
 		return ds.updateDatabase(track, outputPath)
 	}
@@ -252,23 +305,17 @@ func (ds *DownloadSystem) downloadTrack(track structures.Track) error {
 		}
 
 		// Build yt-dlp command with cookies if available
-		// Get yt-dlp quality value
-		ytdlpQuality, qualityOK := constants.AudioQualityMap[audioQuality]
-		if !qualityOK {
-			ytdlpQuality = constants.AudioQualityMap[constants.AudioQualityMedium]
-		}
-
-		args := []string{
-			"--extract-audio",
-			"--audio-format", "mp3",
-			"--audio-quality", ytdlpQuality,
+		args := ds.formatArgs(audioQuality)
+		args = append(args,
 			"--no-playlist",
+			"--newline",
+			"--progress-template", progressTemplate,
 			"--no-check-certificates", // Add this to avoid SSL issues
 			"--user-agent",
-			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) " +
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) "+
 				"AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
 			"--output", filepath.Join(ds.downloadDir, "%(id)s.%(ext)s"),
-		}
+		)
 
 		// Add cookies for authentication
 		if ds.browserCookiesArg != "" {
@@ -287,7 +334,7 @@ func (ds *DownloadSystem) downloadTrack(track structures.Track) error {
 		cmd := exec.CommandContext(ds.ctx, "yt-dlp", args...)
 
 		// Capture output for debugging
-		output, err := cmd.CombinedOutput()
+		output, err := ds.runYtdlp(cmd, track.TrackID)
 		if err != nil {
 			lastErr = err
 			logger.Error("yt-dlp failed for %s (attempt %d): %v\nOutput: %s", track.TrackID, retry+1, err, string(output))
@@ -300,6 +347,14 @@ func (ds *DownloadSystem) downloadTrack(track structures.Track) error {
 			continue
 		}
 
+		outputPath, found := findCachedAudio(ds.downloadDir, track.TrackID)
+		if !found {
+			lastErr = fmt.Errorf("yt-dlp finished but no audio file was written for %s", track.TrackID)
+			logger.Error("%v", lastErr)
+
+			continue
+		}
+
 		// Success - get actual file size
 		if fileInfo, statErr := os.Stat(outputPath); statErr == nil {
 			actualSizeMB := float64(fileInfo.Size()) / 1024.0 / 1024.0
@@ -315,6 +370,143 @@ func (ds *DownloadSystem) downloadTrack(track structures.Track) error {
 	return fmt.Errorf("download failed after %d attempts: %w", maxRetries, lastErr)
 }
 
+// runYtdlp runs yt-dlp, following its progress reports so that the track
+// can be played while it downloads. It returns the rest of the output.
+func (ds *DownloadSystem) runYtdlp(cmd *exec.Cmd, trackID string) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stderr = &output
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		path, written, total, ok := parseProgressLine(line)
+		if !ok {
+			output.WriteString(line)
+			output.WriteByte('\n')
+
+			continue
+		}
+
+		ds.updatePartial(trackID, path, written, total)
+	}
+
+	return output.Bytes(), cmd.Wait()
+}
+
+// parseProgressLine parses a line printed with progressTemplate. yt-dlp
+// prints NA for values it does not know.
+func parseProgressLine(line string) (path string, written, total int64, ok bool) {
+	rest, found := strings.CutPrefix(line, progressPrefix+" ")
+	if !found {
+		return "", 0, 0, false
+	}
+
+	fields := strings.Split(rest, "|")
+	if len(fields) != 4 {
+		return "", 0, 0, false
+	}
+
+	parse := func(s string) int64 {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0
+		}
+		return int64(v)
+	}
+
+	total = parse(fields[2])
+	if total <= 0 {
+		total = parse(fields[3])
+	}
+
+	return fields[0], parse(fields[1]), total, fields[0] != "NA"
+}
+
+// updatePartial records download progress for a track, and announces the
+// track once enough of it is on disk to start playback.
+func (ds *DownloadSystem) updatePartial(trackID, path string, written, total int64) {
+	ds.partialMu.Lock()
+	d, exists := ds.partials[trackID]
+	if !exists || d.Path() != path {
+		if exists {
+			// A retry picked another format; readers of the old file stop.
+			d.Finish(fmt.Errorf("download restarted as %s", path))
+		}
+
+		d = player.NewPartialDownload(path)
+		ds.partials[trackID] = d
+	}
+	ds.partialMu.Unlock()
+
+	before := d.Written()
+	d.Progress(written, total)
+
+	if before < streamStartBytes && d.Written() >= streamStartBytes && ds.streamCallback != nil {
+		ds.streamCallback(trackID)
+	}
+}
+
+// finishPartial ends the in-progress download of a track, waking readers
+// that wait for more data.
+func (ds *DownloadSystem) finishPartial(trackID string, err error) {
+	ds.partialMu.Lock()
+	d, exists := ds.partials[trackID]
+	delete(ds.partials, trackID)
+	ds.partialMu.Unlock()
+
+	if exists {
+		d.Finish(err)
+	}
+}
+
+// formatArgs returns the yt-dlp arguments that select the audio stream.
+// By default YouTube's native Opus or AAC stream is kept as is; the mp3
+// download format re-encodes it instead.
+func (ds *DownloadSystem) formatArgs(audioQuality string) []string {
+	if ds.config.DownloadFormat == constants.DownloadFormatMP3 {
+		ytdlpQuality, qualityOK := constants.AudioQualityMap[audioQuality]
+		if !qualityOK {
+			ytdlpQuality = constants.AudioQualityMap[constants.AudioQualityMedium]
+		}
+
+		return []string{
+			"--extract-audio",
+			"--audio-format", "mp3",
+			"--audio-quality", ytdlpQuality,
+		}
+	}
+
+	selector, ok := constants.AudioFormatSelectorMap[audioQuality]
+	if !ok {
+		selector = constants.AudioFormatSelectorMap[constants.AudioQualityMedium]
+	}
+
+	return []string{"--format", selector}
+}
+
+// findCachedAudio returns the downloaded file for a track, whatever
+// container it was saved in.
+func findCachedAudio(downloadDir, trackID string) (string, bool) {
+	for _, ext := range constants.AudioExtensions {
+		path := filepath.Join(downloadDir, trackID+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
 // updateDatabase updates the database with download info.
 func (ds *DownloadSystem) updateDatabase(track structures.Track, filePath string) error {
 	// Get file size
@@ -353,6 +545,53 @@ func (ds *DownloadSystem) updateDatabase(track structures.Track, filePath string
 	return nil
 }
 
+// loudnessWorker measures the loudness of downloaded tracks one at a time,
+// apart from the download workers. It does not hold up Stop; a measurement
+// finished after it is dropped.
+func (ds *DownloadSystem) loudnessWorker() {
+	for {
+		select {
+		case trackID := <-ds.loudnessQueue:
+			ds.analyzeLoudness(trackID)
+		case <-ds.ctx.Done():
+			return
+		}
+	}
+}
+
+// analyzeLoudness measures the integrated loudness and true peak of a
+// downloaded track and stores them with its database entry.
+func (ds *DownloadSystem) analyzeLoudness(trackID string) {
+	entry, ok := ds.database.Get(trackID)
+	if !ok || entry.Loudness != nil {
+		return
+	}
+
+	lufs, peak, err := player.AnalyzeLoudness(entry.FilePath)
+	if errors.Is(err, player.ErrNoLoudness) {
+		// Left unmeasured, so the track plays unchanged
+		logger.Debug("Loudness of %s: %v", trackID, err)
+		return
+	}
+
+	if err != nil {
+		logger.Warn("Loudness analysis failed for %s: %v", trackID, err)
+		return
+	}
+
+	if ds.ctx.Err() != nil {
+		return
+	}
+
+	entry.Loudness = &structures.Loudness{IntegratedLUFS: lufs, TruePeakDBTP: peak}
+	if err := ds.database.Add(*entry); err != nil {
+		logger.Error("Failed to store loudness for %s: %v", trackID, err)
+		return
+	}
+
+	logger.Debug("Loudness of %s: %.1f LUFS, true peak %.1f dBTP", trackID, lufs, peak)
+}
+
 // getFileBitrate uses ffprobe to get the actual bitrate of an audio file.
 func (ds *DownloadSystem) getFileBitrate(filePath string) int {
 	// Build ffprobe command
@@ -430,7 +669,7 @@ func (ds *DownloadSystem) CleanupOldFiles(maxAge time.Duration) error {
 
 		// Check if file is old enough
 		if now.Sub(info.ModTime()) > maxAge {
-			// Extract track ID from filename (format: trackID.mp3)
+			// Extract track ID from filename (format: trackID.ext)
 			trackID := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
 
 			// Check if it's in the database
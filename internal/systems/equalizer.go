@@ -0,0 +1,219 @@
+package systems
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/haryoiro/yutemal/internal/database"
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/player"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// App state keys for the equalizer: the last used preset, and the presets
+// saved from the EQ editor as JSON.
+const (
+	eqPresetStateKey       = "eq_preset"
+	eqSavedPresetsStateKey = "eq_saved_presets"
+)
+
+// loadEQPresets returns the built-in presets merged with the user presets
+// from the config and those saved from the EQ editor, and the order to
+// cycle through them in: built-ins first, then user presets by key. A user
+// preset with the key of a built-in one replaces it in place.
+func loadEQPresets(cfg *structures.Config, saved map[string]structures.EQPresetConfig) (map[string]player.EQPreset, []string) {
+	presets := make(map[string]player.EQPreset, len(player.EQPresets)+len(cfg.EQPresets)+len(saved))
+	for key, preset := range player.EQPresets {
+		presets[key] = preset
+	}
+
+	order := slices.Clone(player.PresetOrder)
+
+	user := make(map[string]structures.EQPresetConfig, len(cfg.EQPresets)+len(saved))
+	for key, c := range cfg.EQPresets {
+		user[key] = c
+	}
+	for key, c := range saved {
+		user[key] = c
+	}
+
+	userKeys := make([]string, 0, len(user))
+	for key := range user {
+		userKeys = append(userKeys, key)
+	}
+	slices.Sort(userKeys)
+
+	for _, key := range userKeys {
+		preset, err := presetFromConfig(key, user[key])
+		if err != nil {
+			logger.Warn("Ignoring EQ preset %q: %v", key, err)
+			continue
+		}
+
+		if _, builtin := presets[key]; !builtin {
+			order = append(order, key)
+		}
+		presets[key] = preset
+	}
+
+	return presets, order
+}
+
+// presetFromConfig converts and validates a user preset.
+func presetFromConfig(key string, c structures.EQPresetConfig) (player.EQPreset, error) {
+	preset := player.EQPreset{Name: c.Name, Preamp: c.Preamp}
+	if preset.Name == "" {
+		preset.Name = key
+	}
+
+	for _, b := range c.Bands {
+		preset.Bands = append(preset.Bands, player.EQBand{
+			Type: player.BandType(b.Type),
+			Freq: b.Freq,
+			Gain: b.Gain,
+			Q:    b.Q,
+		})
+	}
+
+	return preset, preset.Validate()
+}
+
+// bandsToConfig converts EQ bands to their config form.
+func bandsToConfig(bands []player.EQBand) []structures.EQBandConfig {
+	out := make([]structures.EQBandConfig, len(bands))
+	for i, b := range bands {
+		out[i] = structures.EQBandConfig{Type: string(b.Type), Freq: b.Freq, Gain: b.Gain, Q: b.Q}
+	}
+
+	return out
+}
+
+// loadSavedEQPresets reads the presets saved from the EQ editor.
+func loadSavedEQPresets(db database.DB) map[string]structures.EQPresetConfig {
+	data, ok := db.GetAppState(eqSavedPresetsStateKey)
+	if !ok {
+		return nil
+	}
+
+	var saved map[string]structures.EQPresetConfig
+	if err := json.Unmarshal([]byte(data), &saved); err != nil {
+		logger.Warn("Failed to read saved EQ presets: %v", err)
+		return nil
+	}
+
+	return saved
+}
+
+// restoreEQ applies the last used EQ preset, falling back to the one named
+// in the config or, when that is empty or "flat", its custom band gains.
+// Nothing from the config is remembered, so that editing it still takes
+// effect until a preset is picked in the player.
+func (ps *PlayerSystem) restoreEQ() {
+	if key, ok := ps.database.GetAppState(eqPresetStateKey); ok && ps.setEQPreset(key) {
+		return
+	}
+
+	custom := ps.config.EQPreset == "" || ps.config.EQPreset == "flat"
+	if custom && ps.config.EQBands != [10]float64{} {
+		ps.player.SetEQGains(ps.config.EQBands)
+		ps.state.EQPreset = ""
+
+		return
+	}
+
+	ps.setEQPreset(ps.config.EQPreset)
+}
+
+// applyEQPreset switches the equalizer to a preset and remembers it for
+// the next start. Unknown keys are ignored.
+func (ps *PlayerSystem) applyEQPreset(key string) {
+	if !ps.setEQPreset(key) {
+		logger.Warn("Unknown EQ preset: %s", key)
+		return
+	}
+
+	if err := ps.database.SetAppState(eqPresetStateKey, key); err != nil {
+		logger.Error("Failed to save EQ preset: %v", err)
+	}
+}
+
+// setEQPreset switches the equalizer to a preset, reporting false for
+// unknown keys.
+func (ps *PlayerSystem) setEQPreset(key string) bool {
+	preset, ok := ps.eqPresets[key]
+	if !ok {
+		return false
+	}
+
+	ps.player.SetEQPreset(preset)
+	ps.state.EQPreset = key
+
+	logger.Debug("EQ preset set to %s (%d bands, preamp %.1f dB)", preset.Name, len(preset.Bands), preset.Preamp)
+
+	return true
+}
+
+// cycleEQPreset switches to the preset after the current one.
+func (ps *PlayerSystem) cycleEQPreset() {
+	next := (slices.Index(ps.eqPresetOrder, ps.state.EQPreset) + 1) % len(ps.eqPresetOrder)
+	ps.applyEQPreset(ps.eqPresetOrder[next])
+}
+
+// saveEQPreset stores the current EQ curve as a user preset and makes it
+// the active one. Built-in presets cannot be overwritten, so a name that
+// clashes with one gets a "user_" key instead.
+func (ps *PlayerSystem) saveEQPreset(name string) {
+	name = strings.TrimSpace(name)
+	key := eqPresetKey(name)
+	if key == "" {
+		logger.Warn("Cannot save an EQ preset without a name")
+		return
+	}
+
+	if _, builtin := player.EQPresets[key]; builtin {
+		key = "user_" + key
+	}
+
+	preset := player.EQPreset{
+		Name:   name,
+		Preamp: ps.player.GetEQPreamp(),
+		Bands:  ps.player.GetEQBands(),
+	}
+
+	if _, exists := ps.eqPresets[key]; !exists {
+		ps.eqPresetOrder = append(ps.eqPresetOrder, key)
+	}
+	ps.eqPresets[key] = preset
+
+	saved := loadSavedEQPresets(ps.database)
+	if saved == nil {
+		saved = make(map[string]structures.EQPresetConfig)
+	}
+	saved[key] = structures.EQPresetConfig{Name: name, Preamp: preset.Preamp, Bands: bandsToConfig(preset.Bands)}
+
+	data, err := json.Marshal(saved)
+	if err == nil {
+		err = ps.database.SetAppState(eqSavedPresetsStateKey, string(data))
+	}
+	if err != nil {
+		logger.Error("Failed to save EQ preset %s: %v", name, err)
+	}
+
+	ps.applyEQPreset(key)
+}
+
+// eqPresetKey derives a preset key from a display name.
+func eqPresetKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			b.WriteRune('_')
+		}
+	}
+
+	return strings.Trim(b.String(), "_")
+}
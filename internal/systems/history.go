@@ -0,0 +1,91 @@
+package systems
+
+import (
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// maxListenStep is the largest jump in position, and the longest time,
+// between two updates that still counts as listening; a larger jump is a
+// seek, and a longer time a stall.
+const maxListenStep = time.Second
+
+// listen is the track being listened to, for the listening history.
+type listen struct {
+	track    structures.Track
+	played   time.Duration // wall-clock time listened, without skipped parts
+	heard    time.Duration // track time listened, which differs with the speed
+	duration time.Duration
+	last     time.Duration // position at the last update
+	lastAt   time.Time     // time of the last update
+}
+
+// trackListen adds the time played since the last update to the current
+// listen. A change of track ends the listen, and a new one starts once the
+// new track has loaded. Called from the update loop with ps.mu held.
+func (ps *PlayerSystem) trackListen() {
+	track, ok := ps.queue.CurrentTrack()
+	if !ok || track.TrackID != ps.listen.track.TrackID {
+		ps.endListen()
+	}
+
+	if !ok || !ps.isLoaded(track) {
+		return
+	}
+
+	position := ps.player.GetPosition()
+	now := time.Now()
+
+	if ps.listen.track.TrackID == "" {
+		ps.listen = listen{track: track, last: position, lastAt: now}
+	}
+
+	step, elapsed := position-ps.listen.last, now.Sub(ps.listen.lastAt)
+	if ps.state.IsPlaying && step > 0 && step <= maxListenStep && elapsed <= maxListenStep {
+		ps.listen.played += elapsed
+		ps.listen.heard += step
+	}
+
+	ps.listen.last = position
+	ps.listen.lastAt = now
+	ps.listen.duration = ps.player.GetDuration()
+}
+
+// endListen finishes the current listen, recording it in the history if
+// enough of the track was played to count as a play.
+func (ps *PlayerSystem) endListen() {
+	l := ps.listen
+	ps.listen = listen{}
+
+	if l.track.TrackID == "" || !ps.countsAsPlay(l) {
+		return
+	}
+
+	if err := ps.database.AddPlay(l.track, time.Now(), l.played); err != nil {
+		logger.Warn("Failed to record a play of %s: %v", l.track.TrackID, err)
+		return
+	}
+
+	logger.Debug("Recorded a play of %s (%v of %v)", l.track.TrackID, l.played.Round(time.Second), l.duration.Round(time.Second))
+}
+
+// countsAsPlay reports whether a listen reached the play threshold: a share
+// of the track, or a fixed time listened for long tracks.
+func (ps *PlayerSystem) countsAsPlay(l listen) bool {
+	if l.played <= 0 {
+		return false
+	}
+
+	if seconds := ps.config.PlayThresholdSeconds; seconds > 0 && l.played >= time.Duration(seconds)*time.Second {
+		return true
+	}
+
+	percent := ps.config.PlayThresholdPercent
+	if l.duration <= 0 || percent <= 0 {
+		return false
+	}
+
+	return float64(l.heard) >= float64(l.duration)*min(percent, 100)/100
+}
@@ -18,6 +18,10 @@ import (
 	"github.com/haryoiro/yutemal/internal/structures"
 )
 
+// skipFadeDuration is the fade applied to the outgoing track on a manual
+// next/previous.
+const skipFadeDuration = 150 * time.Millisecond
+
 // PlayerSystem manages audio playback.
 type PlayerSystem struct {
 	mu               sync.RWMutex
@@ -30,30 +34,58 @@ type PlayerSystem struct {
 	player           *player.Player
 	cacheDir         string
 	downloadCallback func(track structures.Track)
+	partialLookup    func(trackID string) (*player.PartialDownload, bool)
 	skipUpdate       int32 // Atomic flag to skip position updates during critical operations
 	apiClient        any   // API client for fetching bitrate info (optional)
+	eqPresets        map[string]player.EQPreset
+	eqPresetOrder    []string
+	sleep            sleepTimer
+	loopTrack        string        // track the A-B loop was set in
+	bookmarksTrack   string        // track state.Bookmarks were loaded for
+	resumeTrack      string        // restored track still to be seeked to resumeAt
+	resumeAt         time.Duration // position to resume resumeTrack at
+	sessionSaved     time.Time     // last time the session was saved
+	listen           listen        // for the listening history
 }
 
 // NewPlayerSystem creates a new player system.
 func NewPlayerSystem(cfg *structures.Config, db database.DB, cacheDir string) *PlayerSystem {
-	audioPlayer, err := player.New()
+	outputPath := cfg.AudioOutputPath
+	if outputPath == "" {
+		outputPath = filepath.Join(cacheDir, "output.wav")
+	}
+
+	output, err := player.NewOutput(cfg.AudioOutput, outputPath)
+	if err != nil {
+		logger.Error("Invalid audio output, using the speaker: %v", err)
+
+		output, _ = player.NewOutput(player.OutputSpeaker, "")
+	}
+
+	audioPlayer, err := player.NewWithOutput(output, cfg.OutputSampleRate)
 	if err != nil {
 		logger.Error("Failed to create audio player: %v", err)
 
 		audioPlayer = nil
 	}
 
+	eqPresets, eqPresetOrder := loadEQPresets(cfg, loadSavedEQPresets(db))
+
 	ps := &PlayerSystem{
-		config:     cfg,
-		database:   db,
-		queue:      queue.New(),
-		actionChan: make(chan structures.SoundAction, 100),
-		stopChan:   make(chan struct{}),
-		player:     audioPlayer,
-		cacheDir:   cacheDir,
+		config:        cfg,
+		database:      db,
+		queue:         queue.New(),
+		actionChan:    make(chan structures.SoundAction, 100),
+		stopChan:      make(chan struct{}),
+		player:        audioPlayer,
+		cacheDir:      cacheDir,
+		eqPresets:     eqPresets,
+		eqPresetOrder: eqPresetOrder,
 		state: &structures.PlayerState{
 			MusicStatus:  make(map[string]structures.MusicDownloadStatus),
 			Volume:       cfg.DefaultVolume,
+			Speed:        1,
+			Buffered:     1,
 			ListSelector: &structures.ListSelector{},
 		},
 	}
@@ -66,8 +98,15 @@ func NewPlayerSystem(cfg *structures.Config, db database.DB, cacheDir string) *P
 		}
 	}
 
-	// Store EQ preset to apply after first song loads
+	ps.restoreRepeat()
+
 	ps.state.EQEnabled = true
+	if ps.player != nil {
+		ps.restoreEQ()
+		ps.player.SetPreamp(cfg.Preamp)
+		ps.player.SetLimiterEnabled(cfg.Limiter)
+		ps.restoreChannels()
+	}
 
 	return ps
 }
@@ -75,6 +114,10 @@ func NewPlayerSystem(cfg *structures.Config, db database.DB, cacheDir string) *P
 // Start starts the player system.
 func (ps *PlayerSystem) Start() error {
 	// Don't reset volume here - it's already set in NewPlayerSystem
+	ps.mu.Lock()
+	ps.restoreSession()
+	ps.mu.Unlock()
+
 	go ps.run()
 	go ps.updateLoop()
 
@@ -85,6 +128,14 @@ func (ps *PlayerSystem) Start() error {
 func (ps *PlayerSystem) Stop() {
 	close(ps.stopChan)
 
+	ps.mu.Lock()
+	if ps.player != nil {
+		ps.trackListen()
+		ps.endListen()
+	}
+	ps.saveSession()
+	ps.mu.Unlock()
+
 	if ps.player != nil {
 		ps.player.Close()
 	}
@@ -95,6 +146,12 @@ func (ps *PlayerSystem) SetDownloadCallback(callback func(track structures.Track
 	ps.downloadCallback = callback
 }
 
+// SetPartialLookup sets the function used to find tracks that can be played
+// while they are still downloading.
+func (ps *PlayerSystem) SetPartialLookup(lookup func(trackID string) (*player.PartialDownload, bool)) {
+	ps.partialLookup = lookup
+}
+
 // SetAPIClient sets the API client for fetching additional track information.
 func (ps *PlayerSystem) SetAPIClient(client any) {
 	ps.mu.Lock()
@@ -119,15 +176,28 @@ func (ps *PlayerSystem) GetState() structures.PlayerState {
 	// Sync queue to state
 	ps.state.List = ps.queue.Tracks
 	ps.state.Current = ps.queue.Current
+	ps.state.Repeat = ps.queue.Repeat
+	ps.state.Shuffled = ps.queue.IsShuffled()
 
 	// Update state from audio player
 	if ps.player != nil {
 		ps.state.Volume = ps.player.GetVolume()
+		ps.state.Speed = ps.player.GetSpeed()
+		ps.state.Buffered = ps.player.Buffered()
 		ps.state.IsPlaying = ps.player.IsPlaying()
 		ps.state.CurrentTime = ps.player.GetPosition()
 		ps.state.TotalTime = ps.player.GetDuration()
 		ps.state.EQGains = ps.player.GetEQGains()
+		ps.state.EQBands = bandsToConfig(ps.player.GetEQBands())
+		ps.state.EQPreamp = ps.player.GetEQPreamp()
 		ps.state.EQEnabled = ps.player.IsEQEnabled()
+		ps.state.Clipped = ps.player.ClippedSamples()
+
+		channels := ps.player.GetChannelSettings()
+		ps.state.Mono = channels.Mono
+		ps.state.Balance = channels.Balance
+		ps.state.Crossfeed = channels.Crossfeed
+		ps.state.Karaoke = channels.Karaoke
 	}
 
 	// Create a deep copy
@@ -139,12 +209,26 @@ func (ps *PlayerSystem) GetState() structures.PlayerState {
 	return stateCopy
 }
 
+// Spectrum fills bars with the current output spectrum. It reports false,
+// leaving bars untouched, while nothing is playing.
+func (ps *PlayerSystem) Spectrum(bars []float64) bool {
+	if ps.player == nil {
+		return false
+	}
+
+	return ps.player.Spectrum(bars)
+}
+
 // run is the main loop of the player system.
 func (ps *PlayerSystem) run() {
 	for {
 		select {
 		case action := <-ps.actionChan:
+			ps.fadeOutForSkip(action)
 			ps.handleAction(action)
+			ps.mu.Lock()
+			ps.refreshPreload()
+			ps.mu.Unlock()
 
 		case <-ps.stopChan:
 			return
@@ -169,6 +253,7 @@ func (ps *PlayerSystem) updateLoop() {
 			if ps.player != nil {
 				ps.state.CurrentTime = ps.player.GetPosition()
 				ps.state.IsPlaying = ps.player.IsPlaying()
+				ps.trackListen()
 
 				// Simplified debug logging every 5 seconds
 				if int(ps.state.CurrentTime.Seconds())%5 == 0 && ps.state.CurrentTime.Milliseconds()%5000 < 100 {
@@ -176,10 +261,27 @@ func (ps *PlayerSystem) updateLoop() {
 					// ps.state.CurrentTime, ps.state.TotalTime, ps.state.IsPlaying)
 				}
 
-				// Check if we've reached the end of the current song
-				if ps.state.IsPlaying && ps.player.HasEnded() && !ps.player.IsRecentSeek() {
+				// Playback may already have continued into the preloaded track
+				if file, ok := ps.player.TakeHandoff(); ok {
+					ps.endListen()
+					ps.advanceGapless(file)
+					ps.sleepTrackEnded()
+				} else if ps.state.IsPlaying && ps.player.HasEnded() && !ps.player.IsRecentSeek() {
+					// Check if we've reached the end of the current song
 					logger.Debug("Song ended, advancing to next song")
-					ps.nextSong()
+					ps.endListen()
+					ps.songEnded()
+					ps.refreshPreload()
+					ps.sleepTrackEnded()
+				}
+
+				ps.tickSleep()
+				ps.refreshBookmarks()
+				ps.checkLoop()
+				ps.applyResume()
+
+				if ps.state.IsPlaying && time.Since(ps.sessionSaved) >= sessionSaveInterval {
+					ps.saveSession()
 				}
 			}
 			ps.mu.Unlock()
@@ -190,6 +292,116 @@ func (ps *PlayerSystem) updateLoop() {
 	}
 }
 
+// advanceGapless moves the queue forward after the player has continued
+// into the preloaded file without reloading.
+func (ps *PlayerSystem) advanceGapless(file string) {
+	if !ps.queue.Advance() {
+		// The queue ended or was cleared after the preload was issued, so
+		// stop rather than play a file the queue no longer points at.
+		logger.Debug("Gapless hand-off to %s past the end of the queue, stopping", file)
+
+		ps.state.IsPlaying = false
+		if err := ps.player.Stop(); err != nil {
+			logger.Error("Failed to stop player: %v", err)
+		}
+
+		return
+	}
+
+	next, _ := ps.queue.CurrentTrack()
+	if path, ok := ps.trackFilePath(next); !ok || path != file {
+		// The queue changed after the preload was issued; fall back to a reload.
+		logger.Debug("Gapless hand-off file %s does not match queue, reloading", file)
+		ps.loadCurrentSong()
+
+		return
+	}
+
+	ps.state.TotalTime = ps.player.GetDuration()
+	ps.state.CurrentTime = ps.player.GetPosition()
+
+	logger.Debug("Advanced gaplessly to: %s", next.Title)
+
+	ps.refreshPreload()
+}
+
+// refreshPreload asks the player to preload the next queued track, or
+// cancels the preload when the next track is no longer available.
+func (ps *PlayerSystem) refreshPreload() {
+	if ps.player == nil {
+		return
+	}
+
+	want := ""
+	opts := player.PreloadOptions{
+		Crossfade: time.Duration(ps.config.CrossfadeSeconds * float64(time.Second)),
+	}
+
+	if index, ok := ps.queue.Upcoming(); ok {
+		next := ps.queue.Tracks[index]
+		if path, ok := ps.trackFilePath(next); ok {
+			want = path
+		}
+
+		if sameAlbum(ps.queue.Tracks[ps.queue.Current], next) {
+			opts.Crossfade = 0
+		}
+
+		opts.GainDB = ps.normalizationGain(next.TrackID)
+	}
+
+	if want != ps.player.PreloadTarget() && ps.player.GetCurrentFile() != "" {
+		ps.player.Preload(want, opts)
+	}
+}
+
+// normalizationGain returns the loudness normalization gain in dB for a
+// downloaded track according to the normalization mode. Tracks that have
+// not been analyzed yet play unchanged.
+func (ps *PlayerSystem) normalizationGain(trackID string) float64 {
+	mode := ps.config.Normalization
+	if mode != "track" && mode != "album" {
+		return 0
+	}
+
+	entry, ok := ps.database.Get(trackID)
+	if !ok || entry.Loudness == nil {
+		return 0
+	}
+
+	lufs := entry.Loudness.IntegratedLUFS
+	peak := entry.Loudness.TruePeakDBTP
+
+	if mode == "album" && entry.Track.Album != "" {
+		var albumLUFS []float64
+		for _, e := range ps.database.GetByAlbum(entry.Track.Album) {
+			if e.Loudness != nil {
+				albumLUFS = append(albumLUFS, e.Loudness.IntegratedLUFS)
+				peak = max(peak, e.Loudness.TruePeakDBTP)
+			}
+		}
+
+		lufs = player.AlbumLoudness(albumLUFS)
+	}
+
+	return player.NormalizationGain(lufs, peak)
+}
+
+// sameAlbum reports whether two tracks are known to come from the same
+// album. Such tracks often run into each other, so they are never crossfaded.
+func sameAlbum(a, b structures.Track) bool {
+	return a.Album != "" && a.Album == b.Album
+}
+
+// trackFilePath returns the local file for a track if it has been downloaded.
+func (ps *PlayerSystem) trackFilePath(track structures.Track) (string, bool) {
+	if entry, exists := ps.database.Get(track.TrackID); exists {
+		return entry.FilePath, true
+	}
+
+	return findCachedAudio(filepath.Join(ps.cacheDir, "downloads"), track.TrackID)
+}
+
 // refreshDownloadStatus updates the download status for all tracks in the list.
 func (ps *PlayerSystem) refreshDownloadStatus() {
 	for _, track := range ps.queue.Tracks {
@@ -198,8 +410,7 @@ func (ps *PlayerSystem) refreshDownloadStatus() {
 			ps.state.MusicStatus[track.TrackID] = structures.Downloaded
 		} else {
 			// Check if file exists in cache
-			cachePath := filepath.Join(ps.cacheDir, "downloads", track.TrackID+".mp3")
-			if _, err := os.Stat(cachePath); err == nil {
+			if _, ok := findCachedAudio(filepath.Join(ps.cacheDir, "downloads"), track.TrackID); ok {
 				ps.state.MusicStatus[track.TrackID] = structures.Downloaded
 			} else {
 				// Check if it's currently downloading
@@ -237,6 +448,8 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 				logger.Debug("Playback paused")
 			}
 		} else {
+			ps.restoreSleepVolume()
+
 			if err := ps.player.Play(); err != nil {
 				logger.Error("Failed to start playback: %v", err)
 				ps.loadCurrentSong()
@@ -259,6 +472,7 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 
 		if !ps.state.IsPlaying {
 			ps.loadCurrentSong()
+			ps.restoreSleepVolume()
 
 			if err := ps.player.Play(); err != nil {
 				logger.Error("Failed to start playback: %v", err)
@@ -359,9 +573,11 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 
 	case structures.NextAction:
 		ps.nextSong()
+		ps.cancelSkipFade()
 
 	case structures.PreviousAction:
 		ps.previousSong()
+		ps.cancelSkipFade()
 
 	case structures.AddTracksToQueueAction:
 		ps.queue.AddTracks(a.Tracks)
@@ -402,6 +618,13 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 		// If the track just finished downloading and it's the current track, reload it
 		curTrack, curOK := ps.queue.CurrentTrack()
 		if a.Status == structures.Downloaded && curOK && curTrack.TrackID == a.TrackID {
+			// Playback from the partial download simply continues.
+			if ps.player != nil && ps.player.IsStreaming() {
+				logger.Debug("Current track download completed while streaming it")
+				ps.refreshPreload()
+
+				return
+			}
 
 			logger.Debug("Current track download completed, reloading...")
 			// Give the database a moment to sync
@@ -410,6 +633,7 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 				ps.mu.Lock()
 				defer ps.mu.Unlock()
 				ps.loadCurrentSong()
+				ps.refreshPreload()
 				// If we were trying to play, start playback now
 				if ps.state.IsPlaying && ps.player != nil {
 					if err := ps.player.Play(); err != nil {
@@ -423,12 +647,31 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 			}()
 		}
 
+	case structures.TrackStreamableAction:
+		curTrack, curOK := ps.queue.CurrentTrack()
+		if curOK && curTrack.TrackID == a.TrackID && ps.player != nil && !ps.isLoaded(curTrack) {
+			ps.loadCurrentSong()
+		}
+
 	case structures.DeleteTrackAction:
 		ps.deleteCurrentTrack()
 
 	case structures.DeleteTrackAtIndexAction:
 		ps.deleteTrackAtIndex(a.Index)
 
+	case structures.DeleteTracksAction:
+		ps.deleteTracks(a.Indices)
+
+	case structures.MoveTrackAction:
+		if !ps.queue.Move(a.From, a.To) {
+			logger.Warn("Invalid move from %d to %d (queue size: %d)", a.From, a.To, ps.queue.Len())
+		}
+
+	case structures.MoveTracksAction:
+		if !ps.queue.MoveRange(a.Start, a.End, a.To) {
+			logger.Warn("Invalid move of %d-%d to %d (queue size: %d)", a.Start, a.End, a.To, ps.queue.Len())
+		}
+
 	case structures.CleanupAction:
 		ps.queue.Clear()
 		ps.state.MusicStatus = make(map[string]structures.MusicDownloadStatus)
@@ -452,8 +695,13 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 		}
 
 	case structures.ShuffleQueueAction:
+		ps.queue.SmartShuffle = ps.config.SmartShuffle
 		ps.queue.Shuffle(rand.New(rand.NewSource(time.Now().UnixNano())))
-		logger.Debug("Queue shuffled")
+		logger.Debug("Queue shuffled (smart: %v)", ps.queue.SmartShuffle)
+
+	case structures.UnshuffleQueueAction:
+		ps.queue.Unshuffle()
+		logger.Debug("Queue unshuffled")
 
 	case structures.JumpToIndexAction:
 		if ps.queue.JumpTo(a.Index) {
@@ -463,27 +711,136 @@ func (ps *PlayerSystem) handleAction(action structures.SoundAction) {
 			logger.Warn("Invalid jump index: %d (queue size: %d)", a.Index, ps.queue.Len())
 		}
 
+	case structures.SetSpeedAction:
+		if ps.player != nil {
+			ps.player.SetSpeed(a.Speed)
+			ps.state.Speed = ps.player.GetSpeed()
+			logger.Debug("Playback speed set to %.2fx", ps.state.Speed)
+		}
+
 	case structures.EQSetBandGainAction:
 		if ps.player != nil {
 			ps.player.SetEQBandGain(a.Band, a.GainDB)
+
+			// The curve no longer matches a preset until it is saved
+			ps.state.EQPreset = ""
 		}
 
 	case structures.EQSetPresetAction:
 		if ps.player != nil {
-			if preset, ok := player.EQPresets[a.Preset]; ok {
-				ps.player.SetEQGains(preset.Gains)
-			}
+			ps.applyEQPreset(a.Preset)
+		}
+
+	case structures.EQCyclePresetAction:
+		if ps.player != nil {
+			ps.cycleEQPreset()
+		}
+
+	case structures.EQResetAction:
+		if ps.player != nil {
+			ps.applyEQPreset("flat")
+		}
+
+	case structures.EQSavePresetAction:
+		if ps.player != nil {
+			ps.saveEQPreset(a.Name)
 		}
 
 	case structures.EQToggleAction:
 		if ps.player != nil {
 			ps.player.SetEQEnabled(!ps.player.IsEQEnabled())
 		}
+
+	case structures.ABLoopAction:
+		ps.markLoop()
+
+	case structures.AddBookmarkAction:
+		ps.addBookmark(a)
+
+	case structures.JumpToBookmarkAction:
+		ps.jumpToBookmark(a.ID)
+
+	case structures.DeleteBookmarkAction:
+		ps.deleteBookmark(a.ID)
+
+	case structures.CycleRepeatAction:
+		ps.setRepeat(ps.queue.Repeat.Next())
+
+	case structures.SetRepeatAction:
+		ps.setRepeat(a.Mode)
+
+	case structures.SetSleepTimerAction:
+		ps.setSleepTimer(a)
+
+	case structures.ToggleMonoAction:
+		ps.updateChannels(func(s *player.ChannelSettings) { s.Mono = !s.Mono })
+
+	case structures.ToggleCrossfeedAction:
+		ps.updateChannels(func(s *player.ChannelSettings) { s.Crossfeed = !s.Crossfeed })
+
+	case structures.ToggleKaraokeAction:
+		ps.updateChannels(func(s *player.ChannelSettings) { s.Karaoke = !s.Karaoke })
+
+	case structures.SetBalanceAction:
+		ps.updateChannels(func(s *player.ChannelSettings) { s.Balance = a.Balance })
 	}
 }
 
-// nextSong advances to the next song - simplified version.
+// fadeOutForSkip briefly fades out the current track before a manual skip,
+// so that it does not end with a hard cut. It waits for the fade without
+// holding ps.mu, so that updates and other actions carry on meanwhile.
+func (ps *PlayerSystem) fadeOutForSkip(action structures.SoundAction) {
+	ps.mu.Lock()
+
+	var done <-chan struct{}
+
+	switch action.(type) {
+	case structures.NextAction:
+		if ps.player != nil && ps.queue.HasNext() {
+			done = ps.player.FadeOut(skipFadeDuration)
+		}
+	case structures.PreviousAction:
+		if ps.player != nil && ps.queue.HasPrevious() {
+			done = ps.player.FadeOut(skipFadeDuration)
+		}
+	}
+
+	ps.mu.Unlock()
+
+	if done == nil {
+		return
+	}
+
+	// The output renders ahead by up to its buffer size.
+	select {
+	case <-done:
+	case <-time.After(skipFadeDuration + time.Second):
+		logger.Debug("Skip fade was not rendered in time")
+	}
+}
+
+// cancelSkipFade restores the faded-out track when the skip did not load
+// another one, such as a track that is not downloaded yet.
+func (ps *PlayerSystem) cancelSkipFade() {
+	if ps.player != nil {
+		ps.player.CancelFade()
+	}
+}
+
+// nextSong skips to the next song.
 func (ps *PlayerSystem) nextSong() {
+	ps.changeSong(ps.queue.Next)
+}
+
+// songEnded moves on from a song that played to its end, which in
+// repeat-one mode means playing it again.
+func (ps *PlayerSystem) songEnded() {
+	ps.changeSong(ps.queue.Advance)
+}
+
+// changeSong moves through the queue with step and loads the new song,
+// stopping at the end of the queue - simplified version.
+func (ps *PlayerSystem) changeSong(step func() bool) {
 	// Disable updates during song transition
 	atomic.StoreInt32(&ps.skipUpdate, 1)
 
@@ -497,7 +854,7 @@ func (ps *PlayerSystem) nextSong() {
 
 	wasPlaying := ps.state.IsPlaying
 
-	if ps.queue.Next() {
+	if step() {
 		ps.loadCurrentSong()
 		if wasPlaying && ps.player != nil {
 			if err := ps.player.Play(); err != nil {
@@ -549,6 +906,8 @@ func (ps *PlayerSystem) loadCurrentSong() {
 	if entry, exists := ps.database.Get(currentTrack.TrackID); exists {
 		logger.Debug("Loading from database: %s", entry.FilePath)
 
+		ps.player.SetTrackGain(ps.normalizationGain(currentTrack.TrackID))
+
 		if err := ps.player.LoadFile(entry.FilePath); err != nil {
 			logger.Error("Failed to load file %s: %v", entry.FilePath, err)
 
@@ -559,15 +918,6 @@ func (ps *PlayerSystem) loadCurrentSong() {
 
 		ps.state.TotalTime = ps.player.GetDuration()
 
-		// Apply EQ preset on first load
-		if ps.config.EQPreset != "" && ps.config.EQPreset != "flat" {
-			if preset, ok := player.EQPresets[ps.config.EQPreset]; ok {
-				ps.player.SetEQGains(preset.Gains)
-			}
-		} else if ps.config.EQBands != [10]float64{} {
-			ps.player.SetEQGains(ps.config.EQBands)
-		}
-
 		// Update track duration with actual file duration if different
 		actualDurationSeconds := int(ps.state.TotalTime.Seconds() + 0.999)
 		if actualDurationSeconds != currentTrack.Duration && actualDurationSeconds > 0 {
@@ -605,15 +955,20 @@ func (ps *PlayerSystem) loadCurrentSong() {
 	} else {
 		// Check if it's currently downloading
 		if status, ok := ps.state.MusicStatus[currentTrack.TrackID]; ok && status == structures.Downloading {
-			logger.Debug("Track is currently downloading, waiting for completion...")
+			if !ps.loadPartial(currentTrack) {
+				logger.Debug("Track is currently downloading, waiting for enough data...")
+			}
+
 			return
 		}
 
 		// Try to find the file in cache directory
-		cachePath := filepath.Join(ps.cacheDir, "downloads", currentTrack.TrackID+".mp3")
-		logger.Debug("Trying to load from cache: %s", cachePath)
+		downloadDir := filepath.Join(ps.cacheDir, "downloads")
+		logger.Debug("Trying to load from cache: %s", downloadDir)
+
+		if cachePath, found := findCachedAudio(downloadDir, currentTrack.TrackID); found {
+			ps.player.SetTrackGain(0)
 
-		if _, err := os.Stat(cachePath); err == nil {
 			if err := ps.player.LoadFile(cachePath); err == nil {
 				ps.state.TotalTime = ps.player.GetDuration()
 				logger.Debug("Song loaded from cache, duration: %v", ps.state.TotalTime)
@@ -653,7 +1008,7 @@ func (ps *PlayerSystem) loadCurrentSong() {
 				ps.state.MusicStatus[currentTrack.TrackID] = structures.NotDownloaded
 			}
 		} else {
-			logger.Debug("File not found in cache: %s", cachePath)
+			logger.Debug("File not found in cache: %s", currentTrack.TrackID)
 
 			ps.state.MusicStatus[currentTrack.TrackID] = structures.NotDownloaded
 			// Queue for download if callback is set
@@ -665,6 +1020,45 @@ func (ps *PlayerSystem) loadCurrentSong() {
 	}
 }
 
+// isLoaded reports whether the player holds a file of the given track.
+// Downloads are named after the track ID.
+func (ps *PlayerSystem) isLoaded(track structures.Track) bool {
+	return strings.HasPrefix(filepath.Base(ps.player.GetCurrentFile()), track.TrackID+".")
+}
+
+// loadPartial starts playing a track that is still downloading, if enough
+// of it is on disk. It reports whether the track was loaded.
+func (ps *PlayerSystem) loadPartial(track structures.Track) bool {
+	if ps.partialLookup == nil {
+		return false
+	}
+
+	download, ok := ps.partialLookup(track.TrackID)
+	if !ok {
+		return false
+	}
+
+	ps.player.SetTrackGain(0)
+
+	if err := ps.player.LoadPartial(download, time.Duration(track.Duration)*time.Second); err != nil {
+		logger.Debug("Cannot stream %s while downloading: %v", track.TrackID, err)
+		return false
+	}
+
+	ps.state.TotalTime = ps.player.GetDuration()
+	logger.Debug("Streaming %s while it downloads", track.Title)
+
+	if ps.state.IsPlaying {
+		if err := ps.player.Play(); err != nil {
+			logger.Error("Failed to start playback: %v", err)
+
+			ps.state.IsPlaying = false
+		}
+	}
+
+	return true
+}
+
 // handleLoadFailure handles the case when current song fails to load.
 func (ps *PlayerSystem) handleLoadFailure() {
 	currentTrack, ok := ps.queue.CurrentTrack()
@@ -675,8 +1069,9 @@ func (ps *PlayerSystem) handleLoadFailure() {
 
 	ps.state.MusicStatus[currentTrack.TrackID] = structures.DownloadFailed
 
-	// Try to advance to next song if available
-	if ps.queue.Current+1 < ps.queue.Len() {
+	// Try to advance to next song if available. This never wraps around,
+	// so that a queue of tracks that all fail cannot loop forever.
+	if !ps.queue.AtEnd() {
 		logger.Debug("Advancing to next song due to load failure")
 		ps.nextSong()
 	} else {
@@ -781,6 +1176,47 @@ func (ps *PlayerSystem) deleteTrackAtIndex(index int) {
 	}
 }
 
+// deleteTracks removes several tracks at once. If the current track is
+// among them, playback continues with the first remaining track after it.
+func (ps *PlayerSystem) deleteTracks(indices []int) {
+	wasPlaying := ps.state.IsPlaying
+	before := ps.queue.Len()
+
+	for _, index := range indices {
+		if index >= 0 && index < ps.queue.Len() {
+			delete(ps.state.MusicStatus, ps.queue.Tracks[index].TrackID)
+		}
+	}
+
+	deletedCurrent := ps.queue.DeleteIndices(indices)
+
+	if ps.state.ListSelector != nil {
+		ps.state.ListSelector.ListSize = max(ps.state.ListSelector.ListSize-(before-ps.queue.Len()), 0)
+	}
+
+	if !deletedCurrent {
+		return
+	}
+
+	if ps.player != nil {
+		if err := ps.player.Stop(); err != nil {
+			logger.Error("Failed to stop player: %v", err)
+		}
+	}
+	ps.state.IsPlaying = false
+
+	if !ps.queue.IsEmpty() && wasPlaying {
+		ps.loadCurrentSong()
+		if ps.player != nil {
+			if err := ps.player.Play(); err != nil {
+				logger.Error("Failed to start playback: %v", err)
+			} else {
+				ps.state.IsPlaying = true
+			}
+		}
+	}
+}
+
 // fetchAndUpdateBitrate fetches bitrate information from API and updates the database.
 func (ps *PlayerSystem) fetchAndUpdateBitrate(track structures.Track) {
 	// Check if API client is available
@@ -0,0 +1,33 @@
+package systems
+
+import (
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// repeatStateKey is the app state key the repeat mode is saved under.
+const repeatStateKey = "repeat"
+
+// restoreRepeat applies the repeat mode saved by the last run.
+func (ps *PlayerSystem) restoreRepeat() {
+	name, ok := ps.database.GetAppState(repeatStateKey)
+	if !ok {
+		return
+	}
+
+	if mode, known := structures.ParseRepeatMode(name); known {
+		ps.queue.Repeat = mode
+	}
+}
+
+// setRepeat switches the repeat mode and remembers it for the next start.
+func (ps *PlayerSystem) setRepeat(mode structures.RepeatMode) {
+	ps.queue.Repeat = mode
+	ps.state.Repeat = mode
+
+	if err := ps.database.SetAppState(repeatStateKey, mode.String()); err != nil {
+		logger.Error("Failed to save repeat mode: %v", err)
+	}
+
+	logger.Debug("Repeat set to %s", mode)
+}
@@ -0,0 +1,168 @@
+package systems
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/queue"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// sessionStateKey is the app state key the playback session is saved under.
+const sessionStateKey = "session"
+
+// sessionSaveInterval is how often the session is saved during playback, so
+// that little is lost if the app does not shut down cleanly.
+const sessionSaveInterval = 30 * time.Second
+
+// session is the playback state saved across restarts.
+type session struct {
+	Queue    queue.Snapshot `json:"queue"`
+	Position time.Duration  `json:"position"`
+	Volume   float64        `json:"volume"`
+	EQ       *sessionEQ     `json:"eq,omitempty"`
+}
+
+// sessionEQ is the equalizer curve of a session, which may have been edited
+// since its preset was applied.
+type sessionEQ struct {
+	Preset  string                    `json:"preset"`
+	Preamp  float64                   `json:"preamp"`
+	Bands   []structures.EQBandConfig `json:"bands"`
+	Enabled bool                      `json:"enabled"`
+}
+
+// saveSession saves the queue, the position in the current track, the
+// volume and the equalizer. Called with ps.mu held.
+func (ps *PlayerSystem) saveSession() {
+	s := session{
+		Queue:  ps.queue.Snapshot(),
+		Volume: ps.state.Volume,
+	}
+
+	if ps.player != nil {
+		s.Volume = ps.player.GetVolume()
+		s.EQ = &sessionEQ{
+			Preset:  ps.state.EQPreset,
+			Preamp:  ps.player.GetEQPreamp(),
+			Bands:   bandsToConfig(ps.player.GetEQBands()),
+			Enabled: ps.player.IsEQEnabled(),
+		}
+
+		if track, ok := ps.queue.CurrentTrack(); ok {
+			switch {
+			case ps.resumeTrack == track.TrackID:
+				// Restored, but not loaded yet
+				s.Position = ps.resumeAt
+			case ps.isLoaded(track):
+				s.Position = ps.player.GetPosition()
+			}
+		}
+	}
+
+	// The sleep timer's fade is not the volume to come back to
+	if ps.sleep.fading || ps.sleep.restore {
+		s.Volume = ps.sleep.volume
+	}
+
+	ps.sessionSaved = time.Now()
+
+	data, err := json.Marshal(s)
+	if err == nil {
+		err = ps.database.SetAppState(sessionStateKey, string(data))
+	}
+	if err != nil {
+		logger.Error("Failed to save the session: %v", err)
+	}
+}
+
+// restoreSession restores the session saved by the last run, paused at the
+// saved position, unless resume_on_start is off.
+func (ps *PlayerSystem) restoreSession() {
+	if !ps.config.ResumeOnStart {
+		return
+	}
+
+	data, ok := ps.database.GetAppState(sessionStateKey)
+	if !ok {
+		return
+	}
+
+	var s session
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		logger.Warn("Failed to read the saved session: %v", err)
+		return
+	}
+
+	if ps.player != nil {
+		ps.setVolume(s.Volume)
+
+		if s.EQ != nil {
+			ps.restoreSessionEQ(*s.EQ)
+		}
+	}
+
+	if !ps.queue.Restore(s.Queue, rand.New(rand.NewSource(time.Now().UnixNano()))) {
+		return
+	}
+	ps.queue.SmartShuffle = ps.config.SmartShuffle
+
+	track, _ := ps.queue.CurrentTrack()
+	logger.Info("Restored %d tracks from the last session, at %s", ps.queue.Len(), track.Title)
+
+	if ps.player == nil {
+		return
+	}
+
+	ps.resumeTrack = track.TrackID
+	ps.resumeAt = s.Position
+	ps.loadCurrentSong()
+	ps.applyResume()
+}
+
+// restoreSessionEQ applies the equalizer curve of a session. A curve that
+// does not validate leaves the restored preset in place.
+func (ps *PlayerSystem) restoreSessionEQ(eq sessionEQ) {
+	preset, err := presetFromConfig(eq.Preset, structures.EQPresetConfig{Preamp: eq.Preamp, Bands: eq.Bands})
+	if err != nil {
+		logger.Warn("Ignoring the saved EQ curve: %v", err)
+		return
+	}
+
+	if current, ok := ps.eqPresets[eq.Preset]; ok {
+		preset.Name = current.Name
+	}
+
+	ps.player.SetEQPreset(preset)
+	ps.player.SetEQEnabled(eq.Enabled)
+	ps.state.EQPreset = eq.Preset
+}
+
+// applyResume seeks to the restored position once the restored track has
+// loaded, which may take until it is downloaded. Called from the update
+// loop with ps.mu held.
+func (ps *PlayerSystem) applyResume() {
+	if ps.resumeTrack == "" {
+		return
+	}
+
+	track, ok := ps.queue.CurrentTrack()
+	if !ok || track.TrackID != ps.resumeTrack {
+		// Moved on before it loaded
+		ps.resumeTrack = ""
+		return
+	}
+
+	if !ps.isLoaded(track) {
+		return
+	}
+
+	if err := ps.player.Seek(ps.resumeAt); err != nil {
+		logger.Warn("Failed to resume %s at %v: %v", track.Title, ps.resumeAt, err)
+	}
+
+	ps.state.CurrentTime = ps.player.GetPosition()
+	ps.resumeTrack = ""
+}
@@ -0,0 +1,185 @@
+package systems
+
+import (
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// sleepFadeDuration is how long the sleep timer takes to fade the volume
+// out before pausing.
+const sleepFadeDuration = 45 * time.Second
+
+// sleepTimer pauses playback after a set time or number of tracks.
+type sleepTimer struct {
+	mode       structures.SleepMode
+	deadline   time.Time // SleepAfterDuration
+	tracksLeft int       // SleepAfterTracks: tracks to play after the current one
+
+	fading  bool
+	volume  float64 // volume before the fade
+	restore bool    // set the volume back to volume on the next play
+}
+
+// setSleepTimer starts or cancels the sleep timer. Cancelling during the
+// fade restores the volume right away.
+func (ps *PlayerSystem) setSleepTimer(a structures.SetSleepTimerAction) {
+	if ps.sleep.fading {
+		ps.setVolume(ps.sleep.volume)
+	}
+
+	ps.sleep = sleepTimer{mode: a.Mode, restore: ps.sleep.restore, volume: ps.sleep.volume}
+
+	switch a.Mode {
+	case structures.SleepAfterDuration:
+		ps.sleep.deadline = time.Now().Add(a.Duration)
+		logger.Debug("Sleep timer set for %v", a.Duration)
+	case structures.SleepAfterTracks:
+		ps.sleep.tracksLeft = max(a.Tracks, 0)
+		logger.Debug("Sleep timer set for the end of the track plus %d more", ps.sleep.tracksLeft)
+	default:
+		ps.sleep.mode = structures.SleepOff
+		logger.Debug("Sleep timer cancelled")
+	}
+
+	ps.updateSleepState()
+}
+
+// sleepRemaining returns the wall-clock time until the sleep timer pauses,
+// and false if that is not known yet because more tracks are to come.
+func (ps *PlayerSystem) sleepRemaining() (time.Duration, bool) {
+	switch ps.sleep.mode {
+	case structures.SleepAfterDuration:
+		return time.Until(ps.sleep.deadline), true
+	case structures.SleepAfterTracks:
+		if ps.sleep.tracksLeft > 0 || ps.state.TotalTime <= 0 {
+			return 0, false
+		}
+
+		// The rest of the track plays faster or slower than it is long
+		left := ps.state.TotalTime - ps.state.CurrentTime
+		if ps.state.Speed > 0 {
+			left = time.Duration(float64(left) / ps.state.Speed)
+		}
+
+		return left, true
+	default:
+		return 0, false
+	}
+}
+
+// tickSleep fades the volume out towards the end of the timer and pauses
+// when a timed sleep runs out. Called from the update loop with ps.mu held.
+func (ps *PlayerSystem) tickSleep() {
+	// Playback resumed some other way than play/pause, e.g. a jump
+	if ps.sleep.restore && ps.state.IsPlaying {
+		ps.restoreSleepVolume()
+	}
+
+	if ps.sleep.mode == structures.SleepOff || ps.player == nil {
+		return
+	}
+
+	remaining, known := ps.sleepRemaining()
+
+	// A timer that ran out while paused has nothing left to do
+	if !ps.state.IsPlaying {
+		if ps.sleep.mode == structures.SleepAfterDuration && remaining <= 0 {
+			ps.setSleepTimer(structures.SetSleepTimerAction{Mode: structures.SleepOff})
+		}
+
+		ps.updateSleepState()
+
+		return
+	}
+
+	if known && remaining < sleepFadeDuration {
+		if !ps.sleep.fading {
+			ps.sleep.fading = true
+			ps.sleep.volume = ps.player.GetVolume()
+			logger.Debug("Sleep timer fading out from volume %.2f", ps.sleep.volume)
+		}
+
+		ps.setVolume(ps.sleep.volume * max(float64(remaining)/float64(sleepFadeDuration), 0))
+	} else if ps.sleep.fading {
+		// Seeked back out of the fade
+		ps.sleep.fading = false
+		ps.setVolume(ps.sleep.volume)
+	}
+
+	// Track-based timers stop at the track change, see sleepTrackEnded
+	if ps.sleep.mode == structures.SleepAfterDuration && remaining <= 0 {
+		ps.sleepNow()
+	}
+
+	ps.updateSleepState()
+}
+
+// sleepTrackEnded counts a track that played to its end and pauses if it
+// was the last one the timer allows. Called after the queue has advanced.
+func (ps *PlayerSystem) sleepTrackEnded() {
+	if ps.sleep.mode != structures.SleepAfterTracks {
+		return
+	}
+
+	if ps.sleep.tracksLeft > 0 {
+		ps.sleep.tracksLeft--
+		ps.updateSleepState()
+
+		return
+	}
+
+	ps.sleepNow()
+}
+
+// sleepNow pauses playback at the end of the timer, keeping the faded
+// volume until the next play.
+func (ps *PlayerSystem) sleepNow() {
+	if err := ps.player.Pause(); err != nil {
+		logger.Error("Sleep timer failed to pause playback: %v", err)
+	}
+	ps.state.IsPlaying = false
+
+	volume := ps.sleep.volume
+	if !ps.sleep.fading {
+		volume = ps.player.GetVolume()
+	}
+
+	ps.sleep = sleepTimer{volume: volume, restore: true}
+	ps.updateSleepState()
+
+	logger.Debug("Sleep timer paused playback")
+}
+
+// restoreSleepVolume sets the volume back to where it was before the sleep
+// timer faded it out. Called when playback starts.
+func (ps *PlayerSystem) restoreSleepVolume() {
+	if !ps.sleep.restore {
+		return
+	}
+
+	ps.sleep.restore = false
+	ps.setVolume(ps.sleep.volume)
+}
+
+// setVolume sets the player volume and mirrors it in the state.
+func (ps *PlayerSystem) setVolume(volume float64) {
+	if ps.player == nil {
+		return
+	}
+
+	if err := ps.player.SetVolume(volume); err != nil {
+		logger.Error("Failed to set volume: %v", err)
+	}
+	ps.state.Volume = ps.player.GetVolume()
+}
+
+// updateSleepState mirrors the sleep timer in the state.
+func (ps *PlayerSystem) updateSleepState() {
+	ps.state.SleepMode = ps.sleep.mode
+	ps.state.SleepTracks = ps.sleep.tracksLeft
+
+	remaining, _ := ps.sleepRemaining()
+	ps.state.SleepLeft = max(remaining, 0)
+}
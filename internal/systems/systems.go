@@ -41,6 +41,12 @@ func (s *Systems) Start() error {
 		})
 	})
 
+	// Let the player start on tracks that are still downloading
+	s.Download.SetStreamCallback(func(trackID string) {
+		s.Player.SendAction(structures.TrackStreamableAction{TrackID: trackID})
+	})
+	s.Player.SetPartialLookup(s.Download.PartialDownload)
+
 	// Connect player download requests to download system
 	s.Player.SetDownloadCallback(func(video structures.Track) {
 		s.QueueVideoForDownload(video)
@@ -77,3 +83,20 @@ func (s *Systems) QueueVideoForDownload(video structures.Track) {
 	// Queue for download
 	s.Download.QueueDownload(video)
 }
+
+// LocalPlaylists returns the playlists kept in the database.
+func (s *Systems) LocalPlaylists() []Playlist {
+	local := s.Database.GetLocalPlaylists()
+
+	playlists := make([]Playlist, len(local))
+	for i, p := range local {
+		playlists[i] = Playlist{
+			ID:         p.ID,
+			Title:      p.Name,
+			VideoCount: p.TrackCount,
+			Local:      true,
+		}
+	}
+
+	return playlists
+}
@@ -21,8 +21,14 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 			m.playlistName = playlist.Title
 			m.playlistSelectedIndex = 0
 			m.playlistScrollOffset = 0
+			m.playlistID = playlist.ID
+			m.playlistLocal = playlist.Local
 			m.state = PlaylistDetailView
 
+			if playlist.Local {
+				return m, m.loadLocalPlaylistTracks(playlist.ID)
+			}
+
 			return m, m.loadPlaylistTracks(playlist.ID)
 		}
 	case PlaylistDetailView:
@@ -45,8 +51,18 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) performSearch() tea.Cmd {
+	query := strings.TrimSpace(m.searchQuery)
+	source := m.searchSource
+
 	return func() tea.Msg {
-		results, err := m.systems.API.Search(strings.TrimSpace(m.searchQuery))
+		switch source {
+		case searchLibrary:
+			return tracksLoadedMsg(m.systems.Database.SearchTracks(query, librarySearchLimit))
+		case searchMerged:
+			return m.searchLibraryFirst(query)
+		}
+
+		results, err := m.systems.API.Search(query)
 		if err != nil {
 			return errorMsg(err)
 		}
@@ -55,14 +71,23 @@ func (m *Model) performSearch() tea.Cmd {
 	}
 }
 
+// loadPlaylists loads the local playlists followed by the library ones.
+// Offline, the local playlists still load.
 func (m *Model) loadPlaylists() tea.Cmd {
 	return func() tea.Msg {
+		local := m.systems.LocalPlaylists()
+
 		playlists, err := m.systems.API.GetLibraryPlaylists()
 		if err != nil {
+			if len(local) > 0 {
+				logger.Warn("Showing local playlists only: %v", err)
+				return playlistsLoadedMsg(local)
+			}
+
 			return errorMsg(err)
 		}
 
-		return playlistsLoadedMsg(playlists)
+		return playlistsLoadedMsg(append(local, playlists...))
 	}
 }
 
@@ -80,6 +105,7 @@ func (m *Model) loadPlaylistTracks(playlistID string) tea.Cmd {
 				Title:       t.Title,
 				Artists:     t.Artists,
 				Thumbnail:   t.Thumbnail,
+				Album:       t.Album,
 				Duration:    t.Duration,
 				IsAvailable: t.IsAvailable,
 				IsExplicit:  t.IsExplicit,
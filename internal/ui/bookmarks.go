@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// bookmarkMaxNameLength limits the length of a bookmark name.
+const bookmarkMaxNameLength = 40
+
+// openBookmarks switches the main pane to the bookmarks of the current track.
+func (m *Model) openBookmarks() (tea.Model, tea.Cmd) {
+	if m.state != BookmarksView {
+		m.bookmarkReturnState = m.state
+		m.state = BookmarksView
+	}
+
+	m.bookmarkNaming = false
+	m.setFocus(FocusMain)
+
+	return m, nil
+}
+
+// closeBookmarks returns to the view the bookmarks were opened from.
+func (m *Model) closeBookmarks() {
+	m.state = m.bookmarkReturnState
+	m.bookmarkNaming = false
+}
+
+// toggleABLoop marks A, then B, then clears the loop.
+func (m *Model) toggleABLoop() (tea.Model, tea.Cmd) {
+	m.systems.Player.SendAction(structures.ABLoopAction{})
+	return m, nil
+}
+
+// handleBookmarkKeys handles keys in the bookmark list. It reports false for
+// keys that the main pane should handle as usual.
+func (m *Model) handleBookmarkKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if m.bookmarkNaming {
+		return m, m.handleBookmarkNameKeys(msg), true
+	}
+
+	kb := m.config.KeyBindings
+	bookmarks := m.playerState.Bookmarks
+	m.bookmarkSelected = min(m.bookmarkSelected, max(len(bookmarks)-1, 0))
+
+	switch {
+	case m.isKeyInList(msg, kb.MoveUp):
+		m.bookmarkSelected = max(m.bookmarkSelected-1, 0)
+	case m.isKeyInList(msg, kb.MoveDown):
+		m.bookmarkSelected = min(m.bookmarkSelected+1, max(len(bookmarks)-1, 0))
+	case m.isKeyInList(msg, kb.Select):
+		if m.bookmarkSelected < len(bookmarks) {
+			m.systems.Player.SendAction(structures.JumpToBookmarkAction{ID: bookmarks[m.bookmarkSelected].ID})
+		}
+	case m.isKey(msg, kb.RemoveTrack):
+		if m.bookmarkSelected < len(bookmarks) {
+			m.systems.Player.SendAction(structures.DeleteBookmarkAction{ID: bookmarks[m.bookmarkSelected].ID})
+		}
+	case m.isKey(msg, "a"):
+		if track, ok := m.currentTrack(); ok {
+			m.bookmarkNaming = true
+			m.bookmarkName = ""
+			m.bookmarkTrack = track.TrackID
+			m.bookmarkAt = m.playerState.CurrentTime
+		}
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// handleBookmarkNameKeys edits the name of a bookmark being added. An empty
+// name leaves it to the player to name the bookmark after its position.
+func (m *Model) handleBookmarkNameKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.systems.Player.SendAction(structures.AddBookmarkAction{
+			TrackID:  m.bookmarkTrack,
+			Name:     strings.TrimSpace(m.bookmarkName),
+			Position: m.bookmarkAt,
+		})
+		m.bookmarkNaming = false
+	case tea.KeyEsc:
+		m.bookmarkNaming = false
+	case tea.KeyBackspace:
+		if runes := []rune(m.bookmarkName); len(runes) > 0 {
+			m.bookmarkName = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		m.bookmarkName += " "
+	case tea.KeyRunes:
+		if len([]rune(m.bookmarkName)) < bookmarkMaxNameLength {
+			m.bookmarkName += string(msg.Runes)
+		}
+	}
+
+	return nil
+}
+
+// currentTrack returns the track that is playing, if any.
+func (m *Model) currentTrack() (structures.Track, bool) {
+	if m.playerState.Current < 0 || m.playerState.Current >= len(m.playerState.List) {
+		return structures.Track{}, false
+	}
+
+	return m.playerState.List[m.playerState.Current], true
+}
+
+// formatPosition formats a position in a track as m:ss.
+func formatPosition(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// loopStatus describes the A-B loop for the player controls, or returns ""
+// when no loop is set. Its glyph differs from those of the repeat modes,
+// which are shown next to it.
+func loopStatus(loop structures.ABLoop) string {
+	switch {
+	case loop.HasB:
+		return "🔄 A-B " + formatPosition(loop.A) + "–" + formatPosition(loop.B)
+	case loop.HasA:
+		return "🔄 A " + formatPosition(loop.A) + "–"
+	default:
+		return ""
+	}
+}
+
+func (m Model) renderBookmarks(maxWidth int) string {
+	titleStyle, selectedStyle, normalStyle, dimStyle, _ := m.getStyles()
+
+	if m.hasFocus("main") {
+		titleStyle = titleStyle.Underline(true)
+	}
+
+	var b strings.Builder
+
+	title := "🔖 Bookmarks"
+	if track, ok := m.currentTrack(); ok {
+		title += ": " + track.Title
+	}
+
+	b.WriteString("  " + titleStyle.Render(truncate(title, max(maxWidth-4, 10))))
+	b.WriteString("\n")
+	b.WriteString("  " + dimStyle.Render(truncate(m.shortcutFormatter.FormatHints(m.shortcutFormatter.GetBookmarkHints()), max(maxWidth-4, 10))))
+	b.WriteString("\n\n")
+
+	if status := loopStatus(m.playerState.Loop); status != "" {
+		b.WriteString("  " + normalStyle.Render(status))
+		b.WriteString("\n\n")
+	}
+
+	bookmarks := m.playerState.Bookmarks
+
+	switch {
+	case m.playerState.Current < 0 || m.playerState.Current >= len(m.playerState.List):
+		b.WriteString(dimStyle.Render("  Nothing is playing"))
+	case len(bookmarks) == 0:
+		b.WriteString(dimStyle.Render("  " + m.shortcutFormatter.GetEmptyStateHint("bookmark the current position", "a")))
+	}
+
+	selectedIndex := min(m.bookmarkSelected, max(len(bookmarks)-1, 0))
+	visibleItems := max(m.contentHeight-8, 1)
+	start := max(selectedIndex-visibleItems+1, 0)
+	end := min(start+visibleItems, len(bookmarks))
+
+	for i := start; i < end; i++ {
+		bookmark := bookmarks[i]
+		style := normalStyle
+		prefix := "   "
+
+		if i == selectedIndex {
+			style = selectedStyle
+			prefix = " ▶ "
+		}
+
+		position := fmt.Sprintf("%6s  ", formatPosition(bookmark.Position))
+		availableWidth := maxWidth - runewidth.StringWidth(prefix+position) - 2
+
+		b.WriteString(style.Render(prefix + position + truncate(bookmark.Name, availableWidth)))
+
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	if m.bookmarkNaming {
+		b.WriteString(fmt.Sprintf("\n\n  Bookmark %s as: ", formatPosition(m.bookmarkAt)))
+		b.WriteString(selectedStyle.Render(m.bookmarkName + "█"))
+	}
+
+	return b.String()
+}
@@ -14,15 +14,20 @@ var (
 	SpaceWidth    = 1
 
 	// Progress bar symbols for different styles.
+	// The not yet downloaded part of a streaming track uses the
+	// Unbuffered symbol.
 	// Block style
-	ProgressBlockFilled = "█"
-	ProgressBlockEmpty  = "░"
+	ProgressBlockFilled     = "█"
+	ProgressBlockEmpty      = "░"
+	ProgressBlockUnbuffered = "·"
 
 	// Line style
-	ProgressLineFilled = "─"
-	ProgressLineEmpty  = "─"
+	ProgressLineFilled     = "─"
+	ProgressLineEmpty      = "─"
+	ProgressLineUnbuffered = "┈"
 
 	// Gradient style
-	ProgressGradientFilled = "━"
-	ProgressGradientEmpty  = "━"
+	ProgressGradientFilled     = "━"
+	ProgressGradientEmpty      = "━"
+	ProgressGradientUnbuffered = "┅"
 )
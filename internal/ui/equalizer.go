@@ -0,0 +1,443 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/haryoiro/yutemal/internal/player"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// EQ editor layout and steps.
+const (
+	eqSliderTop      = 4   // content line of the first slider row
+	eqAxisWidth      = 5   // dB labels left of the sliders and the curve
+	eqMaxGain        = 12  // dB at the top of a slider
+	eqGainStep       = 0.5 // dB per arrow key or wheel notch
+	eqGainPageStep   = 3.0 // dB per page key
+	eqResponseHeight = 7   // rows of the frequency response plot
+	eqMaxNameLength  = 32
+)
+
+// eqLayout holds the geometry of the EQ editor, shared by rendering and
+// mouse handling.
+type eqLayout struct {
+	width        int
+	sliderHeight int
+	columnWidth  int
+}
+
+// computeEQLayout sizes the editor for the main pane.
+func (m *Model) computeEQLayout(width int) eqLayout {
+	// Title, hints, labels, gains, the response plot and its axis, the
+	// band details and the name prompt take 18 lines.
+	height := max(m.contentHeight-18, 5)
+	height = min(height, 4*eqMaxGain+1)
+	if height%2 == 0 {
+		height-- // keep a row for 0 dB
+	}
+
+	bands := max(len(m.playerState.EQBands), 1)
+
+	return eqLayout{
+		width:        width,
+		sliderHeight: height,
+		columnWidth:  min(max((width-eqAxisWidth-2)/bands, 3), 8),
+	}
+}
+
+// gainAtRow returns the gain a slider row stands for.
+func (l eqLayout) gainAtRow(row int) float64 {
+	return eqMaxGain - 2*eqMaxGain*float64(row)/float64(l.sliderHeight-1)
+}
+
+// rowForGain returns the slider row closest to a gain.
+func (l eqLayout) rowForGain(gain float64) int {
+	row := int(math.Round((eqMaxGain - gain) * float64(l.sliderHeight-1) / (2 * eqMaxGain)))
+	return min(max(row, 0), l.sliderHeight-1)
+}
+
+// bandAtColumn returns the band under a content column, or -1.
+func (l eqLayout) bandAtColumn(x, bands int) int {
+	if x < eqAxisWidth || l.columnWidth == 0 {
+		return -1
+	}
+
+	band := (x - eqAxisWidth) / l.columnWidth
+	if band >= bands {
+		return -1
+	}
+
+	return band
+}
+
+// openEQEditor switches the main pane to the EQ editor.
+func (m *Model) openEQEditor() (tea.Model, tea.Cmd) {
+	if m.state != EQView {
+		m.eqReturnState = m.state
+		m.state = EQView
+	}
+
+	m.eqSelectedBand = min(m.eqSelectedBand, max(len(m.playerState.EQBands)-1, 0))
+	m.eqNaming = false
+	m.setFocus(FocusMain)
+
+	return m, nil
+}
+
+// closeEQEditor returns to the view the editor was opened from.
+func (m *Model) closeEQEditor() {
+	m.state = m.eqReturnState
+	m.eqNaming = false
+}
+
+// handleEQKeys handles keys in the EQ editor. It reports false for keys
+// that the main pane should handle as usual.
+func (m *Model) handleEQKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if m.eqNaming {
+		return m, m.handleEQNameKeys(msg), true
+	}
+
+	kb := m.config.KeyBindings
+
+	switch {
+	case m.isKey(msg, "left") || m.isKey(msg, "h"):
+		m.eqSelectedBand = max(m.eqSelectedBand-1, 0)
+	case m.isKey(msg, "right") || m.isKey(msg, "l"):
+		m.eqSelectedBand = min(m.eqSelectedBand+1, max(len(m.playerState.EQBands)-1, 0))
+	case m.isKeyInList(msg, kb.MoveUp):
+		m.adjustEQBand(m.eqSelectedBand, eqGainStep)
+	case m.isKeyInList(msg, kb.MoveDown):
+		m.adjustEQBand(m.eqSelectedBand, -eqGainStep)
+	case m.isKey(msg, "pgup"):
+		m.adjustEQBand(m.eqSelectedBand, eqGainPageStep)
+	case m.isKey(msg, "pgdown"):
+		m.adjustEQBand(m.eqSelectedBand, -eqGainPageStep)
+	case m.isKey(msg, "v"):
+		m.systems.Player.SendAction(structures.EQToggleAction{})
+	case m.isKey(msg, "0"):
+		m.systems.Player.SendAction(structures.EQResetAction{})
+	case m.isKey(msg, "w"):
+		m.eqNaming = true
+		m.eqPresetName = ""
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// handleEQNameKeys edits the name of a preset being saved.
+func (m *Model) handleEQNameKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if strings.TrimSpace(m.eqPresetName) != "" {
+			m.systems.Player.SendAction(structures.EQSavePresetAction{Name: m.eqPresetName})
+		}
+		m.eqNaming = false
+	case tea.KeyEsc:
+		m.eqNaming = false
+	case tea.KeyBackspace:
+		if runes := []rune(m.eqPresetName); len(runes) > 0 {
+			m.eqPresetName = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		m.eqPresetName += " "
+	case tea.KeyRunes:
+		if len([]rune(m.eqPresetName)) < eqMaxNameLength {
+			m.eqPresetName += string(msg.Runes)
+		}
+	}
+
+	return nil
+}
+
+// adjustEQBand changes the gain of a band by delta dB.
+func (m *Model) adjustEQBand(band int, delta float64) {
+	if band < 0 || band >= len(m.playerState.EQBands) {
+		return
+	}
+
+	m.setEQBandGain(band, m.playerState.EQBands[band].Gain+delta)
+}
+
+// setEQBandGain sets the gain of a band and shows it before the player
+// reports back, so that repeated keys build on each other.
+func (m *Model) setEQBandGain(band int, gain float64) {
+	gain = math.Max(-eqMaxGain, math.Min(eqMaxGain, math.Round(gain/eqGainStep)*eqGainStep))
+
+	m.playerState.EQBands[band].Gain = gain
+	m.playerState.EQPreset = ""
+	m.systems.Player.SendAction(structures.EQSetBandGainAction{Band: band, GainDB: gain})
+}
+
+// handleEQMouse selects the band under the pointer and drags its slider.
+func (m *Model) handleEQMouse(x, y int) (tea.Model, tea.Cmd) {
+	if m.eqNaming {
+		return m, nil
+	}
+
+	row := y - 1 - eqSliderTop
+	if row < 0 || row >= m.eqLayout.sliderHeight {
+		return m, nil
+	}
+
+	band := m.eqLayout.bandAtColumn(x-1, len(m.playerState.EQBands))
+	if band < 0 {
+		return m, nil
+	}
+
+	m.eqSelectedBand = band
+	m.setEQBandGain(band, m.eqLayout.gainAtRow(row))
+
+	return m, nil
+}
+
+// eqBandLabel returns the label under a slider: the graphic EQ labels from
+// the player, or the frequency of a parametric band.
+func eqBandLabel(i int, band structures.EQBandConfig) string {
+	if i < player.NumBands && band.Freq == player.DefaultBandFrequencies[i] {
+		return player.BandLabels[i]
+	}
+
+	return formatFrequency(band.Freq)
+}
+
+// formatFrequency formats a frequency compactly, e.g. 125 or 2.5k.
+func formatFrequency(freq float64) string {
+	if freq >= 1000 {
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", freq/1000), ".0") + "k"
+	}
+
+	return fmt.Sprintf("%.0f", freq)
+}
+
+// centerText centers s in a field of the given width.
+func centerText(s string, width int) string {
+	s = truncate(s, width)
+	pad := width - runewidth.StringWidth(s)
+
+	return strings.Repeat(" ", pad/2) + s + strings.Repeat(" ", pad-pad/2)
+}
+
+func (m Model) renderEQEditor(maxWidth int) string {
+	titleStyle, _, _, dimStyle, errorStyle := m.getStyles()
+
+	if m.hasFocus("main") {
+		titleStyle = titleStyle.Underline(true)
+	}
+
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color(m.config.Theme.Selected)).Bold(true)
+	fill := lipgloss.NewStyle().Foreground(lipgloss.Color(m.config.Theme.ProgressBarFill))
+	track := lipgloss.NewStyle().Foreground(lipgloss.Color(m.config.Theme.Border))
+
+	bands := m.playerState.EQBands
+	layout := m.eqLayout
+
+	var b strings.Builder
+
+	preset := "Custom"
+	if m.playerState.EQPreset != "" {
+		preset = m.playerState.EQPreset
+	}
+
+	title := "🎚 Equalizer: " + preset
+	if !m.playerState.EQEnabled {
+		title += " (bypassed)"
+	}
+
+	b.WriteString("  " + titleStyle.Render(title))
+	if m.playerState.Clipped > 0 {
+		b.WriteString("  " + errorStyle.Render("● CLIP"))
+	}
+	b.WriteString("\n")
+	b.WriteString("  " + dimStyle.Render(truncate(m.shortcutFormatter.FormatHints(m.shortcutFormatter.GetEQHints()), max(maxWidth-4, 10))))
+	b.WriteString("\n\n")
+
+	if len(bands) == 0 {
+		b.WriteString(dimStyle.Render("  The equalizer is not available"))
+		return b.String()
+	}
+
+	// Sliders
+	zeroRow := layout.rowForGain(0)
+	for row := range layout.sliderHeight {
+		b.WriteString(m.renderEQAxisLabel(layout.gainAtRow(row), dimStyle))
+
+		for i, band := range bands {
+			style := fill
+			if i == m.eqSelectedBand {
+				style = selected
+			}
+
+			knob := layout.rowForGain(band.Gain)
+
+			var glyph string
+			switch {
+			case row == knob:
+				glyph = style.Render("●")
+			case (row > knob && row <= zeroRow) || (row < knob && row >= zeroRow):
+				glyph = style.Render("┃")
+			case row == zeroRow:
+				glyph = track.Render("┼")
+			default:
+				glyph = track.Render("│")
+			}
+
+			left := (layout.columnWidth - 1) / 2
+			b.WriteString(strings.Repeat(" ", left) + glyph + strings.Repeat(" ", layout.columnWidth-1-left))
+		}
+
+		b.WriteString("\n")
+	}
+
+	// Labels and gains
+	b.WriteString(strings.Repeat(" ", eqAxisWidth))
+	for i, band := range bands {
+		label := centerText(eqBandLabel(i, band), layout.columnWidth)
+		if i == m.eqSelectedBand {
+			label = selected.Render(label)
+		}
+		b.WriteString(label)
+	}
+
+	b.WriteString("\n" + strings.Repeat(" ", eqAxisWidth))
+	for _, band := range bands {
+		value := fmt.Sprintf("%+.1f", band.Gain)
+		switch player.BandType(band.Type) {
+		case player.BandHighPass:
+			value = "HP"
+		case player.BandLowPass:
+			value = "LP"
+		}
+		b.WriteString(dimStyle.Render(centerText(value, layout.columnWidth)))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(m.renderEQResponse(maxWidth, fill, track, dimStyle))
+
+	// Selected band
+	if m.eqSelectedBand < len(bands) {
+		band := bands[m.eqSelectedBand]
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  Band %d/%d: %s %s Hz, Q %.2f, %+.1f dB · Preamp %+.1f dB",
+			m.eqSelectedBand+1, len(bands), strings.ReplaceAll(band.Type, "_", "-"),
+			formatFrequency(band.Freq), band.Q, band.Gain, m.playerState.EQPreamp)))
+	}
+
+	if m.eqNaming {
+		b.WriteString("\n  Save preset as: " + selected.Render(m.eqPresetName+"█"))
+	}
+
+	return b.String()
+}
+
+// renderEQAxisLabel renders the dB label left of a row, for every 6 dB.
+func (m Model) renderEQAxisLabel(gain float64, dimStyle lipgloss.Style) string {
+	label := ""
+	if rounded := math.Round(gain/6) * 6; math.Abs(gain-rounded) < 1e-9 {
+		label = fmt.Sprintf("%+.0f", rounded)
+		if rounded == 0 {
+			label = "0"
+		}
+	}
+
+	return dimStyle.Render(fmt.Sprintf("%4s ", label))
+}
+
+// renderEQResponse plots the combined response of the EQ from 20 Hz to
+// 20 kHz on a log scale, using the coefficients the player uses.
+func (m Model) renderEQResponse(maxWidth int, fill, track, dimStyle lipgloss.Style) string {
+	width := max(maxWidth-eqAxisWidth-2, 10)
+
+	freqs := make([]float64, width)
+	for i := range freqs {
+		freqs[i] = 20 * math.Pow(1000, float64(i)/float64(width-1))
+	}
+
+	bands := make([]player.EQBand, len(m.playerState.EQBands))
+	for i, band := range m.playerState.EQBands {
+		bands[i] = player.EQBand{Type: player.BandType(band.Type), Freq: band.Freq, Gain: band.Gain, Q: band.Q}
+	}
+
+	sampleRate := float64(m.config.OutputSampleRate)
+	if sampleRate <= 0 {
+		sampleRate = player.DefaultOutputSampleRate
+	}
+
+	response := player.FrequencyResponse(bands, m.playerState.EQPreamp, sampleRate, freqs)
+
+	// Scale the plot to the largest deviation, in steps of 6 dB.
+	scale := 6.0
+	for _, v := range response {
+		scale = math.Max(scale, math.Ceil(math.Abs(v)/6)*6)
+	}
+
+	curve := fill
+	if !m.playerState.EQEnabled {
+		curve = dimStyle
+	}
+
+	rowFor := func(v float64) int {
+		row := int(math.Round((scale - v) * (eqResponseHeight - 1) / (2 * scale)))
+		return min(max(row, 0), eqResponseHeight-1)
+	}
+
+	var b strings.Builder
+	for row := range eqResponseHeight {
+		label := ""
+		switch row {
+		case 0:
+			label = fmt.Sprintf("%+.0f", scale)
+		case eqResponseHeight / 2:
+			label = "0"
+		case eqResponseHeight - 1:
+			label = fmt.Sprintf("%+.0f", -scale)
+		}
+		b.WriteString(dimStyle.Render(fmt.Sprintf("%4s ", label)))
+
+		for _, v := range response {
+			switch {
+			case rowFor(v) == row:
+				b.WriteString(curve.Render("•"))
+			case row == eqResponseHeight/2:
+				b.WriteString(track.Render("┈"))
+			default:
+				b.WriteString(" ")
+			}
+		}
+
+		b.WriteString("\n")
+	}
+
+	// Frequency axis
+	axis := []rune(strings.Repeat(" ", width))
+	for _, tick := range []struct {
+		freq  float64
+		label string
+	}{{20, "20"}, {100, "100"}, {1000, "1k"}, {10000, "10k"}, {20000, "20k"}} {
+		x := int(math.Round(math.Log(tick.freq/20) / math.Log(1000) * float64(width-1)))
+		x = min(x, width-len(tick.label))
+		copy(axis[x:], []rune(tick.label))
+	}
+
+	b.WriteString(strings.Repeat(" ", eqAxisWidth) + dimStyle.Render(string(axis)))
+
+	return b.String()
+}
+
+// handleEQWheel nudges the selected band with the mouse wheel.
+func (m *Model) handleEQWheel(up bool) (tea.Model, tea.Cmd) {
+	if up {
+		m.adjustEQBand(m.eqSelectedBand, eqGainStep)
+	} else {
+		m.adjustEQBand(m.eqSelectedBand, -eqGainStep)
+	}
+
+	return m, nil
+}
@@ -37,6 +37,10 @@ func (m *Model) getFocusedPane() FocusPane {
 func (m *Model) setFocus(pane FocusPane) {
 	oldFocus := m.getFocusedPane()
 
+	if pane != FocusQueue {
+		m.queueVisual = false
+	}
+
 	switch pane {
 	case FocusMain:
 		m.queueFocused = false
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// historyLimit is the most listens the history view shows.
+const historyLimit = 500
+
+// historyRanges are the time ranges the history view cycles through. A
+// zero span covers all listens.
+var historyRanges = []struct {
+	label string
+	span  time.Duration
+}{
+	{"Last 24 hours", 24 * time.Hour},
+	{"Last 7 days", 7 * 24 * time.Hour},
+	{"Last 30 days", 30 * 24 * time.Hour},
+	{"Last year", 365 * 24 * time.Hour},
+	{"All time", 0},
+}
+
+type historyLoadedMsg []structures.HistoryEntry
+
+// openHistory switches the main pane to the listening history.
+func (m *Model) openHistory() (tea.Model, tea.Cmd) {
+	if m.state != HistoryView {
+		m.historyReturnState = m.state
+		m.state = HistoryView
+	}
+
+	m.historySelected = 0
+	m.setFocus(FocusMain)
+
+	return m, m.loadHistory()
+}
+
+// closeHistory returns to the view the history was opened from.
+func (m *Model) closeHistory() {
+	m.state = m.historyReturnState
+	m.history = nil
+}
+
+// loadHistory reads the listens in the selected time range.
+func (m *Model) loadHistory() tea.Cmd {
+	span := historyRanges[m.historyRange].span
+
+	return func() tea.Msg {
+		now := time.Now()
+
+		var from time.Time
+		if span > 0 {
+			from = now.Add(-span)
+		}
+
+		return historyLoadedMsg(m.systems.Database.GetHistory(from, now.Add(time.Minute), historyLimit))
+	}
+}
+
+// handleHistoryKeys handles keys in the history list. It reports false for
+// keys that the main pane should handle as usual.
+func (m *Model) handleHistoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	kb := m.config.KeyBindings
+	history := m.history
+	m.historySelected = min(m.historySelected, max(len(history)-1, 0))
+
+	switch {
+	case m.isKeyInList(msg, kb.MoveUp):
+		m.historySelected = max(m.historySelected-1, 0)
+	case m.isKeyInList(msg, kb.MoveDown):
+		m.historySelected = min(m.historySelected+1, max(len(history)-1, 0))
+	case m.isKeyInList(msg, kb.Select):
+		if m.historySelected < len(history) {
+			m.systems.Player.SendAction(structures.CleanupAction{})
+			m.systems.Player.SendAction(structures.AddTrackAction{Track: history[m.historySelected].Track})
+			m.systems.Player.SendAction(structures.PlayAction{})
+		}
+	case m.isKey(msg, "a"):
+		if m.historySelected < len(history) {
+			m.systems.Player.SendAction(structures.InsertTrackAfterCurrentAction{Track: history[m.historySelected].Track})
+		}
+	case m.isKey(msg, "t"):
+		m.historyRange = (m.historyRange + 1) % len(historyRanges)
+		m.historySelected = 0
+
+		return m, m.loadHistory(), true
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// formatPlayedAt formats the time of a listen, leaving out the date for
+// listens today.
+func formatPlayedAt(t time.Time) string {
+	t = t.Local()
+
+	now := time.Now()
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+
+	if t.Year() == now.Year() {
+		return t.Format("Jan _2 15:04")
+	}
+
+	return t.Format("2006-01-02")
+}
+
+func (m Model) renderHistory(maxWidth int) string {
+	titleStyle, selectedStyle, normalStyle, dimStyle, _ := m.getStyles()
+
+	if m.hasFocus("main") {
+		titleStyle = titleStyle.Underline(true)
+	}
+
+	var b strings.Builder
+
+	title := "🕘 History: " + historyRanges[m.historyRange].label
+
+	b.WriteString("  " + titleStyle.Render(truncate(title, max(maxWidth-4, 10))))
+	b.WriteString("\n")
+	b.WriteString("  " + dimStyle.Render(truncate(m.shortcutFormatter.FormatHints(m.shortcutFormatter.GetHistoryHints()), max(maxWidth-4, 10))))
+	b.WriteString("\n\n")
+
+	history := m.history
+	if len(history) == 0 {
+		b.WriteString(dimStyle.Render("  Nothing played in this time range"))
+		return b.String()
+	}
+
+	selectedIndex := min(m.historySelected, len(history)-1)
+	visibleItems := max(m.contentHeight-6, 1)
+	start := max(selectedIndex-visibleItems+1, 0)
+	end := min(start+visibleItems, len(history))
+
+	for i := start; i < end; i++ {
+		entry := history[i]
+		style := normalStyle
+		prefix := "   "
+
+		if i == selectedIndex {
+			style = selectedStyle
+			prefix = " ▶ "
+		}
+
+		when := fmt.Sprintf("%-12s  ", formatPlayedAt(entry.PlayedAt))
+		label := entry.Track.Title
+		if len(entry.Track.Artists) > 0 {
+			label += " - " + strings.Join(entry.Track.Artists, ", ")
+		}
+
+		availableWidth := maxWidth - runewidth.StringWidth(prefix+when) - 2
+
+		b.WriteString(style.Render(prefix + when + truncate(label, availableWidth)))
+
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
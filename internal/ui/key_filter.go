@@ -54,8 +54,8 @@ func (m *Model) shouldProcessKey(keyStr string, msg tea.KeyMsg) bool {
 		return m.keyDebouncer.ShouldProcess(keyStr)
 	}
 
-	// For search view, allow all character input without debouncing
-	if m.state == SearchView && msg.Type == tea.KeyRunes {
+	// For search view and preset, bookmark and playlist names, allow all character input without debouncing
+	if (m.state == SearchView || m.eqNaming || m.bookmarkNaming || m.playlistNaming) && msg.Type == tea.KeyRunes {
 		return true
 	}
 
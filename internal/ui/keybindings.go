@@ -21,6 +21,10 @@ func (m *Model) isKey(msg tea.KeyMsg, key string) bool {
 		return msg.Type == tea.KeyCtrlC
 	case "ctrl+d":
 		return msg.Type == tea.KeyCtrlD
+	case "ctrl+p":
+		return msg.Type == tea.KeyCtrlP
+	case "ctrl+t":
+		return msg.Type == tea.KeyCtrlT
 	case "space":
 		return msg.Type == tea.KeySpace
 	case "enter":
@@ -46,6 +50,11 @@ func (m *Model) isKey(msg tea.KeyMsg, key string) bool {
 	case "pgdown":
 		return msg.Type == tea.KeyPgDown
 	default:
+		// Other control keys arrive as their own key types, named alike
+		if strings.HasPrefix(key, "ctrl+") {
+			return msg.String() == key
+		}
+
 		return msg.Type == tea.KeyRunes && msg.String() == key
 	}
 }
@@ -136,11 +145,56 @@ func (m *Model) handlePlayerFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.togglePlayPause()
 	}
 
-	// e = EQ cycle
+	// e = EQ cycle, E = EQ editor
 	if m.isKey(msg, kb.ToggleEQ) {
 		return m.eqCyclePreset()
 	}
 
+	if m.isKey(msg, kb.EQEditor) {
+		return m.openEQEditor()
+	}
+
+	// [ ] = playback speed
+	if m.isKey(msg, kb.SpeedUp) {
+		return m.changeSpeed(speedStep)
+	}
+
+	if m.isKey(msg, kb.SpeedDown) {
+		return m.changeSpeed(-speedStep)
+	}
+
+	// z = sleep timer
+	if m.isKey(msg, kb.SleepTimer) {
+		return m.cycleSleepTimer()
+	}
+
+	// r = repeat off/all/one
+	if m.isKey(msg, kb.Repeat) {
+		return m.cycleRepeat()
+	}
+
+	// L = A-B loop, B = bookmarks, H = history, S = stats
+	if m.isKey(msg, kb.ABLoop) {
+		return m.toggleABLoop()
+	}
+
+	if m.isKey(msg, kb.Bookmarks) {
+		return m.openBookmarks()
+	}
+
+	if m.isKey(msg, kb.History) {
+		return m.openHistory()
+	}
+
+	if m.isKey(msg, kb.Stats) {
+		return m.openStats()
+	}
+
+	// M X K < > = channel tools
+	if m.handleChannelKeys(msg) {
+		return m, nil
+	}
+
 	// s = shuffle
 	if m.isKey(msg, kb.Shuffle) {
 		return m.shuffleQueue()
@@ -185,6 +239,16 @@ func (m *Model) handleQueueFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.pageDown()
 	}
 
+	// v = visual selection, K/J/T/E = move, d = delete the selection
+	if m.handleQueueEditKeys(msg) {
+		return m, nil
+	}
+
+	// p = add the selection to a local playlist
+	if m.isKey(msg, kb.AddToPlaylist) {
+		return m.addQueueSelectionToPlaylist()
+	}
+
 	// Select = play from here
 	if m.isKeyInList(msg, kb.Select) {
 		return m.handleQueueSelection()
@@ -237,6 +301,10 @@ func (m *Model) handleSearchFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.navigateBack()
 	}
 
+	if m.isKey(msg, searchSourceKey) {
+		return m.cycleSearchSource()
+	}
+
 	// If search results exist and not typing, allow navigation
 	if len(m.searchResults) > 0 {
 		if m.isKeyInList(msg, kb.MoveUp) {
@@ -246,6 +314,10 @@ func (m *Model) handleSearchFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.isKeyInList(msg, kb.MoveDown) {
 			return m.moveDown()
 		}
+
+		if m.isKey(msg, searchAddToPlaylistKey) {
+			return m.addSearchResultToPlaylist()
+		}
 	}
 
 	// Text input handling
@@ -256,6 +328,43 @@ func (m *Model) handleSearchFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m *Model) handleMainFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	kb := m.config.KeyBindings
 
+	// The EQ editor takes arrows and a few letters for itself
+	if m.state == EQView {
+		if model, cmd, handled := m.handleEQKeys(msg); handled {
+			return model, cmd
+		}
+	}
+
+	if m.state == BookmarksView {
+		if model, cmd, handled := m.handleBookmarkKeys(msg); handled {
+			return model, cmd
+		}
+	}
+
+	if m.state == HistoryView {
+		if model, cmd, handled := m.handleHistoryKeys(msg); handled {
+			return model, cmd
+		}
+	}
+
+	if m.state == StatsView {
+		if model, cmd, handled := m.handleStatsKeys(msg); handled {
+			return model, cmd
+		}
+	}
+
+	if m.state == AddToPlaylistView {
+		if model, cmd, handled := m.handlePlaylistPickerKeys(msg); handled {
+			return model, cmd
+		}
+	}
+
+	if m.state == PlaylistListView || m.state == PlaylistDetailView {
+		if model, cmd, handled := m.handleLocalPlaylistKeys(msg); handled {
+			return model, cmd
+		}
+	}
+
 	// Navigation keys
 	if m.isKeyInList(msg, kb.MoveUp) {
 		return m.moveUp()
@@ -303,6 +412,37 @@ func (m *Model) handleMainFocusKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.eqCyclePreset()
 	}
 
+	if m.isKey(msg, kb.EQEditor) {
+		return m.openEQEditor()
+	}
+
+	// Repeat
+	if m.isKey(msg, kb.Repeat) {
+		return m.cycleRepeat()
+	}
+
+	// A-B loop and bookmarks
+	if m.isKey(msg, kb.ABLoop) {
+		return m.toggleABLoop()
+	}
+
+	if m.isKey(msg, kb.Bookmarks) {
+		return m.openBookmarks()
+	}
+
+	if m.isKey(msg, kb.History) {
+		return m.openHistory()
+	}
+
+	if m.isKey(msg, kb.Stats) {
+		return m.openStats()
+	}
+
+	// Channel tools
+	if m.handleChannelKeys(msg) {
+		return m, nil
+	}
+
 	// Queue toggle
 	if m.isKey(msg, "q") {
 		return m.toggleQueue()
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// searchSourceKey switches what the search view searches. Letters type into
+// the query there, so it cannot be a plain letter.
+const searchSourceKey = "ctrl+t"
+
+// librarySearchLimit is the most tracks a library search returns.
+const librarySearchLimit = 100
+
+// searchSource is what the search view searches.
+type searchSource int
+
+const (
+	searchOnline  searchSource = iota // YouTube Music
+	searchLibrary                     // the downloaded tracks
+	searchMerged                      // the library first, then YouTube Music
+)
+
+func (s searchSource) String() string {
+	switch s {
+	case searchLibrary:
+		return "Library"
+	case searchMerged:
+		return "Library + Online"
+	default:
+		return "Online"
+	}
+}
+
+// cycleSearchSource switches between online, library and merged search,
+// searching again if there is a query.
+func (m *Model) cycleSearchSource() (tea.Model, tea.Cmd) {
+	m.searchSource = (m.searchSource + 1) % 3
+
+	if m.searchQuery == "" {
+		return m, nil
+	}
+
+	return m, m.performSearch()
+}
+
+// mergeSearchResults puts the library results first and leaves out online
+// results that are in the library already.
+func mergeSearchResults(library, online []structures.Track) []structures.Track {
+	seen := make(map[string]bool, len(library))
+	for _, track := range library {
+		seen[track.TrackID] = true
+	}
+
+	merged := library
+	for _, track := range online {
+		if !seen[track.TrackID] {
+			merged = append(merged, track)
+		}
+	}
+
+	return merged
+}
+
+// searchLibraryFirst searches the library and YouTube Music. Offline, the
+// library results still show.
+func (m *Model) searchLibraryFirst(query string) tea.Msg {
+	library := m.systems.Database.SearchTracks(query, librarySearchLimit)
+
+	results, err := m.systems.API.Search(query)
+	if err != nil {
+		if len(library) > 0 {
+			logger.Warn("Showing library search results only: %v", err)
+			return tracksLoadedMsg(library)
+		}
+
+		return errorMsg(err)
+	}
+
+	return tracksLoadedMsg(mergeSearchResults(library, results.Tracks))
+}
@@ -0,0 +1,399 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/haryoiro/yutemal/internal/database"
+	"github.com/haryoiro/yutemal/internal/logger"
+	"github.com/haryoiro/yutemal/internal/structures"
+	"github.com/haryoiro/yutemal/internal/systems"
+)
+
+// playlistMaxNameLength limits the length of a local playlist name.
+const playlistMaxNameLength = 60
+
+// searchAddToPlaylistKey adds a search result to a playlist. Letters type
+// into the query in the search view, so it cannot use the usual binding.
+const searchAddToPlaylistKey = "ctrl+p"
+
+// playlistNameAction is what a playlist name being typed is for.
+type playlistNameAction int
+
+const (
+	nameNewPlaylist playlistNameAction = iota
+	nameRenamePlaylist
+	nameNewPlaylistWithTracks // from the add to playlist picker
+)
+
+type localPlaylistsMsg []systems.Playlist
+
+// playlistsChangedMsg reports a change to the local playlists.
+type playlistsChangedMsg struct{ err error }
+
+// changePlaylists runs a change to the local playlists off the UI loop.
+func (m *Model) changePlaylists(change func(db database.DB) error) tea.Cmd {
+	return func() tea.Msg {
+		return playlistsChangedMsg{err: change(m.systems.Database)}
+	}
+}
+
+// handlePlaylistsChanged reloads whatever shows the changed playlists.
+func (m *Model) handlePlaylistsChanged(msg playlistsChangedMsg) tea.Cmd {
+	if msg.err != nil {
+		logger.Error("Failed to change local playlists: %v", msg.err)
+	}
+
+	switch {
+	case m.state == PlaylistListView:
+		return m.loadPlaylists()
+	case m.state == PlaylistDetailView && m.playlistLocal:
+		return m.loadLocalPlaylistTracks(m.playlistID)
+	case m.state == AddToPlaylistView:
+		return m.loadLocalPlaylists()
+	default:
+		return nil
+	}
+}
+
+// loadLocalPlaylists reads the local playlists for the picker.
+func (m *Model) loadLocalPlaylists() tea.Cmd {
+	return func() tea.Msg {
+		return localPlaylistsMsg(m.systems.LocalPlaylists())
+	}
+}
+
+// loadLocalPlaylistTracks reads the tracks of a local playlist, which needs
+// no connection.
+func (m *Model) loadLocalPlaylistTracks(playlistID string) tea.Cmd {
+	return func() tea.Msg {
+		return tracksLoadedMsg(m.systems.Database.GetPlaylistTracks(playlistID))
+	}
+}
+
+// selectedPlaylist returns the playlist selected in the playlist list.
+func (m *Model) selectedPlaylist() (systems.Playlist, bool) {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.playlists) {
+		return systems.Playlist{}, false
+	}
+
+	return m.playlists[m.selectedIndex], true
+}
+
+// startPlaylistName starts typing a playlist name.
+func (m *Model) startPlaylistName(action playlistNameAction, name string) {
+	m.playlistNaming = true
+	m.playlistNameFor = action
+	m.playlistNameInput = name
+}
+
+// handleLocalPlaylistKeys handles the keys that manage local playlists in
+// the playlist list and detail views. It reports false for keys that the
+// main pane should handle as usual.
+func (m *Model) handleLocalPlaylistKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if m.playlistNaming {
+		return m, m.handlePlaylistNameKeys(msg), true
+	}
+
+	kb := m.config.KeyBindings
+
+	if m.playlistDeleting {
+		m.playlistDeleting = false
+
+		if playlist, ok := m.selectedPlaylist(); ok && playlist.Local && m.isKey(msg, "y") {
+			return m, m.changePlaylists(func(db database.DB) error {
+				return db.DeletePlaylist(playlist.ID)
+			}), true
+		}
+
+		return m, nil, true
+	}
+
+	if m.state == PlaylistListView {
+		playlist, ok := m.selectedPlaylist()
+
+		switch {
+		case m.isKey(msg, "n"):
+			m.startPlaylistName(nameNewPlaylist, "")
+		case ok && playlist.Local && m.isKey(msg, "R"):
+			m.startPlaylistName(nameRenamePlaylist, playlist.Title)
+		case ok && playlist.Local && m.isKey(msg, kb.RemoveTrack):
+			m.playlistDeleting = true
+		default:
+			return m, nil, false
+		}
+
+		return m, nil, true
+	}
+
+	if m.state != PlaylistDetailView || len(m.playlistTracks) == 0 {
+		return m, nil, false
+	}
+
+	index := min(m.playlistSelectedIndex, len(m.playlistTracks)-1)
+	playlistID := m.playlistID
+
+	switch {
+	case m.isKey(msg, kb.AddToPlaylist):
+		return m.openPlaylistPicker(m.playlistTracks[index : index+1])
+	case !m.playlistLocal:
+		return m, nil, false
+	case m.isKey(msg, kb.RemoveTrack):
+		trackID := m.playlistTracks[index].TrackID
+
+		return m, m.changePlaylists(func(db database.DB) error {
+			return db.RemovePlaylistTrack(playlistID, trackID)
+		}), true
+	case m.isKey(msg, kb.MoveTrackUp), m.isKey(msg, kb.MoveTrackDown):
+		to := index - 1
+		if m.isKey(msg, kb.MoveTrackDown) {
+			to = index + 1
+		}
+
+		if to < 0 || to >= len(m.playlistTracks) {
+			return m, nil, true
+		}
+
+		m.playlistSelectedIndex = to
+		m.adjustPlaylistScroll()
+
+		return m, m.changePlaylists(func(db database.DB) error {
+			return db.MovePlaylistTrack(playlistID, index, to)
+		}), true
+	default:
+		return m, nil, false
+	}
+}
+
+// handlePlaylistNameKeys edits the name of a playlist being created or
+// renamed.
+func (m *Model) handlePlaylistNameKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		name := strings.TrimSpace(m.playlistNameInput)
+		if name == "" {
+			return nil
+		}
+
+		m.playlistNaming = false
+
+		return m.commitPlaylistName(name)
+	case tea.KeyEsc:
+		m.playlistNaming = false
+	case tea.KeyBackspace:
+		if runes := []rune(m.playlistNameInput); len(runes) > 0 {
+			m.playlistNameInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		m.playlistNameInput += " "
+	case tea.KeyRunes:
+		if len([]rune(m.playlistNameInput)) < playlistMaxNameLength {
+			m.playlistNameInput += string(msg.Runes)
+		}
+	}
+
+	return nil
+}
+
+// commitPlaylistName creates or renames a playlist with a typed name.
+func (m *Model) commitPlaylistName(name string) tea.Cmd {
+	switch m.playlistNameFor {
+	case nameRenamePlaylist:
+		playlist, ok := m.selectedPlaylist()
+		if !ok || !playlist.Local {
+			return nil
+		}
+
+		return m.changePlaylists(func(db database.DB) error {
+			return db.RenamePlaylist(playlist.ID, name)
+		})
+	case nameNewPlaylistWithTracks:
+		tracks := m.pickerTracks
+		m.closePlaylistPicker()
+
+		return m.changePlaylists(func(db database.DB) error {
+			id, err := db.CreatePlaylist(name)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.AddPlaylistTracks(id, tracks)
+
+			return err
+		})
+	default:
+		return m.changePlaylists(func(db database.DB) error {
+			_, err := db.CreatePlaylist(name)
+			return err
+		})
+	}
+}
+
+// openPlaylistPicker switches the main pane to a list of local playlists to
+// add tracks to.
+func (m *Model) openPlaylistPicker(tracks []structures.Track) (tea.Model, tea.Cmd, bool) {
+	if len(tracks) == 0 {
+		return m, nil, true
+	}
+
+	if m.state != AddToPlaylistView {
+		m.pickerReturnState = m.state
+		m.pickerReturnFocus = m.getFocusedPane()
+		m.state = AddToPlaylistView
+	}
+
+	m.pickerTracks = slices.Clone(tracks)
+	m.pickerSelected = 0
+	m.playlistNaming = false
+	m.setFocus(FocusMain)
+
+	return m, m.loadLocalPlaylists(), true
+}
+
+// addQueueSelectionToPlaylist opens the picker for the selected queue tracks.
+func (m *Model) addQueueSelectionToPlaylist() (tea.Model, tea.Cmd) {
+	start, end := m.queueSelection()
+	if start >= end {
+		return m, nil
+	}
+
+	model, cmd, _ := m.openPlaylistPicker(m.playerState.List[start:end])
+
+	return model, cmd
+}
+
+// addSearchResultToPlaylist opens the picker for the selected search result.
+func (m *Model) addSearchResultToPlaylist() (tea.Model, tea.Cmd) {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.searchResults) {
+		return m, nil
+	}
+
+	model, cmd, _ := m.openPlaylistPicker(m.searchResults[m.selectedIndex : m.selectedIndex+1])
+
+	return model, cmd
+}
+
+// closePlaylistPicker returns to where the picker was opened from.
+func (m *Model) closePlaylistPicker() {
+	m.state = m.pickerReturnState
+	m.pickerTracks = nil
+	m.playlistNaming = false
+	m.setFocus(m.pickerReturnFocus)
+}
+
+// handlePlaylistPickerKeys handles keys in the add to playlist picker. The
+// first row creates a new playlist.
+func (m *Model) handlePlaylistPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if m.playlistNaming {
+		return m, m.handlePlaylistNameKeys(msg), true
+	}
+
+	kb := m.config.KeyBindings
+	rows := len(m.pickerPlaylists) + 1
+	m.pickerSelected = min(m.pickerSelected, rows-1)
+
+	switch {
+	case m.isKeyInList(msg, kb.MoveUp):
+		m.pickerSelected = max(m.pickerSelected-1, 0)
+	case m.isKeyInList(msg, kb.MoveDown):
+		m.pickerSelected = min(m.pickerSelected+1, rows-1)
+	case m.isKeyInList(msg, kb.Select):
+		if m.pickerSelected == 0 {
+			m.startPlaylistName(nameNewPlaylistWithTracks, "")
+			return m, nil, true
+		}
+
+		playlist := m.pickerPlaylists[m.pickerSelected-1]
+		tracks := m.pickerTracks
+		m.closePlaylistPicker()
+
+		return m, m.changePlaylists(func(db database.DB) error {
+			added, err := db.AddPlaylistTracks(playlist.ID, tracks)
+			logger.Debug("Added %d of %d tracks to %s", added, len(tracks), playlist.Title)
+
+			return err
+		}), true
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// renderPlaylistNamePrompt renders the name being typed, if any.
+func (m Model) renderPlaylistNamePrompt() string {
+	_, selectedStyle, normalStyle, _, _ := m.getStyles()
+
+	switch {
+	case m.playlistNaming:
+		label := "New playlist: "
+		if m.playlistNameFor == nameRenamePlaylist {
+			label = "Rename to: "
+		}
+
+		return "\n\n  " + label + selectedStyle.Render(m.playlistNameInput+"█")
+	case m.playlistDeleting:
+		if playlist, ok := m.selectedPlaylist(); ok {
+			return "\n\n  " + normalStyle.Render(fmt.Sprintf("Delete %q? (y/n)", playlist.Title))
+		}
+	}
+
+	return ""
+}
+
+func (m Model) renderPlaylistPicker(maxWidth int) string {
+	titleStyle, selectedStyle, normalStyle, dimStyle, _ := m.getStyles()
+
+	if m.hasFocus("main") {
+		titleStyle = titleStyle.Underline(true)
+	}
+
+	var b strings.Builder
+
+	title := fmt.Sprintf("➕ Add %d tracks to a playlist", len(m.pickerTracks))
+	if len(m.pickerTracks) == 1 {
+		title = "➕ Add " + m.pickerTracks[0].Title + " to a playlist"
+	}
+
+	b.WriteString("  " + titleStyle.Render(truncate(title, max(maxWidth-4, 10))))
+	b.WriteString("\n")
+	b.WriteString("  " + dimStyle.Render(truncate(m.shortcutFormatter.FormatHints(m.shortcutFormatter.GetPlaylistPickerHints()), max(maxWidth-4, 10))))
+	b.WriteString("\n\n")
+
+	rows := len(m.pickerPlaylists) + 1
+	selectedIndex := min(m.pickerSelected, rows-1)
+	visibleItems := max(m.contentHeight-8, 1)
+	start := max(selectedIndex-visibleItems+1, 0)
+	end := min(start+visibleItems, rows)
+
+	for i := start; i < end; i++ {
+		style := normalStyle
+		prefix := "   "
+
+		if i == selectedIndex {
+			style = selectedStyle
+			prefix = " ▶ "
+		}
+
+		label := "✚ New playlist…"
+		if i > 0 {
+			playlist := m.pickerPlaylists[i-1]
+			label = fmt.Sprintf("📝 %s (%d tracks)", playlist.Title, playlist.VideoCount)
+		}
+
+		availableWidth := maxWidth - runewidth.StringWidth(prefix) - 2
+		b.WriteString(style.Render(prefix + truncate(label, availableWidth)))
+
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(m.renderPlaylistNamePrompt())
+
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/haryoiro/yutemal/internal/database"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+func TestLocalPlaylistKeysReorderTracks(t *testing.T) {
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "yutemal.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	playlistID, err := db.CreatePlaylist("Practice")
+	if err != nil {
+		t.Fatalf("CreatePlaylist: %v", err)
+	}
+
+	tracks := []structures.Track{{TrackID: "a", Title: "A"}, {TrackID: "b", Title: "B"}, {TrackID: "c", Title: "C"}}
+	if _, err := db.AddPlaylistTracks(playlistID, tracks); err != nil {
+		t.Fatalf("AddPlaylistTracks: %v", err)
+	}
+
+	m := newKeyTestModel()
+	m.systems.Database = db
+	m.state = PlaylistDetailView
+	m.contentHeight = 20
+	m.playlistID = playlistID
+	m.playlistLocal = true
+	m.playlistSelectedIndex = 1
+
+	for _, step := range []struct {
+		key      string
+		selected int
+		want     []string
+	}{
+		{"K", 0, []string{"b", "a", "c"}},
+		{"K", 0, []string{"b", "a", "c"}}, // already at the top
+		{"J", 1, []string{"a", "b", "c"}},
+		{"J", 2, []string{"a", "c", "b"}},
+	} {
+		m.playlistTracks = db.GetPlaylistTracks(playlistID)
+
+		_, cmd := m.handleMainFocusKeys(runeKey(step.key))
+		if cmd != nil {
+			if msg, ok := cmd().(playlistsChangedMsg); !ok || msg.err != nil {
+				t.Fatalf("%s: got %#v, want a successful playlist change", step.key, msg)
+			}
+		}
+
+		if m.playlistSelectedIndex != step.selected {
+			t.Errorf("%s: selected %d, want %d", step.key, m.playlistSelectedIndex, step.selected)
+		}
+
+		var got []string
+		for _, track := range db.GetPlaylistTracks(playlistID) {
+			got = append(got, track.TrackID)
+		}
+
+		if !slices.Equal(got, step.want) {
+			t.Fatalf("%s: got %v, want %v", step.key, got, step.want)
+		}
+	}
+}
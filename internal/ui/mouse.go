@@ -16,6 +16,10 @@ func (m *Model) handleMouseEvent(mouse tea.MouseMsg) (tea.Model, tea.Cmd) {
 			return m.handleMouseClick(mouse.X, mouse.Y)
 		}
 
+		if m.state == EQView && !m.inQueuePane(mouse.X) && (mouse.Button == tea.MouseButtonWheelUp || mouse.Button == tea.MouseButtonWheelDown) {
+			return m.handleEQWheel(mouse.Button == tea.MouseButtonWheelUp)
+		}
+
 		if mouse.Button == tea.MouseButtonWheelUp {
 			return m.handleScrollUp()
 		}
@@ -23,6 +27,20 @@ func (m *Model) handleMouseEvent(mouse tea.MouseMsg) (tea.Model, tea.Cmd) {
 		if mouse.Button == tea.MouseButtonWheelDown {
 			return m.handleScrollDown()
 		}
+
+	case tea.MouseActionMotion:
+		// Dragging a track in the queue
+		if mouse.Button == tea.MouseButtonLeft && m.queueDragging {
+			return m.handleQueueDrag(mouse.Y)
+		}
+
+		// Dragging a slider
+		if mouse.Button == tea.MouseButtonLeft && m.state == EQView && mouse.Y < m.height-m.playerHeight {
+			return m.handleEQMouse(mouse.X, mouse.Y)
+		}
+
+	case tea.MouseActionRelease:
+		m.queueDragging = false
 	}
 
 	return m, nil
@@ -36,6 +54,10 @@ func (m *Model) handleMouseClick(x, y int) (tea.Model, tea.Cmd) {
 		return m.handlePlayerClick(x, y-playerAreaStart)
 	}
 
+	if m.inQueuePane(x) {
+		return m.handleQueuePress(y)
+	}
+
 	return m.handleContentClick(x, y)
 }
 
@@ -51,7 +73,7 @@ func (m *Model) handlePlayerClick(x, y int) (tea.Model, tea.Cmd) {
 		timeDisplayWidth := 6
 		progressBarStart := timeDisplayWidth
 
-		barWidth := m.playerContentWidth - (timeDisplayWidth * 2)
+		barWidth := m.playerContentWidth - (timeDisplayWidth * 2) - m.spectrumWidth()
 		if barWidth <= 0 {
 			return m, nil
 		}
@@ -79,6 +101,9 @@ func (m *Model) handleContentClick(x, y int) (tea.Model, tea.Cmd) {
 	contentY := y - 1
 
 	switch m.state {
+	case EQView:
+		return m.handleEQMouse(x, y)
+
 	case PlaylistDetailView:
 		listStartY := 4
 		relativeY := contentY - listStartY
@@ -204,6 +204,21 @@ func (m *Model) navigateBack() (tea.Model, tea.Cmd) {
 		m.searchQuery = ""
 		m.searchResults = nil
 		m.setFocus(FocusMain)
+	case EQView:
+		logger.Debug("navigateBack: Closing the EQ editor")
+		m.closeEQEditor()
+	case BookmarksView:
+		logger.Debug("navigateBack: Closing the bookmarks")
+		m.closeBookmarks()
+	case HistoryView:
+		logger.Debug("navigateBack: Closing the history")
+		m.closeHistory()
+	case AddToPlaylistView:
+		logger.Debug("navigateBack: Closing the playlist picker")
+		m.closePlaylistPicker()
+	case StatsView:
+		logger.Debug("navigateBack: Closing the stats")
+		m.closeStats()
 	case PlaylistListView:
 		logger.Debug("navigateBack: Already at PlaylistListView, ignoring")
 	default:
@@ -113,7 +113,7 @@ func (m *Model) renderPlayer() string {
 		// Time format is always "MM:SS" so both are 5 characters
 		timeWidth := 10 + 2 // Two time displays (5 chars each) plus 2 spaces
 
-		barWidth := max(contentWidth-timeWidth*2+6, 10)
+		barWidth := max(contentWidth-timeWidth*2+6-m.spectrumWidth(), 10)
 
 		progressBar := m.renderProgressBar(barWidth)
 
@@ -121,6 +121,10 @@ func (m *Model) renderPlayer() string {
 			timeStyle.Render(currentTime),
 			progressBar,
 			timeStyle.Render(totalTime)))
+
+		if m.spectrumWidth() > 0 {
+			content.WriteString(" " + m.renderSpectrum())
+		}
 	} else {
 		// Get progressBgStyle for empty player
 		var progressBgStyle lipgloss.Style
@@ -134,7 +138,7 @@ func (m *Model) renderPlayer() string {
 		// Calculate exact width for empty progress bar
 		timeWidth := TimeFormatWidth*2 + 2 // 2 time displays + 2 spaces
 
-		barWidth := max(contentWidth-timeWidth*2+6, 10)
+		barWidth := max(contentWidth-timeWidth*2+6-m.spectrumWidth(), 10)
 
 		bar := progressBgStyle.Render(strings.Repeat("─", barWidth))
 		content.WriteString(fmt.Sprintf("%s %s %s",
@@ -182,6 +186,12 @@ func (m *Model) renderProgressBar(width int) string {
 	filled := int(float64(width) * progress)
 	empty := width - filled
 
+	// Cells past the downloaded part of a streaming track
+	unbuffered := 0
+	if buffered := m.playerState.Buffered; buffered > 0 && buffered < 1 {
+		unbuffered = min(width-int(float64(width)*buffered), empty)
+	}
+
 	// Choose style based on config
 	style := m.config.Theme.ProgressBarStyle
 	if style == "" {
@@ -197,9 +207,7 @@ func (m *Model) renderProgressBar(width int) string {
 			bar.WriteString(progressBarStyle.Render(strings.Repeat(ProgressBlockFilled, filled)))
 		}
 
-		if empty > 0 {
-			bar.WriteString(progressBgStyle.Render(strings.Repeat(ProgressBlockEmpty, empty)))
-		}
+		bar.WriteString(renderRemaining(progressBgStyle, ProgressBlockEmpty, ProgressBlockUnbuffered, empty, unbuffered))
 
 	case "line":
 		// Line style with simple lines
@@ -207,9 +215,7 @@ func (m *Model) renderProgressBar(width int) string {
 			bar.WriteString(progressBarStyle.Render(strings.Repeat(ProgressLineFilled, filled)))
 		}
 
-		if empty > 0 {
-			bar.WriteString(progressBgStyle.Render(strings.Repeat(ProgressLineEmpty, empty)))
-		}
+		bar.WriteString(renderRemaining(progressBgStyle, ProgressLineEmpty, ProgressLineUnbuffered, empty, unbuffered))
 
 	case "gradient":
 		// Gradient style with smooth transition
@@ -219,9 +225,7 @@ func (m *Model) renderProgressBar(width int) string {
 			bar.WriteString(gradientBar)
 		}
 
-		if empty > 0 {
-			bar.WriteString(progressBgStyle.Render(strings.Repeat(ProgressGradientEmpty, empty)))
-		}
+		bar.WriteString(renderRemaining(progressBgStyle, ProgressGradientEmpty, ProgressGradientUnbuffered, empty, unbuffered))
 
 	case "rainbow":
 		// Rainbow gradient style with animation
@@ -230,9 +234,7 @@ func (m *Model) renderProgressBar(width int) string {
 			bar.WriteString(rainbowBar)
 		}
 
-		if empty > 0 {
-			bar.WriteString(progressBgStyle.Render(strings.Repeat(ProgressGradientEmpty, empty)))
-		}
+		bar.WriteString(renderRemaining(progressBgStyle, ProgressGradientEmpty, ProgressGradientUnbuffered, empty, unbuffered))
 
 	default:
 		// Default to gradient
@@ -241,14 +243,22 @@ func (m *Model) renderProgressBar(width int) string {
 			bar.WriteString(gradientBar)
 		}
 
-		if empty > 0 {
-			bar.WriteString(progressBgStyle.Render(strings.Repeat(ProgressGradientEmpty, empty)))
-		}
+		bar.WriteString(renderRemaining(progressBgStyle, ProgressGradientEmpty, ProgressGradientUnbuffered, empty, unbuffered))
 	}
 
 	return bar.String()
 }
 
+// renderRemaining renders the unplayed part of the progress bar, marking
+// its last unbuffered cells as not yet downloaded.
+func renderRemaining(style lipgloss.Style, emptySymbol, unbufferedSymbol string, empty, unbuffered int) string {
+	if empty <= 0 {
+		return ""
+	}
+
+	return style.Render(strings.Repeat(emptySymbol, empty-unbuffered) + strings.Repeat(unbufferedSymbol, unbuffered))
+}
+
 // createGradientBar creates a gradient effect between two colors.
 func (m *Model) createGradientBar(width int, startColor, endColor string) string {
 	if width <= 0 {
@@ -407,9 +417,68 @@ func (m *Model) renderControls(availableWidth int) string {
 
 	parts = append(parts, fmt.Sprintf("%s %d%%", volumeIcon, volume))
 
+	// Playback speed
+	if speed := m.playerState.Speed; speed != 0 && math.Abs(speed-1) > 0.001 {
+		parts = append(parts, fmt.Sprintf("⏩ %.1fx", speed))
+	}
+
 	// EQ preset
-	if m.playerState.EQEnabled && m.eqPresetIndex > 0 {
-		parts = append(parts, fmt.Sprintf("EQ:%s", eqPresetOrder[m.eqPresetIndex]))
+	if preset := m.playerState.EQPreset; m.playerState.EQEnabled && preset != "" && preset != "flat" {
+		parts = append(parts, fmt.Sprintf("EQ:%s", preset))
+	}
+
+	// Sleep timer
+	switch state := m.playerState; {
+	case state.SleepMode == structures.SleepAfterDuration:
+		parts = append(parts, "💤 "+formatDuration(int(math.Ceil(state.SleepLeft.Seconds()))))
+	case state.SleepMode == structures.SleepAfterTracks && state.SleepTracks > 0:
+		parts = append(parts, fmt.Sprintf("💤 +%d tracks", state.SleepTracks))
+	case state.SleepMode == structures.SleepAfterTracks:
+		parts = append(parts, "💤 End of track")
+	}
+
+	// Shuffle and repeat
+	if m.playerState.Shuffled {
+		parts = append(parts, "🔀 Shuffle")
+	}
+
+	switch m.playerState.Repeat {
+	case structures.RepeatAll:
+		parts = append(parts, "🔁 All")
+	case structures.RepeatOne:
+		parts = append(parts, "🔂 One")
+	}
+
+	// A-B loop
+	if status := loopStatus(m.playerState.Loop); status != "" {
+		parts = append(parts, status)
+	}
+
+	// Channel tools
+	if m.playerState.Mono {
+		parts = append(parts, "Mono")
+	}
+
+	if balance := m.playerState.Balance; math.Abs(balance) > 0.001 {
+		side := "R"
+		if balance < 0 {
+			side = "L"
+		}
+		parts = append(parts, fmt.Sprintf("Bal %s%d", side, int(math.Round(math.Abs(balance)*100))))
+	}
+
+	if m.playerState.Crossfeed {
+		parts = append(parts, "Crossfeed")
+	}
+
+	if m.playerState.Karaoke {
+		parts = append(parts, "Karaoke")
+	}
+
+	// Clip indicator: the EQ curve or preamp is too hot for this track
+	if m.playerState.Clipped > 0 {
+		_, _, _, _, errorStyle := m.getStyles()
+		parts = append(parts, errorStyle.Render("● CLIP"))
 	}
 
 	// Bitrate info
@@ -427,7 +496,11 @@ func (m *Model) renderControls(availableWidth int) string {
 		video := m.playerState.List[m.playerState.Current]
 		if status, exists := m.playerState.MusicStatus[video.TrackID]; exists {
 			if status == structures.Downloading {
-				parts = append(parts, "⬇️  Downloading")
+				if buffered := m.playerState.Buffered; buffered < 1 {
+					parts = append(parts, fmt.Sprintf("⬇️  Buffered %d%%", int(buffered*100)))
+				} else {
+					parts = append(parts, "⬇️  Downloading")
+				}
 			}
 		}
 	}
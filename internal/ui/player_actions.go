@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"math"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/haryoiro/yutemal/internal/logger"
@@ -39,9 +42,103 @@ func (m *Model) seekBackward() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// shuffleQueue shuffles the current queue.
+// speedStep is the playback speed change per key press.
+const speedStep = 0.1
+
+// changeSpeed adjusts the playback speed by delta; the player clamps it to
+// its supported range.
+func (m *Model) changeSpeed(delta float64) (tea.Model, tea.Cmd) {
+	speed := m.playerState.Speed
+	if speed == 0 {
+		speed = 1
+	}
+
+	// Round to the step so repeated presses do not accumulate float error
+	speed = math.Round((speed+delta)/speedStep) * speedStep
+	m.systems.Player.SendAction(structures.SetSpeedAction{Speed: speed})
+
+	return m, nil
+}
+
+// sleepTimerOptions are the sleep timer settings the sleep key steps
+// through, ending with off.
+var sleepTimerOptions = []structures.SetSleepTimerAction{
+	{Mode: structures.SleepAfterDuration, Duration: 15 * time.Minute},
+	{Mode: structures.SleepAfterDuration, Duration: 30 * time.Minute},
+	{Mode: structures.SleepAfterDuration, Duration: 60 * time.Minute},
+	{Mode: structures.SleepAfterDuration, Duration: 90 * time.Minute},
+	{Mode: structures.SleepAfterTracks, Tracks: 0},
+	{Mode: structures.SleepAfterTracks, Tracks: 3},
+	{Mode: structures.SleepOff},
+}
+
+// cycleSleepTimer switches to the next sleep timer setting. With the timer
+// off, for example after it has run out, it starts from the first one.
+func (m *Model) cycleSleepTimer() (tea.Model, tea.Cmd) {
+	if m.playerState.SleepMode == structures.SleepOff {
+		m.sleepOption = 0
+	} else {
+		m.sleepOption = (m.sleepOption + 1) % len(sleepTimerOptions)
+	}
+
+	m.systems.Player.SendAction(sleepTimerOptions[m.sleepOption])
+
+	return m, nil
+}
+
+// cycleRepeat switches repeat off, all, one in turn.
+func (m *Model) cycleRepeat() (tea.Model, tea.Cmd) {
+	m.playerState.Repeat = m.playerState.Repeat.Next()
+	m.systems.Player.SendAction(structures.SetRepeatAction{Mode: m.playerState.Repeat})
+
+	return m, nil
+}
+
+// balanceStep is the balance change per key press.
+const balanceStep = 0.1
+
+// changeBalance moves the balance by delta towards the right.
+func (m *Model) changeBalance(delta float64) (tea.Model, tea.Cmd) {
+	balance := math.Round((m.playerState.Balance+delta)/balanceStep) * balanceStep
+	m.systems.Player.SendAction(structures.SetBalanceAction{Balance: balance})
+
+	return m, nil
+}
+
+// handleChannelKeys handles the channel tool keys, reporting whether msg
+// was one of them.
+func (m *Model) handleChannelKeys(msg tea.KeyMsg) bool {
+	kb := m.config.KeyBindings
+
+	switch {
+	case m.isKey(msg, kb.ToggleMono):
+		m.systems.Player.SendAction(structures.ToggleMonoAction{})
+	case m.isKey(msg, kb.ToggleCrossfeed):
+		m.systems.Player.SendAction(structures.ToggleCrossfeedAction{})
+	case m.isKey(msg, kb.ToggleKaraoke):
+		m.systems.Player.SendAction(structures.ToggleKaraokeAction{})
+	case m.isKey(msg, kb.BalanceLeft):
+		m.changeBalance(-balanceStep)
+	case m.isKey(msg, kb.BalanceRight):
+		m.changeBalance(balanceStep)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// shuffleQueue turns shuffle on or off. Turning it off goes back to the
+// original order from the current track.
 func (m *Model) shuffleQueue() (tea.Model, tea.Cmd) {
-	m.systems.Player.SendAction(structures.ShuffleQueueAction{})
+	if m.playerState.Shuffled {
+		m.systems.Player.SendAction(structures.UnshuffleQueueAction{})
+	} else {
+		m.systems.Player.SendAction(structures.ShuffleQueueAction{})
+	}
+
+	m.playerState.Shuffled = !m.playerState.Shuffled
+
 	return m, nil
 }
 
@@ -98,13 +195,8 @@ func (m *Model) handleQueueSelection() (tea.Model, tea.Cmd) {
 	return m.handleEnter()
 }
 
-// eqCyclePreset cycles through EQ presets.
+// eqCyclePreset cycles through the built-in and user EQ presets.
 func (m *Model) eqCyclePreset() (tea.Model, tea.Cmd) {
-	m.eqPresetIndex = (m.eqPresetIndex + 1) % len(eqPresetOrder)
-	presetName := eqPresetOrder[m.eqPresetIndex]
-	m.systems.Player.SendAction(structures.EQSetPresetAction{Preset: presetName})
+	m.systems.Player.SendAction(structures.EQCyclePresetAction{})
 	return m, nil
 }
-
-// eqPresetOrder mirrors player.PresetOrder for the UI layer.
-var eqPresetOrder = []string{"flat", "bass_boost", "treble_boost", "vocal", "rock", "electronic", "acoustic"}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// queueListTop is the screen row of the first track in the queue pane:
+// the border, the title, the hint line and a blank line come before it.
+const queueListTop = 4
+
+// queueSelection returns the selected range of the queue, end exclusive:
+// the visual selection, or just the selected track.
+func (m *Model) queueSelection() (start, end int) {
+	start, end = m.queueSelectedIndex, m.queueSelectedIndex
+	if m.queueVisual {
+		start, end = min(m.queueAnchor, m.queueSelectedIndex), max(m.queueAnchor, m.queueSelectedIndex)
+	}
+
+	return max(start, 0), min(end+1, len(m.playerState.List))
+}
+
+// inQueueSelection reports whether a queue index is part of the visual
+// selection.
+func (m *Model) inQueueSelection(index int) bool {
+	if !m.queueVisual {
+		return false
+	}
+
+	start, end := m.queueSelection()
+
+	return index >= start && index < end
+}
+
+// handleQueueEditKeys handles the keys that edit the queue. It reports
+// false for keys that the queue pane should handle as usual.
+func (m *Model) handleQueueEditKeys(msg tea.KeyMsg) bool {
+	kb := m.config.KeyBindings
+
+	switch {
+	case m.isKey(msg, kb.VisualSelect):
+		m.queueVisual = !m.queueVisual
+		m.queueAnchor = m.queueSelectedIndex
+	case m.queueVisual && m.isKeyInList(msg, kb.Back):
+		m.queueVisual = false
+	case m.isKey(msg, kb.MoveTrackUp):
+		start, _ := m.queueSelection()
+		m.moveQueueSelection(start - 1)
+	case m.isKey(msg, kb.MoveTrackDown):
+		start, _ := m.queueSelection()
+		m.moveQueueSelection(start + 1)
+	case m.isKey(msg, kb.MoveToTop):
+		m.moveQueueSelection(0)
+	case m.isKey(msg, kb.MoveToEnd):
+		start, end := m.queueSelection()
+		m.moveQueueSelection(len(m.playerState.List) - (end - start))
+	case m.queueVisual && m.isKey(msg, kb.RemoveTrack):
+		m.deleteQueueSelection()
+	default:
+		return false
+	}
+
+	return true
+}
+
+// moveQueueSelection moves the selected tracks so that the first of them
+// ends up at index to, keeping them selected.
+func (m *Model) moveQueueSelection(to int) {
+	start, end := m.queueSelection()
+	if end <= start || to < 0 || to > len(m.playerState.List)-(end-start) || to == start {
+		return
+	}
+
+	if end-start == 1 {
+		m.systems.Player.SendAction(structures.MoveTrackAction{From: start, To: to})
+	} else {
+		m.systems.Player.SendAction(structures.MoveTracksAction{Start: start, End: end, To: to})
+	}
+
+	m.queueSelectedIndex += to - start
+	m.queueAnchor += to - start
+}
+
+// deleteQueueSelection removes the visually selected tracks and leaves
+// visual mode.
+func (m *Model) deleteQueueSelection() {
+	start, end := m.queueSelection()
+
+	indices := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		indices = append(indices, i)
+	}
+
+	if len(indices) > 0 {
+		m.systems.Player.SendAction(structures.DeleteTracksAction{Indices: indices})
+	}
+
+	m.queueVisual = false
+	m.queueSelectedIndex = max(min(start, len(m.playerState.List)-len(indices)-1), 0)
+}
+
+// inQueuePane reports whether a screen column belongs to the queue pane.
+func (m *Model) inQueuePane(x int) bool {
+	return m.showQueue && m.queueWidth > 0 && x >= m.width-m.queueWidth
+}
+
+// queueIndexAt returns the queue index of the track at a screen row,
+// clamped to the tracks on screen.
+func (m *Model) queueIndexAt(y int) (int, bool) {
+	if len(m.playerState.List) == 0 {
+		return 0, false
+	}
+
+	// As in renderQueue, inside the pane's border
+	visibleLines := max(m.contentHeight-6, 1)
+	row := min(max(y-queueListTop, 0), visibleLines-1)
+
+	return min(m.queueScrollOffset+row, len(m.playerState.List)-1), true
+}
+
+// handleQueuePress selects the track under the mouse and starts a drag.
+func (m *Model) handleQueuePress(y int) (tea.Model, tea.Cmd) {
+	m.setFocus(FocusQueue)
+
+	if y < queueListTop {
+		return m, nil
+	}
+
+	index, ok := m.queueIndexAt(y)
+	if !ok {
+		return m, nil
+	}
+
+	m.queueVisual = false
+	m.queueSelectedIndex = index
+	m.queueDragging = true
+
+	return m, nil
+}
+
+// handleQueueDrag moves the dragged track to the row under the mouse.
+func (m *Model) handleQueueDrag(y int) (tea.Model, tea.Cmd) {
+	index, ok := m.queueIndexAt(y)
+	if !ok || index == m.queueSelectedIndex {
+		return m, nil
+	}
+
+	m.systems.Player.SendAction(structures.MoveTrackAction{From: m.queueSelectedIndex, To: index})
+	m.queueSelectedIndex = index
+
+	return m, nil
+}
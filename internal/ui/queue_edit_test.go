@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/haryoiro/yutemal/internal/config"
+	"github.com/haryoiro/yutemal/internal/structures"
+	"github.com/haryoiro/yutemal/internal/systems"
+)
+
+// newKeyTestModel returns a model with the default config whose player
+// drops the actions sent to it.
+func newKeyTestModel() *Model {
+	cfg := config.Default()
+
+	return &Model{
+		config:  cfg,
+		systems: &systems.Systems{Config: cfg, Player: &systems.PlayerSystem{}},
+	}
+}
+
+func runeKey(key string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestQueueEditKeysMoveTheSelection(t *testing.T) {
+	m := newKeyTestModel()
+	m.playerState.List = make([]structures.Track, 5)
+	m.queueSelectedIndex = 2
+
+	for _, step := range []struct {
+		key  tea.KeyMsg
+		want int
+	}{
+		{runeKey("K"), 1},
+		{runeKey("J"), 2},
+		{runeKey("J"), 3},
+		{runeKey("T"), 0},
+		{runeKey("E"), 4},
+		{runeKey("E"), 4},
+		{runeKey("K"), 3},
+	} {
+		m.handleQueueFocusKeys(step.key)
+
+		if m.queueSelectedIndex != step.want {
+			t.Fatalf("after %s: selected %d, want %d", step.key, m.queueSelectedIndex, step.want)
+		}
+	}
+}
+
+func TestQueueEditKeysMoveVisualSelection(t *testing.T) {
+	m := newKeyTestModel()
+	m.showQueue, m.queueFocused = true, true
+	m.contentHeight = 20
+	m.playerState.List = make([]structures.Track, 5)
+	m.queueSelectedIndex = 1
+
+	m.handleQueueFocusKeys(runeKey("v"))
+	m.handleQueueFocusKeys(tea.KeyMsg{Type: tea.KeyDown})
+	m.handleQueueFocusKeys(runeKey("E"))
+
+	if start, end := m.queueSelection(); start != 3 || end != 5 {
+		t.Errorf("selection after moving to the end: got [%d, %d), want [3, 5)", start, end)
+	}
+}
+
+func TestIsKeyMatchesControlKeys(t *testing.T) {
+	m := newKeyTestModel()
+	m.config.KeyBindings.MoveTrackUp = "ctrl+k"
+	m.playerState.List = make([]structures.Track, 3)
+	m.queueSelectedIndex = 2
+
+	m.handleQueueFocusKeys(tea.KeyMsg{Type: tea.KeyCtrlK})
+
+	if m.queueSelectedIndex != 1 {
+		t.Errorf("ctrl+k: selected %d, want 1", m.queueSelectedIndex)
+	}
+
+	if m.isKey(runeKey("k"), "ctrl+k") {
+		t.Error(`"k" matched ctrl+k`)
+	}
+}
@@ -168,6 +168,18 @@ func (sf *ShortcutFormatter) GetPlayerHints(focused bool) []ShortcutHint {
 			{Key: upArrow + "/" + downArrow, Action: "Volume"},
 			{Key: leftArrow + "/" + rightArrow, Action: "Seek"},
 			{Key: sf.formatKey(kb.ToggleEQ), Action: "EQ"},
+			{Key: sf.formatKey(kb.EQEditor), Action: "EQ Editor"},
+			{Key: sf.formatKey(kb.SpeedDown) + "/" + sf.formatKey(kb.SpeedUp), Action: "Speed"},
+			{Key: sf.formatKey(kb.SleepTimer), Action: "Sleep"},
+			{Key: sf.formatKey(kb.Repeat), Action: "Repeat"},
+			{Key: sf.formatKey(kb.ABLoop), Action: "A-B Loop"},
+			{Key: sf.formatKey(kb.Bookmarks), Action: "Bookmarks"},
+			{Key: sf.formatKey(kb.History), Action: "History"},
+			{Key: sf.formatKey(kb.Stats), Action: "Stats"},
+			{Key: sf.formatKey(kb.BalanceLeft) + "/" + sf.formatKey(kb.BalanceRight), Action: "Balance"},
+			{Key: sf.formatKey(kb.ToggleMono), Action: "Mono"},
+			{Key: sf.formatKey(kb.ToggleCrossfeed), Action: "Crossfeed"},
+			{Key: sf.formatKey(kb.ToggleKaraoke), Action: "Karaoke"},
 			{Key: sf.formatKey("tab"), Action: "Next Pane"},
 		}
 	}
@@ -186,6 +198,9 @@ func (sf *ShortcutFormatter) GetPlaylistListHints(showQueue bool) []ShortcutHint
 	hints := []ShortcutHint{
 		{Key: sf.formatKeys(kb.Select), Action: "Open"},
 		{Key: sf.formatKeys(kb.Search), Action: "Search"},
+		{Key: "n", Action: "New Playlist"},
+		{Key: sf.formatKey(kb.History), Action: "History"},
+		{Key: sf.formatKey(kb.Stats), Action: "Stats"},
 		{Key: sf.formatKey("tab"), Action: "Next Pane"},
 	}
 
@@ -204,6 +219,7 @@ func (sf *ShortcutFormatter) GetPlaylistHints(showQueue bool) []ShortcutHint {
 	hints := []ShortcutHint{
 		{Key: sf.formatKeys(kb.Select), Action: "Play from Here"},
 		{Key: "a", Action: "Add Next"},
+		{Key: sf.formatKey(kb.AddToPlaylist), Action: "Add to Playlist"},
 		{Key: sf.formatKey(kb.RemoveTrack), Action: "Remove"},
 		{Key: sf.formatKeys(kb.Back), Action: "Back"},
 		{Key: sf.formatKey("tab"), Action: "Next Pane"},
@@ -236,6 +252,10 @@ func (sf *ShortcutFormatter) GetQueueHints(hasFocus bool) []ShortcutHint {
 		{Key: sf.formatKeys(kb.MoveUp) + "/" + sf.formatKeys(kb.MoveDown), Action: "Navigate"},
 		{Key: sf.formatKeys(kb.Select), Action: "Play"},
 		{Key: sf.formatKey(kb.RemoveTrack), Action: "Remove"},
+		{Key: sf.formatKey(kb.MoveTrackUp) + "/" + sf.formatKey(kb.MoveTrackDown), Action: "Move"},
+		{Key: sf.formatKey(kb.MoveToTop) + "/" + sf.formatKey(kb.MoveToEnd), Action: "Top/End"},
+		{Key: sf.formatKey(kb.VisualSelect), Action: "Select"},
+		{Key: sf.formatKey(kb.AddToPlaylist), Action: "Add to Playlist"},
 	}
 
 	if !hasFocus {
@@ -253,10 +273,73 @@ func (sf *ShortcutFormatter) GetSearchHints() []ShortcutHint {
 
 	return []ShortcutHint{
 		{Key: sf.formatKey("enter"), Action: "Search"},
+		{Key: sf.formatKey(searchSourceKey), Action: "Online/Library"},
+		{Key: sf.formatKey(searchAddToPlaylistKey), Action: "Add to Playlist"},
 		{Key: sf.formatKeys(kb.Back), Action: "Cancel"},
 	}
 }
 
+// GetPlaylistPickerHints returns add to playlist picker shortcuts.
+func (sf *ShortcutFormatter) GetPlaylistPickerHints() []ShortcutHint {
+	kb := sf.config.KeyBindings
+
+	return []ShortcutHint{
+		{Key: sf.formatKeys(kb.Select), Action: "Add"},
+		{Key: sf.formatKeys(kb.Back), Action: "Cancel"},
+	}
+}
+
+// GetEQHints returns EQ editor shortcuts.
+func (sf *ShortcutFormatter) GetEQHints() []ShortcutHint {
+	kb := sf.config.KeyBindings
+
+	return []ShortcutHint{
+		{Key: leftArrow + "/" + rightArrow, Action: "Band"},
+		{Key: upArrow + "/" + downArrow, Action: "Gain"},
+		{Key: "v", Action: "Bypass"},
+		{Key: "0", Action: "Flat"},
+		{Key: "w", Action: "Save"},
+		{Key: sf.formatKey(kb.ToggleEQ), Action: "Preset"},
+		{Key: sf.formatKeys(kb.Back), Action: "Back"},
+	}
+}
+
+// GetBookmarkHints returns bookmark list shortcuts.
+func (sf *ShortcutFormatter) GetBookmarkHints() []ShortcutHint {
+	kb := sf.config.KeyBindings
+
+	return []ShortcutHint{
+		{Key: sf.formatKeys(kb.Select), Action: "Jump"},
+		{Key: "a", Action: "Add"},
+		{Key: sf.formatKey(kb.RemoveTrack), Action: "Delete"},
+		{Key: sf.formatKey(kb.ABLoop), Action: "A-B Loop"},
+		{Key: sf.formatKeys(kb.Back), Action: "Back"},
+	}
+}
+
+// GetHistoryHints returns listening history shortcuts.
+func (sf *ShortcutFormatter) GetHistoryHints() []ShortcutHint {
+	kb := sf.config.KeyBindings
+
+	return []ShortcutHint{
+		{Key: sf.formatKeys(kb.Select), Action: "Play"},
+		{Key: "a", Action: "Play Next"},
+		{Key: "t", Action: "Range"},
+		{Key: sf.formatKeys(kb.Back), Action: "Back"},
+	}
+}
+
+// GetStatsHints returns listening statistics shortcuts.
+func (sf *ShortcutFormatter) GetStatsHints() []ShortcutHint {
+	kb := sf.config.KeyBindings
+
+	return []ShortcutHint{
+		{Key: sf.formatKeys(kb.MoveUp) + "/" + sf.formatKeys(kb.MoveDown), Action: "Scroll"},
+		{Key: "t", Action: "Range"},
+		{Key: sf.formatKeys(kb.Back), Action: "Back"},
+	}
+}
+
 // GetContextualHints returns shortcuts based on the current UI state.
 func (sf *ShortcutFormatter) GetContextualHints(state ViewState, showQueue bool, hasFocus func(string) bool) string {
 	if hasFocus("player") {
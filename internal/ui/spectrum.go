@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Spectrum analyzer display.
+const (
+	spectrumCells     = 16                    // width next to the progress bar
+	spectrumMinWidth  = 60                    // narrower player panes leave it out
+	spectrumInterval  = 50 * time.Millisecond // refresh rate while playing
+	spectrumFalloff   = 0.06                  // level a bar drops per refresh
+	spectrumBarLevels = "▁▂▃▄▅▆▇█"
+)
+
+type spectrumMsg []float64
+
+// spectrumStyle returns the configured spectrum style, "bars" by default.
+func (m *Model) spectrumStyle() string {
+	if m.config.Theme.SpectrumStyle == "" {
+		return "bars"
+	}
+
+	return m.config.Theme.SpectrumStyle
+}
+
+// spectrumWidth returns the cells the spectrum takes next to the progress
+// bar, including the space before it, or 0 if it is not shown.
+func (m *Model) spectrumWidth() int {
+	if m.spectrumStyle() == "off" || m.playerContentWidth < spectrumMinWidth {
+		return 0
+	}
+
+	return spectrumCells + 1
+}
+
+// shouldAnalyze reports whether the spectrum needs refreshing: it is shown,
+// the terminal has focus and something is playing.
+func (m *Model) shouldAnalyze() bool {
+	return m.spectrumWidth() > 0 && !m.blurred && m.playerState.IsPlaying
+}
+
+// checkSpectrumCmd starts refreshing the spectrum if it is not already.
+func (m *Model) checkSpectrumCmd() tea.Cmd {
+	if !m.shouldAnalyze() || m.spectrumActive {
+		return nil
+	}
+
+	m.spectrumActive = true
+
+	return m.spectrumCmd()
+}
+
+// spectrumCmd computes the next spectrum frame. The FFT runs here, in the
+// command's goroutine, not on the audio thread.
+func (m *Model) spectrumCmd() tea.Cmd {
+	bands := spectrumCells
+	if m.spectrumStyle() == "braille" {
+		bands *= 2
+	}
+
+	player := m.systems.Player
+
+	return tea.Tick(spectrumInterval, func(time.Time) tea.Msg {
+		bars := make([]float64, bands)
+		if !player.Spectrum(bars) {
+			return spectrumMsg(nil)
+		}
+
+		return spectrumMsg(bars)
+	})
+}
+
+// updateSpectrum takes a new frame, letting bars fall gradually, and
+// schedules the next one while the analyzer is needed.
+func (m *Model) updateSpectrum(msg spectrumMsg) tea.Cmd {
+	if msg == nil || !m.shouldAnalyze() {
+		m.spectrumActive = false
+		return nil
+	}
+
+	if len(m.spectrum) != len(msg) {
+		m.spectrum = make([]float64, len(msg))
+	}
+
+	for i, v := range msg {
+		m.spectrum[i] = math.Max(v, m.spectrum[i]-spectrumFalloff)
+	}
+
+	return m.spectrumCmd()
+}
+
+// renderSpectrum draws the last spectrum frame in spectrumCells cells.
+func (m *Model) renderSpectrum() string {
+	if m.playerState.TotalTime == 0 {
+		return strings.Repeat(" ", spectrumCells)
+	}
+
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
+	if m.themeManager != nil {
+		style = m.themeManager.ProgressFillStyle()
+	}
+
+	levels := []rune(spectrumBarLevels)
+	braille := m.spectrumStyle() == "braille"
+
+	var b strings.Builder
+	for i := range spectrumCells {
+		if !braille {
+			b.WriteRune(levels[quantize(m.spectrumLevel(i), len(levels)-1)])
+			continue
+		}
+
+		// Two bands per cell, four dots high, filled from the bottom
+		left := []rune{0x40, 0x04, 0x02, 0x01}
+		right := []rune{0x80, 0x20, 0x10, 0x08}
+
+		dots := rune(0x2800)
+		for j := range quantize(m.spectrumLevel(2*i), 4) {
+			dots |= left[j]
+		}
+		for j := range quantize(m.spectrumLevel(2*i+1), 4) {
+			dots |= right[j]
+		}
+
+		b.WriteRune(dots)
+	}
+
+	return style.Render(b.String())
+}
+
+// spectrumLevel returns the level of a band, 0 before the first frame.
+func (m *Model) spectrumLevel(i int) float64 {
+	if i < len(m.spectrum) {
+		return m.spectrum[i]
+	}
+
+	return 0
+}
+
+// quantize maps a level from 0 to 1 to a step from 0 to steps.
+func quantize(level float64, steps int) int {
+	return min(max(int(math.Round(level*float64(steps))), 0), steps)
+}
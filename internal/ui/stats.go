@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/haryoiro/yutemal/internal/stats"
+	"github.com/haryoiro/yutemal/internal/structures"
+)
+
+// statsLimit is how many top tracks, artists and albums the stats view
+// shows.
+const statsLimit = 10
+
+// statsSparkline are the bar heights of the listening by hour.
+var statsSparkline = []rune("▁▂▃▄▅▆▇█")
+
+type statsLoadedMsg struct {
+	err   error
+	stats structures.ListeningStats
+}
+
+// openStats switches the main pane to the listening statistics.
+func (m *Model) openStats() (tea.Model, tea.Cmd) {
+	if m.state != StatsView {
+		m.statsReturnState = m.state
+		m.state = StatsView
+	}
+
+	m.statsScroll = 0
+	m.setFocus(FocusMain)
+
+	return m, m.loadStats()
+}
+
+// closeStats returns to the view the statistics were opened from.
+func (m *Model) closeStats() {
+	m.state = m.statsReturnState
+	m.stats = nil
+	m.statsErr = nil
+}
+
+// loadStats computes the statistics of the selected time range, which are
+// the same ranges as the history's.
+func (m *Model) loadStats() tea.Cmd {
+	span := historyRanges[m.statsRange].span
+
+	return func() tea.Msg {
+		now := time.Now()
+
+		var from time.Time
+		if span > 0 {
+			from = now.Add(-span)
+		}
+
+		stats, err := m.systems.Database.GetStats(from, now.Add(time.Minute), statsLimit)
+
+		return statsLoadedMsg{stats: stats, err: err}
+	}
+}
+
+// handleStatsKeys handles keys in the statistics. It reports false for keys
+// that the main pane should handle as usual.
+func (m *Model) handleStatsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	kb := m.config.KeyBindings
+	maxScroll := max(len(m.statsLines(m.width))-m.statsVisibleLines(), 0)
+
+	switch {
+	case m.isKeyInList(msg, kb.MoveUp):
+		m.statsScroll = max(m.statsScroll-1, 0)
+	case m.isKeyInList(msg, kb.MoveDown):
+		m.statsScroll = min(m.statsScroll+1, maxScroll)
+	case m.isKey(msg, "t"):
+		m.statsRange = (m.statsRange + 1) % len(historyRanges)
+		m.statsScroll = 0
+
+		return m, m.loadStats(), true
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// statsVisibleLines is how many report lines fit under the title and hints.
+func (m *Model) statsVisibleLines() int {
+	return max(m.contentHeight-6, 1)
+}
+
+// statsLines renders the report, one string per line, for scrolling.
+func (m *Model) statsLines(maxWidth int) []string {
+	_, selectedStyle, normalStyle, dimStyle, _ := m.getStyles()
+
+	s := m.stats
+	if s == nil {
+		return []string{dimStyle.Render("  Loading…")}
+	}
+
+	if s.Plays == 0 {
+		return []string{dimStyle.Render("  Nothing played in this time range")}
+	}
+
+	width := max(maxWidth-6, 10)
+
+	var lines []string
+
+	section := func(title string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, selectedStyle.Render(title))
+	}
+
+	row := func(label, value string) {
+		label = truncate(label, max(width-runewidth.StringWidth(value)-2, 10))
+		lines = append(lines, normalStyle.Render(" "+label)+dimStyle.Render("  "+value))
+	}
+
+	summary := fmt.Sprintf("%s listened · %d plays · %d tracks · %d artists",
+		stats.FormatHours(s.Played), s.Plays, s.Tracks, s.Artists)
+	lines = append(lines, normalStyle.Render(truncate(summary, width)))
+
+	section("Top tracks")
+
+	for i, t := range s.TopTracks {
+		label := fmt.Sprintf("%2d. %s", i+1, t.Track.Title)
+		if len(t.Track.Artists) > 0 {
+			label += " - " + strings.Join(t.Track.Artists, ", ")
+		}
+
+		row(label, fmt.Sprintf("%d plays, %s", t.Plays, stats.FormatHours(t.Played)))
+	}
+
+	section("Top artists")
+
+	for i, a := range s.TopArtists {
+		row(fmt.Sprintf("%2d. %s", i+1, a.Name), fmt.Sprintf("%d plays, %s", a.Plays, stats.FormatHours(a.Played)))
+	}
+
+	if len(s.TopAlbums) > 0 {
+		section("Top albums")
+
+		for i, a := range s.TopAlbums {
+			label := fmt.Sprintf("%2d. %s", i+1, a.Name)
+			if a.Artist != "" {
+				label += " - " + a.Artist
+			}
+
+			row(label, fmt.Sprintf("%d plays, %s", a.Plays, stats.FormatHours(a.Played)))
+		}
+	}
+
+	section("By hour of day")
+
+	var spark strings.Builder
+
+	busiest := slices.Max(s.ByHour[:])
+	for _, d := range s.ByHour {
+		level := 0
+		if busiest > 0 {
+			level = int(int64(d) * int64(len(statsSparkline)-1) / int64(busiest))
+		}
+
+		spark.WriteRune(statsSparkline[level])
+		spark.WriteRune(statsSparkline[level])
+	}
+
+	lines = append(lines,
+		normalStyle.Render(" "+spark.String()),
+		dimStyle.Render(fmt.Sprintf("  %-12s%-12s%-12s%s", "0", "6", "12", "18")))
+
+	section("By weekday")
+
+	busiest = slices.Max(s.ByWeekday[:])
+	barWidth := max(min(width-20, 30), 1)
+
+	for day, d := range s.ByWeekday {
+		bar := 0
+		if busiest > 0 {
+			bar = int(int64(d) * int64(barWidth) / int64(busiest))
+		}
+
+		lines = append(lines, normalStyle.Render(fmt.Sprintf(" %s %s", time.Weekday(day).String()[:3], strings.Repeat("█", bar)))+
+			dimStyle.Render(" "+stats.FormatHours(d)))
+	}
+
+	section("Longest streaks")
+
+	for _, streak := range s.Streaks[:min(len(s.Streaks), 3)] {
+		days := "days"
+		if streak.Days == 1 {
+			days = "day"
+		}
+
+		row(fmt.Sprintf("%d %s", streak.Days, days), streak.Start.Format("Jan _2, 2006")+" – "+streak.End.Format("Jan _2, 2006"))
+	}
+
+	if len(s.NewArtists) > 0 {
+		section("New artists")
+
+		for _, a := range s.NewArtists {
+			row(a.Name, "first played "+formatPlayedAt(a.First))
+		}
+	}
+
+	return lines
+}
+
+func (m Model) renderStats(maxWidth int) string {
+	titleStyle, _, _, dimStyle, errorStyle := m.getStyles()
+
+	if m.hasFocus("main") {
+		titleStyle = titleStyle.Underline(true)
+	}
+
+	var b strings.Builder
+
+	title := "📊 Stats: " + historyRanges[m.statsRange].label
+
+	b.WriteString("  " + titleStyle.Render(truncate(title, max(maxWidth-4, 10))))
+	b.WriteString("\n")
+	b.WriteString("  " + dimStyle.Render(truncate(m.shortcutFormatter.FormatHints(m.shortcutFormatter.GetStatsHints()), max(maxWidth-4, 10))))
+	b.WriteString("\n\n")
+
+	if m.statsErr != nil {
+		b.WriteString(errorStyle.Render("  Failed to compute the statistics: " + m.statsErr.Error()))
+		return b.String()
+	}
+
+	lines := m.statsLines(maxWidth)
+	start := min(m.statsScroll, max(len(lines)-m.statsVisibleLines(), 0))
+	end := min(start+m.statsVisibleLines(), len(lines))
+
+	b.WriteString(strings.Join(lines[start:end], "\n"))
+
+	return b.String()
+}
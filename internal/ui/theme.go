@@ -150,5 +150,6 @@ func GetDefaultTheme() structures.Theme {
 		ProgressBar:      "#565f89",  // Tokyo Night dark gray
 		ProgressBarFill:  "#7aa2f7",  // Tokyo Night blue
 		ProgressBarStyle: "gradient", // Default to gradient style
+		SpectrumStyle:    "bars",
 	}
 }
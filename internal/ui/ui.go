@@ -25,6 +25,11 @@ const (
 	PlaylistListView ViewState = iota
 	PlaylistDetailView
 	SearchView
+	EQView
+	BookmarksView
+	HistoryView
+	AddToPlaylistView
+	StatsView
 )
 
 func (v ViewState) String() string {
@@ -35,6 +40,16 @@ func (v ViewState) String() string {
 		return "PlaylistDetailView"
 	case SearchView:
 		return "SearchView"
+	case EQView:
+		return "EQView"
+	case BookmarksView:
+		return "BookmarksView"
+	case HistoryView:
+		return "HistoryView"
+	case AddToPlaylistView:
+		return "AddToPlaylistView"
+	case StatsView:
+		return "StatsView"
 	default:
 		return "Unknown"
 	}
@@ -62,6 +77,19 @@ type Model struct {
 	playlistName          string
 	playlistSelectedIndex int
 	playlistScrollOffset  int
+	playlistID            string
+	playlistLocal         bool // kept in the database rather than on YouTube Music
+
+	// Local playlists: naming, deleting and the add to playlist picker
+	playlistNaming    bool
+	playlistNameInput string
+	playlistNameFor   playlistNameAction
+	playlistDeleting  bool // waiting for y to delete the selected playlist
+	pickerReturnState ViewState
+	pickerReturnFocus FocusPane
+	pickerTracks      []structures.Track
+	pickerPlaylists   []systems.Playlist
+	pickerSelected    int
 
 	// Queue display
 	showQueue          bool
@@ -69,6 +97,9 @@ type Model struct {
 	queueScrollOffset  int
 	queueFocused       bool
 	queueSelectedIndex int
+	queueVisual        bool // visual selection from queueAnchor to queueSelectedIndex
+	queueAnchor        int
+	queueDragging      bool
 
 	// Player focus
 	playerFocused bool
@@ -77,6 +108,7 @@ type Model struct {
 	playerState   structures.PlayerState
 	searchQuery   string
 	searchResults []structures.Track
+	searchSource  searchSource
 	err           error
 	marqueeOffset int
 	marqueeTicker *time.Ticker
@@ -86,8 +118,41 @@ type Model struct {
 	// Rainbow seekbar animation
 	rainbowOffset int
 
-	// Equalizer UI state
-	eqPresetIndex int
+	// Index into sleepTimerOptions of the running sleep timer
+	sleepOption int
+
+	// Spectrum analyzer
+	spectrum       []float64
+	spectrumActive bool
+	blurred        bool // the terminal reported losing focus
+
+	// EQ editor
+	eqReturnState  ViewState
+	eqSelectedBand int
+	eqNaming       bool
+	eqPresetName   string
+	eqLayout       eqLayout
+
+	// Bookmarks
+	bookmarkReturnState ViewState
+	bookmarkSelected    int
+	bookmarkNaming      bool
+	bookmarkName        string
+	bookmarkTrack       string
+	bookmarkAt          time.Duration
+
+	// Listening history
+	historyReturnState ViewState
+	history            []structures.HistoryEntry
+	historySelected    int
+	historyRange       int // index into historyRanges
+
+	// Listening statistics
+	statsReturnState ViewState
+	stats            *structures.ListeningStats
+	statsErr         error
+	statsRange       int // index into historyRanges
+	statsScroll      int
 
 	// Unified tick management
 	tickActive bool
@@ -128,6 +193,7 @@ func RunSimple(systems *systems.Systems, config *structures.Config) error {
 	opts := []tea.ProgramOption{
 		tea.WithMouseCellMotion(),
 		tea.WithAltScreen(),
+		tea.WithReportFocus(),
 	}
 
 	p := tea.NewProgram(&m, opts...)
@@ -194,6 +260,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.MouseMsg:
 		return m.handleMouseEvent(msg)
 
+	case tea.FocusMsg:
+		m.blurred = false
+		return m, m.checkSpectrumCmd()
+
+	case tea.BlurMsg:
+		// The analyzer stops on its next frame
+		m.blurred = true
+		return m, nil
+
+	case spectrumMsg:
+		return m, m.updateSpectrum(msg)
+
 	case tickMsg:
 		m.lastUpdate = time.Time(msg)
 
@@ -231,6 +309,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmds []tea.Cmd
 		cmds = append(cmds, m.listenToPlayer())
 		cmds = append(cmds, m.checkMarqueeCmd())
+		cmds = append(cmds, m.checkSpectrumCmd())
 
 		if m.shouldTick() && !m.tickActive {
 			m.tickActive = true
@@ -240,12 +319,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case playlistsLoadedMsg:
+		// Reloaded after a change to the local playlists: keep the selection
 		m.playlists = msg
-		m.selectedIndex = 0
-		m.scrollOffset = 0
+		m.err = nil
+		m.selectedIndex = min(m.selectedIndex, max(len(msg)-1, 0))
+		m.scrollOffset = min(m.scrollOffset, m.selectedIndex)
 
 		return m, nil
 
+	case localPlaylistsMsg:
+		m.pickerPlaylists = msg
+		return m, nil
+
+	case playlistsChangedMsg:
+		return m, m.handlePlaylistsChanged(msg)
+
 	case tracksLoadedMsg:
 		if m.state == SearchView {
 			m.searchResults = msg
@@ -268,6 +356,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return m, m.downloadAllSongs(msg)
 
+	case historyLoadedMsg:
+		if m.state == HistoryView {
+			m.history = msg
+		}
+
+		return m, nil
+
+	case statsLoadedMsg:
+		if m.state == StatsView {
+			m.stats = &msg.stats
+			m.statsErr = msg.err
+		}
+
+		return m, nil
+
 	case errorMsg:
 		m.err = msg
 		return m, nil
@@ -357,6 +460,17 @@ func (m *Model) View() string {
 		content = m.renderPlaylistDetail(mainContentWidth)
 	case SearchView:
 		content = m.renderSearch(mainContentWidth)
+	case EQView:
+		m.eqLayout = m.computeEQLayout(mainContentWidth)
+		content = m.renderEQEditor(mainContentWidth)
+	case BookmarksView:
+		content = m.renderBookmarks(mainContentWidth)
+	case HistoryView:
+		content = m.renderHistory(mainContentWidth)
+	case AddToPlaylistView:
+		content = m.renderPlaylistPicker(mainContentWidth)
+	case StatsView:
+		content = m.renderStats(mainContentWidth)
 	}
 
 	m.playerContentWidth = playerContentWidth
@@ -240,7 +240,7 @@ func (m Model) renderSearch(maxWidth int) string {
 	titleStyle, selectedStyle, normalStyle, dimStyle, _ := m.getStyles()
 
 	var b strings.Builder
-	b.WriteString("  " + titleStyle.Render("🔍 Search"))
+	b.WriteString("  " + titleStyle.Render("🔍 Search: "+m.searchSource.String()))
 	b.WriteString("\n")
 
 	b.WriteString("Query: ")
@@ -345,11 +345,13 @@ func (m Model) renderPlaylistList(maxWidth int) string {
 
 	if m.err != nil {
 		b.WriteString(errorStyle.Render(fmt.Sprintf("⚠️  Error: %v", m.err)))
+		b.WriteString(m.renderPlaylistNamePrompt())
 		return b.String()
 	}
 
 	if len(m.playlists) == 0 {
 		b.WriteString(dimStyle.Render("Loading playlists..."))
+		b.WriteString(m.renderPlaylistNamePrompt())
 		return b.String()
 	}
 
@@ -367,7 +369,12 @@ func (m Model) renderPlaylistList(maxWidth int) string {
 			prefix = " ▶ "
 		}
 
-		displayText := fmt.Sprintf("📁 %s", playlist.Title)
+		icon := "📁"
+		if playlist.Local {
+			icon = "📝"
+		}
+
+		displayText := fmt.Sprintf("%s %s", icon, playlist.Title)
 		if playlist.VideoCount > 0 {
 			displayText += fmt.Sprintf(" (%d tracks)", playlist.VideoCount)
 		}
@@ -387,6 +394,8 @@ func (m Model) renderPlaylistList(maxWidth int) string {
 		b.WriteString(dimStyle.Render(fmt.Sprintf("%d/%d", m.selectedIndex+1, len(m.playlists))))
 	}
 
+	b.WriteString(m.renderPlaylistNamePrompt())
+
 	return b.String()
 }
 
@@ -712,6 +721,10 @@ func (m *Model) renderQueue(maxWidth int, maxHeight int) string {
 			// Selected track in queue (when focused)
 			trackNum = "→   "
 			style = selectedStyle.Background(lipgloss.Color("#44475A"))
+		} else if m.hasFocus("queue") && m.inQueueSelection(displayIdx) {
+			// Part of the visual selection
+			trackNum = "┃   "
+			style = normalStyle.Background(lipgloss.Color("#44475A"))
 		}
 
 		line = trackNum + line
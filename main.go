@@ -46,6 +46,7 @@ func main() {
 	if *showHelp {
 		fmt.Println(banner)
 		fmt.Println("\nUsage: yutemal [OPTIONS]")
+		fmt.Println("       yutemal stats [-range 24h|7d|30d|year|all|<year>] [-limit n] [-json]")
 		fmt.Println("\nOptions:")
 		flag.PrintDefaults()
 		fmt.Println("\nKeyboard shortcuts:")
@@ -140,6 +141,15 @@ func main() {
 		return
 	}
 
+	if flag.Arg(0) == "stats" {
+		if statsErr := runStats(flag.Args()[1:], dataDir); statsErr != nil {
+			fmt.Fprintf(os.Stderr, "yutemal stats: %v\n", statsErr)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if ytDlpErr := checkYtDlp(); ytDlpErr != nil {
 		showYtDlpError()
 		return
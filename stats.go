@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/haryoiro/yutemal/internal/database"
+	"github.com/haryoiro/yutemal/internal/stats"
+)
+
+// runStats prints a report of the listening history: yutemal stats
+// [-range 24h|7d|30d|year|all|<year>] [-limit n] [-json].
+func runStats(args []string, dataDir string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	rangeFlag := fs.String("range", "year", "Time range: 24h, 7d, 30d, year, all, or a calendar year such as 2025")
+	limit := fs.Int("limit", 10, "Number of top tracks, artists and albums")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yutemal stats [OPTIONS]")
+		fmt.Fprintln(fs.Output(), "\nListening statistics from the local play history.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+
+		return err
+	}
+
+	from, to, err := stats.ParseRange(*rangeFlag, time.Now())
+	if err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(dataDir, "yutemal.db")
+	if !fileExists(dbPath) {
+		return fmt.Errorf("no listening history yet (%s does not exist)", dbPath)
+	}
+
+	db, err := database.OpenSQLite(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}
+	defer db.Close()
+
+	report, err := db.GetStats(from, to, *limit)
+	if err != nil {
+		return fmt.Errorf("failed to compute the statistics: %w", err)
+	}
+
+	if *asJSON {
+		return stats.PrintJSON(os.Stdout, report)
+	}
+
+	stats.Print(os.Stdout, report)
+
+	return nil
+}